@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"gva/internal/domain/service"
+	"gva/internal/infrastructure/config"
+	"gva/internal/infrastructure/database"
+)
+
+// audit-verify 校验审计事件哈希链，链完整时退出码为0，发现断开的链接时输出位置和原因并以退出码1退出
+func main() {
+	configFile := flag.String("config", "configs/config.yaml", "配置文件路径")
+	flag.Parse()
+
+	// 加载配置
+	cfg, err := config.LoadConfig(*configFile)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 连接数据库
+	db, err := database.NewMySQLDB(&cfg.MySQL)
+	if err != nil {
+		log.Fatalf("连接数据库失败: %v", err)
+	}
+
+	result, err := service.NewAuditService(db, cfg.Audit).Verify(context.Background())
+	if err != nil {
+		log.Fatalf("校验审计事件失败: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(result); err != nil {
+		log.Fatalf("输出校验结果失败: %v", err)
+	}
+	if !result.Signed {
+		log.Println("未配置 audit.hmac_key，未校验检查点签名")
+	}
+	if !result.Valid {
+		os.Exit(1)
+	}
+}
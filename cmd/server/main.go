@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"gva/internal/domain/cache"
+	"gva/internal/domain/job"
+	"gva/internal/domain/message"
 	"gva/internal/domain/service"
 	infraCache "gva/internal/infrastructure/cache"
 	"gva/internal/infrastructure/config"
 	"gva/internal/infrastructure/database"
+	infraMessage "gva/internal/infrastructure/message"
+	"gva/internal/infrastructure/push"
 	"gva/internal/infrastructure/redis"
 	"gva/internal/infrastructure/repository"
+	"gva/internal/infrastructure/scheduler"
 	"gva/internal/interfaces/router"
+	"gva/internal/pkg/mask"
+	"gva/internal/pkg/redact"
 )
 
 func main() {
@@ -20,6 +34,12 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
+	// 加载脱敏规则
+	mask.Configure(cfg.Mask)
+
+	// 加载操作日志的脱敏规则
+	redact.Configure(cfg.OperationLog)
+
 	// 初始化数据库连接
 	db, err := database.NewMySQLDB(&cfg.MySQL)
 	if err != nil {
@@ -50,13 +70,92 @@ func main() {
 	if rdb != nil {
 		userCache = infraCache.NewRedisUserCache(rdb)
 	}
-	userService := service.NewUserService(userRepo, db, userCache)
+
+	// 收到退出信号时取消 ctx，后台任务随之停止
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 初始化实时推送，配置了Redis时通过发布订阅在多个实例间分发
+	pushHub := push.NewHub(rdb)
+	go pushHub.Run(ctx)
+
+	userService := service.NewUserService(userRepo, db, userCache, pushHub)
+
+	// 初始化通知服务，并定时投递到达发送时间的定时通知
+	notificationService := service.NewNotificationService(db, pushHub)
+	go notificationService.RunScheduler(ctx, 30*time.Second)
+
+	// 初始化消息服务，站内通知渠道始终可用，邮件和Webhook渠道按配置启用
+	senders := []message.Sender{service.NewInAppSender(notificationService)}
+	if cfg.Message.SMTP.Host != "" {
+		senders = append(senders, infraMessage.NewSMTPSender(cfg.Message.SMTP))
+	}
+	if cfg.Message.Webhook.URL != "" {
+		senders = append(senders, infraMessage.NewWebhookSender(cfg.Message.Webhook))
+	}
+	messageService := service.NewMessageService(db, cfg.Message.DefaultLocale, senders...)
+	go messageService.RunRetry(ctx, time.Minute)
+
+	// 操作日志按保留策略归档，由定时任务或管理接口触发，配置了Redis时多个实例同一时间只有一个实例归档
+	var archiveLocker cache.Locker
+	if rdb != nil {
+		archiveLocker = infraCache.NewRedisLocker(rdb)
+	}
+	logArchiver := service.NewOperationLogArchiver(db, cfg.OperationLog.Retention, archiveLocker)
+
+	// 审计事件组成哈希链，定时任务定期对链头签名生成检查点
+	auditService := service.NewAuditService(db, cfg.Audit)
+
+	// 注册定时任务可引用的任务类型
+	jobRegistry := job.NewRegistry()
+	if err := service.RegisterTaskJobs(jobRegistry, db, logArchiver, auditService); err != nil {
+		log.Fatalf("注册任务类型失败: %v", err)
+	}
+
+	// 初始化定时任务调度器和管理服务，配置了Redis时多个实例通过租约选出一个实例执行任务，
+	// 任务最终执行失败时通过站内通知告警负责人
+	taskScheduler := scheduler.New(db, rdb, jobRegistry, service.NewTaskAlerter(notificationService))
+	taskService := service.NewTaskService(db, taskScheduler, jobRegistry)
+
+	// 初始化操作日志服务，日志先进入有界队列，由后台协程批量写入
+	logService := service.NewOperationLogService(repository.NewOperationLogRepository(db), cfg.OperationLog)
+	logService.Start()
 
 	// 初始化路由
-	r := router.InitRouter(db, rdb, userService)
+	r := router.InitRouter(db, rdb, userService, notificationService, messageService, taskService, logService, logArchiver, auditService, pushHub)
+
+	// 启动定时任务调度器
+	if err := taskScheduler.Start(ctx); err != nil {
+		log.Fatalf("启动定时任务调度器失败: %v", err)
+	}
 
 	// 启动服务器
-	if err := r.Run(":" + cfg.Server.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Println("正在关闭服务...")
+
+	// 先停止接收新请求，再等待正在执行的定时任务结束
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("关闭HTTP服务失败: %v", err)
+	}
+	// 所有请求结束后写入队列中剩余的操作日志
+	if err := logService.Close(shutdownCtx); err != nil {
+		log.Printf("写入剩余操作日志超时: %v", err)
+	}
+	if err := taskScheduler.Stop(shutdownCtx); err != nil {
+		log.Printf("等待定时任务结束超时，已取消正在执行的任务: %v", err)
 	}
+	log.Println("服务已关闭")
 }
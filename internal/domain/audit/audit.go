@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"sort"
+)
+
+// 审计的实体类型
+const (
+	EntityUser       = "user"
+	EntityRole       = "role"
+	EntityPermission = "permission"
+)
+
+// 审计的操作
+const (
+	ActionUpdate            = "update"             // 修改基本信息
+	ActionUpdateStatus      = "update_status"      // 修改状态
+	ActionAssignPermissions = "assign_permissions" // 分配权限
+	ActionDelete            = "delete"             // 删除
+	ActionClone             = "clone"              // 复制
+	ActionImport            = "import"             // 通过导入新建或修改
+)
+
+// Actor 执行操作的用户
+type Actor struct {
+	UserID uint
+	IP     string
+}
+
+type actorKey struct{}
+
+// WithActor 将操作人存入上下文，由认证中间件在请求开始时设置
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom 获取上下文中的操作人，定时任务等系统操作没有操作人，返回零值
+func ActorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// Change 一个字段的变更
+type Change struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff 比较变更前后的字段值，返回值发生变化的字段，按字段名排序
+// 只在一侧出现的字段视为从空值变更而来或变更为空值
+func Diff(before, after map[string]interface{}) []Change {
+	fields := make(map[string]bool, len(before)+len(after))
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	changes := make([]Change, 0)
+	for field := range fields {
+		if !equal(before[field], after[field]) {
+			changes = append(changes, Change{Field: field, Before: before[field], After: after[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// equal 比较两个字段值，空切片与 nil 切片视为相同
+func equal(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() == reflect.Slice && vb.Kind() == reflect.Slice && va.Len() == 0 && vb.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
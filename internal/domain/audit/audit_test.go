@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{
+		"name":          "admin",
+		"status":        1,
+		"department_id": nil,
+		"position_ids":  []uint(nil),
+		"permissions":   []string{"a", "b"},
+	}
+	after := map[string]interface{}{
+		"name":          "admin",
+		"status":        0,
+		"department_id": uint(3),
+		"position_ids":  []uint{},
+		"permissions":   []string{"b"},
+	}
+
+	assert.Equal(t, []Change{
+		{Field: "department_id", Before: nil, After: uint(3)},
+		{Field: "permissions", Before: []string{"a", "b"}, After: []string{"b"}},
+		{Field: "status", Before: 1, After: 0},
+	}, Diff(before, after))
+	assert.Empty(t, Diff(before, before))
+}
+
+func TestActor(t *testing.T) {
+	assert.Equal(t, Actor{}, ActorFrom(context.Background()))
+
+	ctx := WithActor(context.Background(), Actor{UserID: 1, IP: "127.0.0.1"})
+	assert.Equal(t, Actor{UserID: 1, IP: "127.0.0.1"}, ActorFrom(ctx))
+}
@@ -0,0 +1,13 @@
+package cache
+
+import (
+	"context"
+	"gva/internal/domain/entity"
+)
+
+// DictCache 数据字典缓存接口，缓存的字典只包含已启用的字典项
+type DictCache interface {
+	GetDict(ctx context.Context, code string) (*entity.Dict, error)
+	SetDict(ctx context.Context, dict *entity.Dict) error
+	DeleteDict(ctx context.Context, codes ...string) error
+}
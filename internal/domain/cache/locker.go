@@ -0,0 +1,13 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Locker 多个实例共享的互斥锁
+type Locker interface {
+	// TryLock 尝试获取 key 对应的锁，锁已被其他持有者持有时返回 false
+	// 获取成功时返回释放函数，持有期间自动续期，持有者宕机时锁在 ttl 后过期
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuditChainEvents 审计事件哈希链的名称
+const AuditChainEvents = "audit_events"
+
+// AuditEvent 审计事件，记录谁对哪个实体做了什么操作，以及字段级的变更
+// 索引对应常用查询：查看某个实体的变更历史，查看某个用户做过的操作
+// 每条事件通过 PrevHash 引用上一条事件的哈希，组成哈希链，修改或删除任意一条都会使后续的链接断开
+type AuditEvent struct {
+	ID         uint            `json:"id" gorm:"primarykey"`
+	ActorID    uint            `json:"actor_id" gorm:"index:idx_audit_event_actor_time,priority:1"` // 操作人，系统操作时为0
+	ActorIP    string          `json:"actor_ip" gorm:"size:64"`
+	EntityType string          `json:"entity_type" gorm:"size:32;index:idx_audit_event_entity,priority:1"` // 实体类型，如 user、role
+	EntityID   uint            `json:"entity_id" gorm:"index:idx_audit_event_entity,priority:2"`
+	Action     string          `json:"action" gorm:"size:32"`
+	Changes    json.RawMessage `json:"changes" gorm:"type:text"` // 字段级变更，如 [{"field":"status","before":1,"after":0}]
+	PrevHash   string          `json:"prev_hash" gorm:"size:64"` // 上一条事件的哈希，第一条事件为空
+	Hash       string          `json:"hash" gorm:"size:64"`      // 上一条事件的哈希与本条内容的 SHA-256
+	CreatedAt  time.Time       `json:"created_at" gorm:"index:idx_audit_event_entity,priority:3;index:idx_audit_event_actor_time,priority:2"`
+}
+
+// ComputeHash 计算事件的哈希，覆盖 PrevHash 和除ID、Hash 外的全部内容
+// 创建时间精确到毫秒，与数据库保存的精度一致
+func (e *AuditEvent) ComputeHash() string {
+	content, _ := json.Marshal(struct {
+		PrevHash   string `json:"prev_hash"`
+		ActorID    uint   `json:"actor_id"`
+		ActorIP    string `json:"actor_ip"`
+		EntityType string `json:"entity_type"`
+		EntityID   uint   `json:"entity_id"`
+		Action     string `json:"action"`
+		Changes    string `json:"changes"`
+		CreatedAt  string `json:"created_at"`
+	}{
+		PrevHash:   e.PrevHash,
+		ActorID:    e.ActorID,
+		ActorIP:    e.ActorIP,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Action:     e.Action,
+		Changes:    string(e.Changes),
+		CreatedAt:  e.CreatedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditChainHead 哈希链的链头，记录最后一条事件，追加事件时加行锁保证链的顺序
+type AuditChainHead struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Name      string    `json:"name" gorm:"size:32;uniqueIndex"`
+	LastID    uint      `json:"last_id"`
+	LastHash  string    `json:"last_hash" gorm:"size:64"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AuditCheckpoint 哈希链的检查点，使用 HMAC 对某一时刻的链头签名
+// 没有密钥无法伪造检查点，即使重新计算了整条链，也无法与已签名的检查点一致
+type AuditCheckpoint struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	LastEventID uint      `json:"last_event_id" gorm:"index"`
+	LastHash    string    `json:"last_hash" gorm:"size:64"`
+	Signature   string    `json:"signature" gorm:"size:64"` // HMAC-SHA256(LastEventID:LastHash:CreatedAt)
+	CreatedAt   time.Time `json:"created_at"`
+}
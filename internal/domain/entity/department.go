@@ -8,12 +8,14 @@ type Department struct {
 	Name        string `gorm:"size:64;not null" json:"name"`    // 部门名称
 	Code        string `gorm:"size:64;uniqueIndex" json:"code"` // 部门编码
 	ParentID    *uint  `json:"parent_id"`                       // 父部门ID
-	Path        string `gorm:"size:255" json:"path"`            // 部门路径
+	Path        string `gorm:"size:255;index" json:"path"`      // 部门路径，如 /1/3/，包含自身及所有上级部门ID
 	Leader      string `gorm:"size:64" json:"leader"`           // 部门负责人
 	Phone       string `gorm:"size:32" json:"phone"`            // 联系电话
 	Email       string `gorm:"size:128" json:"email"`           // 邮箱
 	Sort        int    `gorm:"default:0" json:"sort"`           // 排序
 	Status      int    `gorm:"default:1" json:"status"`         // 状态
 	Description string `gorm:"size:256" json:"description"`     // 描述
-	Users       []User `json:"users"`                           // 部门用户
+	Users       []User `json:"users,omitempty"`                 // 部门用户
+
+	Children []*Department `gorm:"-" json:"children,omitempty"` // 子部门（仅用于组装部门树）
 }
@@ -15,7 +15,7 @@ type Dict struct {
 // DictItem 字典项
 type DictItem struct {
 	gorm.Model
-	DictID      uint   `json:"dict_id"`                        // 所属字典ID
+	DictID      uint   `gorm:"index" json:"dict_id"`           // 所属字典ID
 	Label       string `gorm:"size:128;not null" json:"label"` // 标签
 	Value       string `gorm:"size:128;not null" json:"value"` // 值
 	Sort        int    `gorm:"default:0" json:"sort"`          // 排序
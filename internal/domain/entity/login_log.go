@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+)
+
+// 登录结果
+const (
+	LoginResultSuccess       = "success"        // 登录成功
+	LoginResultWrongPassword = "wrong_password" // 密码错误
+	LoginResultNotFound      = "not_found"      // 用户不存在
+	LoginResultFrozen        = "frozen"         // 账号已冻结
+	LoginResultDisabled      = "disabled"       // 账号已禁用
+	LoginResultPending       = "pending"        // 账号未通过审核
+	LoginResultError         = "error"          // 系统错误
+)
+
+// LoginLog 登录日志，记录每一次登录尝试
+// 索引对应常用查询：按用户名或用户查看最近的登录记录，按结果、IP 筛选
+type LoginLog struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Username  string    `json:"username" gorm:"size:64;index:idx_login_log_username_time,priority:1"` // 尝试登录的用户名
+	UserID    uint      `json:"user_id" gorm:"index:idx_login_log_user_time,priority:1"`              // 用户名对应的用户，用户不存在时为0
+	Result    string    `json:"result" gorm:"size:32;index:idx_login_log_result_time,priority:1"`
+	IP        string    `json:"ip" gorm:"size:64;index:idx_login_log_ip"`
+	UserAgent string    `json:"user_agent" gorm:"size:512"`
+	Browser   string    `json:"browser" gorm:"size:64"` // 从 User-Agent 解析出的浏览器
+	OS        string    `json:"os" gorm:"size:64"`      // 从 User-Agent 解析出的操作系统
+	Device    string    `json:"device" gorm:"size:16"`  // 从 User-Agent 解析出的设备类型
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_login_log_username_time,priority:2;index:idx_login_log_user_time,priority:2;index:idx_login_log_result_time,priority:2;index"`
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 消息渠道
+const (
+	MessageChannelInApp   = "inapp"   // 站内通知
+	MessageChannelEmail   = "email"   // 邮件
+	MessageChannelWebhook = "webhook" // Webhook
+)
+
+// 消息投递状态
+const (
+	MessageDeliveryPending = 0 // 待投递（包括等待重试）
+	MessageDeliverySuccess = 1 // 投递成功
+	MessageDeliveryFailed  = 2 // 投递失败（已达最大重试次数）
+)
+
+// MessageTemplate 消息模板，主题和正文使用 text/template 语法，如 {{.Username}}
+type MessageTemplate struct {
+	gorm.Model
+	Code             string                   `gorm:"size:64;uniqueIndex;not null" json:"code"`        // 模板编码
+	Name             string                   `gorm:"size:64;not null" json:"name"`                    // 模板名称
+	Channels         string                   `gorm:"size:128" json:"channels"`                        // 默认投递渠道，逗号分隔
+	NotificationType string                   `gorm:"size:32;default:system" json:"notification_type"` // 站内通知的类型
+	Status           int                      `gorm:"default:1" json:"status"`                         // 状态
+	Description      string                   `gorm:"size:256" json:"description"`                     // 描述
+	Contents         []MessageTemplateContent `gorm:"foreignKey:TemplateID" json:"contents"`           // 各语言的内容
+}
+
+// MessageTemplateContent 消息模板在某一语言下的内容
+type MessageTemplateContent struct {
+	gorm.Model
+	TemplateID uint   `gorm:"uniqueIndex:idx_template_locale;not null" json:"template_id"`    // 模板ID
+	Locale     string `gorm:"size:16;uniqueIndex:idx_template_locale;not null" json:"locale"` // 语言，如 zh-CN、en-US
+	Subject    string `gorm:"size:256" json:"subject"`                                        // 主题
+	Body       string `gorm:"type:text" json:"body"`                                          // 正文
+}
+
+// MessageDelivery 消息按接收用户和渠道的投递记录
+type MessageDelivery struct {
+	gorm.Model
+	TemplateID   uint       `gorm:"index" json:"template_id"`                         // 模板ID
+	TemplateCode string     `gorm:"size:64;index" json:"template_code"`               // 模板编码
+	UserID       uint       `gorm:"index" json:"user_id"`                             // 接收用户ID
+	Channel      string     `gorm:"size:16;index" json:"channel"`                     // 投递渠道
+	Recipient    string     `gorm:"size:256" json:"recipient"`                        // 接收地址，如邮箱
+	Locale       string     `gorm:"size:16" json:"locale"`                            // 使用的语言
+	Subject      string     `gorm:"size:256" json:"subject"`                          // 渲染后的主题
+	Body         string     `gorm:"type:text" json:"body"`                            // 渲染后的正文
+	Status       int        `gorm:"default:0;index:idx_delivery_retry" json:"status"` // 投递状态
+	Attempts     int        `gorm:"default:0" json:"attempts"`                        // 已尝试次数
+	LastError    string     `gorm:"size:512" json:"last_error"`                       // 最近一次失败原因
+	NextRetryAt  *time.Time `gorm:"index:idx_delivery_retry" json:"next_retry_at"`    // 下次重试时间
+	SentAt       *time.Time `json:"sent_at"`                                          // 投递成功时间
+}
@@ -6,23 +6,53 @@ import (
 	"gorm.io/gorm"
 )
 
+// 通知状态
+const (
+	NotificationStatusDraft     = 0 // 草稿
+	NotificationStatusSent      = 1 // 已发送
+	NotificationStatusScheduled = 2 // 待发送（定时发送）
+)
+
+// 通知接收对象类型
+const (
+	NotificationTargetUser       = "user"       // 指定用户
+	NotificationTargetAll        = "all"        // 全体用户
+	NotificationTargetRole       = "role"       // 指定角色
+	NotificationTargetDepartment = "department" // 指定部门（包含下级部门）
+)
+
+// 通知阅读状态
+const (
+	NotificationUnread = 0 // 未读
+	NotificationRead   = 1 // 已读
+)
+
+// NotificationTypeDictCode 通知类型对应的数据字典编码
+const NotificationTypeDictCode = "notification_type"
+
 // Notification 通知
 type Notification struct {
 	gorm.Model
-	Title     string `gorm:"size:128;not null" json:"title"`                 // 标题
-	Content   string `gorm:"type:text" json:"content"`                       // 内容
-	Type      string `gorm:"size:32" json:"type"`                            // 通知类型
-	Status    int    `gorm:"default:0" json:"status"`                        // 状态
-	SenderID  uint   `json:"sender_id"`                                      // 发送者ID
-	Sender    User   `json:"sender"`                                         // 发送者
-	Receivers []User `gorm:"many2many:user_notifications;" json:"receivers"` // 接收者
+	Title     string `gorm:"size:128;not null" json:"title"`                           // 标题
+	Content   string `gorm:"type:text" json:"content"`                                 // 内容
+	Type      string `gorm:"size:32" json:"type"`                                      // 通知类型
+	Status    int    `gorm:"default:0" json:"status"`                                  // 状态
+	SenderID  uint   `json:"sender_id"`                                                // 发送者ID
+	Sender    *User  `json:"sender,omitempty"`                                         // 发送者
+	Receivers []User `gorm:"many2many:user_notifications;" json:"receivers,omitempty"` // 接收者
+
+	TargetType  string     `gorm:"size:16;default:user;index" json:"target_type"` // 接收对象类型
+	Targets     string     `gorm:"type:text" json:"targets"`                      // 接收对象，逗号分隔的用户ID、角色编码或部门ID
+	ScheduledAt *time.Time `gorm:"index" json:"scheduled_at"`                     // 定时发送时间，为空时立即发送
+	SentAt      *time.Time `json:"sent_at"`                                       // 实际发送时间
+	DeliveryID  *uint      `gorm:"uniqueIndex" json:"delivery_id,omitempty"`      // 由消息投递创建时的投递记录ID，保证重复投递时只创建一次
 }
 
 // UserNotification 用户通知关联
 type UserNotification struct {
 	gorm.Model
-	UserID         uint       `json:"user_id"`                      // 用户ID
-	NotificationID uint       `json:"notification_id"`              // 通知ID
-	ReadStatus     int        `gorm:"default:0" json:"read_status"` // 阅读状态
-	ReadTime       *time.Time `json:"read_time"`                    // 阅读时间
+	UserID         uint       `gorm:"uniqueIndex:idx_user_notification;index:idx_user_read" json:"user_id"` // 用户ID
+	NotificationID uint       `gorm:"uniqueIndex:idx_user_notification;index" json:"notification_id"`       // 通知ID
+	ReadStatus     int        `gorm:"default:0;index:idx_user_read" json:"read_status"`                     // 阅读状态
+	ReadTime       *time.Time `json:"read_time"`                                                            // 阅读时间
 }
@@ -5,17 +5,18 @@ import (
 )
 
 // OperationLog 操作日志
+// 索引对应操作日志的常用查询：按用户、IP、路径前缀、状态码筛选，并按时间范围过滤和排序
 type OperationLog struct {
 	ID        uint      `json:"id" gorm:"primarykey"`
-	UserID    uint      `json:"user_id"`
+	UserID    uint      `json:"user_id" gorm:"index:idx_operation_log_user_time,priority:1"`
 	User      *User     `json:"user" gorm:"foreignKey:UserID"`
-	IP        string    `json:"ip"`
-	Method    string    `json:"method"`
-	Path      string    `json:"path"`
-	Status    int       `json:"status"`
+	IP        string    `json:"ip" gorm:"size:64;index:idx_operation_log_ip"`
+	Method    string    `json:"method" gorm:"size:16"`
+	Path      string    `json:"path" gorm:"size:255;index:idx_operation_log_path"`
+	Status    int       `json:"status" gorm:"index:idx_operation_log_status_time,priority:1"`
 	Latency   int64     `json:"latency"` // 请求耗时（毫秒）
 	UserAgent string    `json:"user_agent"`
 	Request   string    `json:"request"`  // 请求参数
 	Response  string    `json:"response"` // 响应内容
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_operation_log_user_time,priority:2;index:idx_operation_log_status_time,priority:2;index"`
 }
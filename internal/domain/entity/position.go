@@ -0,0 +1,15 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Position 岗位
+type Position struct {
+	gorm.Model
+	Code         string      `gorm:"size:64;uniqueIndex;not null" json:"code"`            // 岗位编码
+	Name         string      `gorm:"size:64;not null" json:"name"`                        // 岗位名称
+	DepartmentID *uint       `gorm:"index" json:"department_id"`                          // 所属部门ID，为空表示通用岗位
+	Department   *Department `gorm:"foreignKey:DepartmentID" json:"department,omitempty"` // 所属部门
+	Sort         int         `gorm:"default:0" json:"sort"`                               // 排序
+	Status       int         `gorm:"default:1" json:"status"`                             // 状态
+	Description  string      `gorm:"size:256" json:"description"`                         // 描述
+}
@@ -2,6 +2,13 @@ package entity
 
 import "gorm.io/gorm"
 
+// 系统内置角色编码
+const (
+	RoleCodeSuperAdmin = "super_admin" // 超级管理员
+	RoleCodeAdmin      = "admin"       // 管理员
+	RoleCodeUser       = "user"        // 普通用户，注册时的默认角色
+)
+
 type Role struct {
 	gorm.Model
 	Name        string       `json:"name" gorm:"size:64;uniqueIndex;not null"`
@@ -13,3 +20,12 @@ type Role struct {
 	Description string       `json:"description" gorm:"size:256"`
 	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions;"` // 角色拥有的权限
 }
+
+// IsSystem 是否为系统内置角色
+func (r *Role) IsSystem() bool {
+	switch r.Code {
+	case RoleCodeSuperAdmin, RoleCodeAdmin, RoleCodeUser:
+		return true
+	}
+	return false
+}
@@ -1,29 +1,68 @@
 package entity
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// 任务执行状态
+const (
+	TaskLogRunning = 0 // 执行中
+	TaskLogSuccess = 1 // 执行成功
+	TaskLogFailed  = 2 // 执行失败
+	TaskLogSkipped = 3 // 未执行（上一次执行尚未结束等原因）
+)
+
+// 任务触发方式
+const (
+	TaskTriggerSchedule = "schedule" // 按计划触发
+	TaskTriggerManual   = "manual"   // 手动触发
+)
+
+// 任务重叠执行策略，决定上一次执行尚未结束时如何处理新的触发
+const (
+	TaskOverlapSkip  = "skip"  // 跳过本次触发
+	TaskOverlapQueue = "queue" // 等待上一次执行结束后再执行
+	TaskOverlapAllow = "allow" // 允许同时执行
+)
+
 // Task 定时任务
 type Task struct {
 	gorm.Model
-	Name        string     `gorm:"size:64;not null" json:"name"`     // 任务名称
-	Cron        string     `gorm:"size:64;not null" json:"cron"`     // cron表达式
-	Command     string     `gorm:"size:512;not null" json:"command"` // 执行命令
-	Status      int        `gorm:"default:1" json:"status"`          // 状态
-	Description string     `gorm:"size:256" json:"description"`      // 描述
-	LastRunTime *time.Time `json:"last_run_time"`                    // 上次执行时间
-	NextRunTime *time.Time `json:"next_run_time"`                    // 下次执行时间
+	Name        string          `gorm:"size:64;not null" json:"name"`          // 任务名称
+	Cron        string          `gorm:"size:64;not null" json:"cron"`          // cron表达式，包含秒，如 0 */5 * * * *
+	Handler     string          `gorm:"size:64;not null;index" json:"handler"` // 任务类型，对应代码中注册的任务处理器
+	Params      json.RawMessage `gorm:"type:text" json:"params"`               // 任务参数（JSON），保存时已按任务类型的参数定义校验
+	Status      int             `gorm:"default:1" json:"status"`               // 状态
+	Description string          `gorm:"size:256" json:"description"`           // 描述
+	LastRunTime *time.Time      `json:"last_run_time"`                         // 上次执行时间
+	NextRunTime *time.Time      `json:"next_run_time"`                         // 下次执行时间
+
+	OwnerID       uint   `gorm:"index" json:"owner_id"`                      // 负责人，任务最终执行失败时通知该用户，为空时通知超级管理员
+	Timeout       int    `gorm:"default:0" json:"timeout"`                   // 单次执行的超时时间（秒），0 表示不限制
+	MaxRetries    int    `gorm:"default:0" json:"max_retries"`               // 执行失败后的最大重试次数
+	RetryInterval int    `gorm:"default:0" json:"retry_interval"`            // 第一次重试前的等待时间（秒），之后每次翻倍，0 表示使用默认值
+	OverlapPolicy string `gorm:"size:16;default:skip" json:"overlap_policy"` // 重叠执行策略
+
+	FencingToken int64 `gorm:"default:0" json:"fencing_token"` // 最近一次按计划执行时主节点租约的 fencing token，用于拒绝过期主节点的执行
 }
 
 // TaskLog 任务执行日志
 type TaskLog struct {
 	gorm.Model
-	TaskID    uint      `json:"task_id"`                 // 任务ID
-	Status    int       `json:"status"`                  // 执行状态
-	Result    string    `gorm:"type:text" json:"result"` // 执行结果
-	StartTime time.Time `json:"start_time"`              // 开始时间
-	EndTime   time.Time `json:"end_time"`                // 结束时间
+	TaskID    uint      `gorm:"index" json:"task_id"`     // 任务ID
+	Status    int       `json:"status"`                   // 执行状态
+	Trigger   string    `gorm:"size:16" json:"trigger"`   // 触发方式
+	Result    string    `gorm:"type:text" json:"result"`  // 执行结果
+	StartTime time.Time `json:"start_time"`               // 开始时间
+	EndTime   time.Time `json:"end_time"`                 // 结束时间
+	Duration  int64     `json:"duration"`                 // 执行耗时（毫秒）
+	Attempt   int       `gorm:"default:1" json:"attempt"` // 第几次尝试，从 1 开始
+	RetryOf   uint      `gorm:"index" json:"retry_of"`    // 重试时为第一次尝试的日志ID
+
+	InstanceID   string `gorm:"size:64;index" json:"instance_id"` // 执行任务的实例
+	LockHolder   string `gorm:"size:128" json:"lock_holder"`      // 执行时持有的主节点租约，手动执行时为空
+	FencingToken int64  `json:"fencing_token"`                    // 执行时租约的 fencing token
 }
@@ -1,11 +1,31 @@
 package entity
 
 import (
+	"encoding/json"
 	"time"
 
+	"gva/internal/pkg/mask"
+
 	"gorm.io/gorm"
 )
 
+// 用户状态，取值与数据字典 user_status 的字典项一致
+const (
+	UserStatusDisabled = 0 // 禁用
+	UserStatusNormal   = 1 // 正常
+	UserStatusFrozen   = 2 // 冻结
+	UserStatusPending  = 3 // 待审核
+)
+
+// UserStatusDictCode 用户状态对应的数据字典编码
+const UserStatusDictCode = "user_status"
+
+// 用户的敏感字段，序列化时默认脱敏，拥有字段级权限时通过 Reveal 显示明文
+const (
+	UserFieldPhone = "phone"
+	UserFieldEmail = "email"
+)
+
 type User struct {
 	gorm.Model
 	Username  string         `json:"username" gorm:"size:64;uniqueIndex;not null"`
@@ -20,4 +40,44 @@ type User struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	DepartmentID *uint       `json:"department_id" gorm:"index"`                           // 所属部门ID
+	Department   *Department `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`  // 所属部门
+	Positions    []Position  `json:"positions,omitempty" gorm:"many2many:user_positions;"` // 岗位
+
+	reveal userReveal // 序列化时显示明文的敏感字段
+}
+
+type userReveal struct {
+	phone bool
+	email bool
+}
+
+// Reveal 允许序列化时显示敏感字段的明文，用于当前用户拥有字段级权限的场景
+func (u *User) Reveal(fields ...string) {
+	for _, field := range fields {
+		switch field {
+		case UserFieldPhone:
+			u.reveal.phone = true
+		case UserFieldEmail:
+			u.reveal.email = true
+		}
+	}
+}
+
+// Masked 返回敏感字段脱敏后的副本，通过 Reveal 允许显示明文的字段保持不变
+func (u User) Masked() User {
+	if !u.reveal.phone {
+		u.Phone = mask.Field(UserFieldPhone, u.Phone)
+	}
+	if !u.reveal.email {
+		u.Email = mask.Field(UserFieldEmail, u.Email)
+	}
+	return u
+}
+
+// MarshalJSON 序列化时对敏感字段脱敏，通知的发送人、操作日志的操作人等预加载的用户信息同样不会泄露明文
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return json.Marshal(plain(u.Masked()))
 }
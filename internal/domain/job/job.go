@@ -0,0 +1,233 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// 定义任务类型相关的错误
+var (
+	ErrHandlerNotFound = errors.New("未注册的任务类型")
+	ErrInvalidParams   = errors.New("任务参数无效")
+	ErrBusy            = errors.New("任务正在执行")
+)
+
+// RunFunc 任务的执行函数，返回的字符串会记录到任务日志中
+type RunFunc func(ctx context.Context, params json.RawMessage) (string, error)
+
+// Definition 一种可由定时任务引用的任务类型
+type Definition struct {
+	Name        string  `json:"name"`        // 任务类型名称，Task.Handler 引用该名称
+	Title       string  `json:"title"`       // 显示名称
+	Description string  `json:"description"` // 描述
+	Params      Schema  `json:"params"`      // 参数定义
+	Run         RunFunc `json:"-"`
+}
+
+// Typed 将接收强类型参数的函数转换为 RunFunc，参数在执行前已通过校验并填充默认值
+func Typed[P any](fn func(ctx context.Context, params P) (string, error)) RunFunc {
+	return func(ctx context.Context, raw json.RawMessage) (string, error) {
+		var params P
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return "", fmt.Errorf("%w: %v", ErrInvalidParams, err)
+			}
+		}
+		return fn(ctx, params)
+	}
+}
+
+// Registry 任务类型注册表
+type Registry struct {
+	mu          sync.RWMutex
+	definitions map[string]*Definition
+}
+
+func NewRegistry() *Registry {
+	return &Registry{definitions: make(map[string]*Definition)}
+}
+
+// Register 注册任务类型，名称重复时返回错误
+func (r *Registry) Register(def Definition) error {
+	if def.Name == "" || def.Run == nil {
+		return errors.New("任务类型缺少名称或执行函数")
+	}
+	if def.Params.Type == "" {
+		def.Params.Type = "object"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.definitions[def.Name]; ok {
+		return fmt.Errorf("任务类型 %s 已注册", def.Name)
+	}
+	r.definitions[def.Name] = &def
+	return nil
+}
+
+// Get 获取任务类型
+func (r *Registry) Get(name string) (*Definition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	def, ok := r.definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrHandlerNotFound, name)
+	}
+	return def, nil
+}
+
+// List 按名称排序返回所有任务类型
+func (r *Registry) List() []*Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := make([]*Definition, 0, len(r.definitions))
+	for _, def := range r.definitions {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// Validate 按任务类型的参数定义校验参数，返回填充了默认值的参数
+func (r *Registry) Validate(name string, params json.RawMessage) (json.RawMessage, error) {
+	def, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return def.Params.Validate(params)
+}
+
+// Run 校验参数后执行任务，任务内的 panic 会被转换为错误
+func (r *Registry) Run(ctx context.Context, name string, params json.RawMessage) (output string, err error) {
+	def, err := r.Get(name)
+	if err != nil {
+		return "", err
+	}
+	params, err = def.Params.Validate(params)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("任务执行异常: %v", p)
+		}
+	}()
+	return def.Run(ctx, params)
+}
+
+// Schema 任务参数定义，格式为 JSON Schema 的子集（仅支持一层对象）
+type Schema struct {
+	Type       string              `json:"type"` // 固定为 object
+	Properties map[string]Property `json:"properties,omitempty"`
+	Required   []string            `json:"required,omitempty"`
+}
+
+// Property 单个参数的定义
+type Property struct {
+	Type        string      `json:"type"` // string、integer、number、boolean
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Minimum     *float64    `json:"minimum,omitempty"`
+	Maximum     *float64    `json:"maximum,omitempty"`
+	Enum        []string    `json:"enum,omitempty"` // 仅用于 string
+}
+
+// Validate 校验参数：不允许未定义的参数，检查必填项、类型、取值范围，并填充默认值
+func (s Schema) Validate(raw json.RawMessage) (json.RawMessage, error) {
+	values := map[string]interface{}{}
+	if len(bytes.TrimSpace(raw)) > 0 && string(bytes.TrimSpace(raw)) != "null" {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&values); err != nil {
+			return nil, fmt.Errorf("%w: 参数必须是JSON对象", ErrInvalidParams)
+		}
+	}
+
+	for name := range values {
+		if _, ok := s.Properties[name]; !ok {
+			return nil, fmt.Errorf("%w: 未定义的参数 %s", ErrInvalidParams, name)
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("%w: 缺少参数 %s", ErrInvalidParams, name)
+		}
+	}
+
+	for name, prop := range s.Properties {
+		value, ok := values[name]
+		if !ok {
+			if prop.Default != nil {
+				values[name] = prop.Default
+			}
+			continue
+		}
+		if err := prop.check(value); err != nil {
+			return nil, fmt.Errorf("%w: 参数 %s %v", ErrInvalidParams, name, err)
+		}
+	}
+
+	normalized, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+	return normalized, nil
+}
+
+func (p Property) check(value interface{}) error {
+	switch p.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return errors.New("必须是字符串")
+		}
+		if len(p.Enum) > 0 {
+			for _, e := range p.Enum {
+				if s == e {
+					return nil
+				}
+			}
+			return fmt.Errorf("必须是 %v 之一", p.Enum)
+		}
+		return nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return errors.New("必须是布尔值")
+		}
+		return nil
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return errors.New("必须是数字")
+		}
+		if p.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				return errors.New("必须是整数")
+			}
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return errors.New("必须是数字")
+		}
+		if p.Minimum != nil && f < *p.Minimum {
+			return fmt.Errorf("不能小于 %v", *p.Minimum)
+		}
+		if p.Maximum != nil && f > *p.Maximum {
+			return fmt.Errorf("不能大于 %v", *p.Maximum)
+		}
+		return nil
+	default:
+		return fmt.Errorf("类型 %s 不受支持", p.Type)
+	}
+}
+
+// Float 返回 float64 指针，便于定义 Minimum、Maximum
+func Float(v float64) *float64 {
+	return &v
+}
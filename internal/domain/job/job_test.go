@@ -0,0 +1,79 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := Schema{
+		Type: "object",
+		Properties: map[string]Property{
+			"days":  {Type: "integer", Default: 30, Minimum: Float(1), Maximum: Float(365)},
+			"scope": {Type: "string", Enum: []string{"all", "failed"}},
+			"dry":   {Type: "boolean"},
+		},
+		Required: []string{"scope"},
+	}
+
+	tests := []struct {
+		params string
+		want   string
+		ok     bool
+	}{
+		{`{"scope":"all"}`, `{"days":30,"scope":"all"}`, true},
+		{`{"scope":"failed","days":7,"dry":true}`, `{"days":7,"dry":true,"scope":"failed"}`, true},
+		{`{}`, "", false},                         // 缺少必填参数
+		{`{"scope":"none"}`, "", false},           // 不在可选值中
+		{`{"scope":"all","days":0}`, "", false},   // 小于最小值
+		{`{"scope":"all","days":1.5}`, "", false}, // 不是整数
+		{`{"scope":"all","days":"7"}`, "", false}, // 类型错误
+		{`{"scope":"all","extra":1}`, "", false},  // 未定义的参数
+		{`["all"]`, "", false},                    // 不是对象
+	}
+
+	for _, tt := range tests {
+		got, err := schema.Validate(json.RawMessage(tt.params))
+		if !tt.ok {
+			assert.ErrorIs(t, err, ErrInvalidParams, tt.params)
+			continue
+		}
+		if assert.NoError(t, err, tt.params) {
+			assert.JSONEq(t, tt.want, string(got), tt.params)
+		}
+	}
+}
+
+func TestRegistryRun(t *testing.T) {
+	registry := NewRegistry()
+	type params struct {
+		Days int `json:"days"`
+	}
+	err := registry.Register(Definition{
+		Name:   "purge",
+		Params: Schema{Properties: map[string]Property{"days": {Type: "integer", Default: 30}}},
+		Run: Typed(func(ctx context.Context, p params) (string, error) {
+			if p.Days > 100 {
+				panic("too many days")
+			}
+			return fmt.Sprintf("days=%d", p.Days), nil
+		}),
+	})
+	assert.NoError(t, err)
+	assert.Error(t, registry.Register(Definition{Name: "purge", Run: func(context.Context, json.RawMessage) (string, error) { return "", nil }}))
+
+	out, err := registry.Run(context.Background(), "purge", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "days=30", out)
+
+	_, err = registry.Run(context.Background(), "purge", json.RawMessage(`{"days":200}`))
+	assert.Error(t, err)
+
+	_, err = registry.Run(context.Background(), "missing", nil)
+	assert.True(t, errors.Is(err, ErrHandlerNotFound))
+}
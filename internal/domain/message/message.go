@@ -0,0 +1,28 @@
+package message
+
+import (
+	"context"
+
+	"gva/internal/domain/entity"
+)
+
+// Message 渲染后待投递的消息
+type Message struct {
+	DeliveryID   uint   // 投递记录ID，重试时不变，发送器可据此避免重复发送
+	TemplateCode string // 模板编码
+	Type         string // 消息类型，站内通知渠道作为通知类型使用
+	UserID       uint   // 接收用户ID
+	Recipient    string // 接收地址，如邮箱
+	Subject      string // 主题
+	Body         string // 正文
+}
+
+// Sender 消息渠道的发送接口
+type Sender interface {
+	// Channel 返回渠道名称，如 email、webhook
+	Channel() string
+	// Recipient 返回用户在该渠道的接收地址，为空表示该用户无法通过此渠道接收
+	Recipient(user *entity.User) string
+	// Send 发送消息，返回错误时会按重试策略重新投递
+	Send(ctx context.Context, msg *Message) error
+}
@@ -0,0 +1,43 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// 推送事件类型
+const (
+	EventNotification = "notification" // 新通知
+	EventUnreadCount  = "unread_count" // 未读数量变化
+	EventForceLogout  = "force_logout" // 强制下线
+)
+
+// Message 推送给客户端的消息
+// ID 全局单调递增，客户端重连时携带最后收到的ID即可补发错过的消息，
+// 同一条消息可能因重连补发而重复送达，客户端应按ID去重
+type Message struct {
+	ID   int64           `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+	Time time.Time       `json:"time"`
+}
+
+// Publisher 实时消息推送接口
+type Publisher interface {
+	// Publish 推送消息给指定用户的所有在线连接
+	Publish(ctx context.Context, userID uint, eventType string, data interface{}) error
+	// Broadcast 推送消息给所有在线用户
+	Broadcast(ctx context.Context, eventType string, data interface{}) error
+}
+
+// NopPublisher 不推送任何消息的空实现
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(ctx context.Context, userID uint, eventType string, data interface{}) error {
+	return nil
+}
+
+func (NopPublisher) Broadcast(ctx context.Context, eventType string, data interface{}) error {
+	return nil
+}
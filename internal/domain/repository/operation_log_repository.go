@@ -3,10 +3,28 @@ package repository
 import (
 	"context"
 	"gva/internal/domain/entity"
+	"time"
 )
 
+// OperationLogFilter 操作日志查询条件
+type OperationLogFilter struct {
+	UserID     *uint      // 用户ID
+	Username   string     // 用户名（模糊匹配）
+	Method     string     // 请求方法
+	PathPrefix string     // 请求路径前缀
+	StatusMin  *int       // 最小状态码（包含）
+	StatusMax  *int       // 最大状态码（包含）
+	IP         string     // 客户端IP
+	MinLatency *int64     // 最小耗时（毫秒）
+	StartTime  *time.Time // 开始时间（包含）
+	EndTime    *time.Time // 结束时间（不包含）
+	SortBy     string     // 排序字段：created_at、latency、status，默认按ID
+	SortDesc   bool       // 是否倒序
+}
+
 // OperationLogRepository 操作日志仓储接口
 type OperationLogRepository interface {
 	Create(ctx context.Context, log *entity.OperationLog) error
-	List(ctx context.Context, page, size int) ([]*entity.OperationLog, int64, error)
+	CreateBatch(ctx context.Context, logs []*entity.OperationLog) error
+	List(ctx context.Context, page, size int, filter OperationLogFilter) ([]*entity.OperationLog, int64, error)
 }
@@ -5,12 +5,22 @@ import (
 	"gva/internal/domain/entity"
 )
 
+// UserFilter 用户列表查询条件
+type UserFilter struct {
+	Keyword               string // 搜索关键词
+	Status                *int   // 用户状态
+	RoleID                *uint  // 角色ID
+	DepartmentID          *uint  // 部门ID
+	IncludeSubDepartments bool   // 是否包含下级部门的用户
+	PositionID            *uint  // 岗位ID
+}
+
 // UserRepository 用户仓储接口
 type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
 	FindByUsername(ctx context.Context, username string) (*entity.User, error)
 	FindByID(ctx context.Context, id uint) (*entity.User, error)
 	Update(ctx context.Context, user *entity.User) error
-	List(ctx context.Context, page, size int, keyword string, status *int) ([]*entity.User, int64, error)
+	List(ctx context.Context, page, size int, filter UserFilter) ([]*entity.User, int64, error)
 	FindAll(ctx context.Context) ([]*entity.User, error)
 }
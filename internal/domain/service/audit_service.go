@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gva/internal/domain/audit"
+	"gva/internal/domain/entity"
+	"gva/internal/pkg/config"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrAuditKeyMissing 未配置审计检查点的签名密钥
+	ErrAuditKeyMissing = errors.New("未配置审计检查点的签名密钥")
+	// ErrAuditChainHeadMissing 审计链头不存在，但已有事件接入哈希链
+	ErrAuditChainHeadMissing = errors.New("审计链头不存在，但已有审计事件接入哈希链，链头可能被删除")
+)
+
+// auditVerifyBatchSize 校验哈希链时每批读取的事件数
+const auditVerifyBatchSize = 1000
+
+// AuditEventFilter 审计事件查询条件
+type AuditEventFilter struct {
+	EntityType string
+	EntityID   uint
+	ActorID    uint
+	Action     string
+	StartTime  *time.Time
+	EndTime    *time.Time
+}
+
+// AuditVerifyResult 哈希链的校验结果
+type AuditVerifyResult struct {
+	Valid       bool             `json:"valid"`
+	Checked     int64            `json:"checked"`             // 校验的审计事件条数
+	Checkpoints int              `json:"checkpoints"`         // 校验的检查点个数
+	Signed      bool             `json:"signed"`              // 是否校验了检查点签名，未配置密钥时不校验
+	BrokenAt    *AuditBrokenLink `json:"broken_at,omitempty"` // 第一处断开的链接
+}
+
+// AuditBrokenLink 哈希链断开的位置和原因
+type AuditBrokenLink struct {
+	EventID      uint   `json:"event_id,omitempty"`
+	CheckpointID uint   `json:"checkpoint_id,omitempty"`
+	Reason       string `json:"reason"`
+}
+
+type AuditService struct {
+	db  *gorm.DB
+	key []byte
+}
+
+func NewAuditService(db *gorm.DB, cfg config.AuditConfig) *AuditService {
+	s := &AuditService{db: db}
+	if cfg.HMACKey != "" {
+		s.key = []byte(cfg.HMACKey)
+	}
+	return s
+}
+
+// List 分页查询审计事件，按时间倒序
+func (s *AuditService) List(ctx context.Context, page, pageSize int, filter AuditEventFilter) ([]entity.AuditEvent, int64, error) {
+	db := s.db.WithContext(ctx).Model(&entity.AuditEvent{})
+	if filter.EntityType != "" {
+		db = db.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID > 0 {
+		db = db.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorID > 0 {
+		db = db.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.StartTime != nil {
+		db = db.Where("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		db = db.Where("created_at < ?", *filter.EndTime)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计审计事件失败: %v", err)
+	}
+
+	var events []entity.AuditEvent
+	err := db.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&events).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询审计事件失败: %v", err)
+	}
+	return events, total, nil
+}
+
+// Checkpoint 对当前的链头签名生成检查点，链头自上一个检查点以来没有变化时不重复生成
+func (s *AuditService) Checkpoint(ctx context.Context) (*entity.AuditCheckpoint, error) {
+	if s.key == nil {
+		return nil, ErrAuditKeyMissing
+	}
+
+	var checkpoint *entity.AuditCheckpoint
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		head, err := lockAuditChainHead(tx)
+		if err != nil {
+			return err
+		}
+		if head.LastID == 0 {
+			return nil
+		}
+
+		var last entity.AuditCheckpoint
+		err = tx.Order("id DESC").Limit(1).Find(&last).Error
+		if err != nil {
+			return fmt.Errorf("查询审计检查点失败: %v", err)
+		}
+		if last.ID > 0 && last.LastEventID == head.LastID && last.LastHash == head.LastHash {
+			checkpoint = &last
+			return nil
+		}
+
+		checkpoint = &entity.AuditCheckpoint{
+			LastEventID: head.LastID,
+			LastHash:    head.LastHash,
+			CreatedAt:   time.Now().Truncate(time.Millisecond),
+		}
+		checkpoint.Signature = s.sign(checkpoint)
+		if err := tx.Create(checkpoint).Error; err != nil {
+			return fmt.Errorf("保存审计检查点失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// Verify 按ID顺序遍历审计事件，校验每条事件的哈希、与上一条事件的链接、检查点和链头，报告第一处断开的链接
+func (s *AuditService) Verify(ctx context.Context) (*AuditVerifyResult, error) {
+	result := &AuditVerifyResult{Signed: s.key != nil}
+
+	// 先读取检查点再读取链头，链头只会向后移动，正常情况下检查点不会超出链头
+	var checkpoints []entity.AuditCheckpoint
+	if err := s.db.WithContext(ctx).Order("id").Find(&checkpoints).Error; err != nil {
+		return nil, fmt.Errorf("查询审计检查点失败: %v", err)
+	}
+	result.Checkpoints = len(checkpoints)
+
+	// 只校验到链头为止，校验期间新追加的事件不影响结果
+	var head entity.AuditChainHead
+	err := s.db.WithContext(ctx).Where("name = ?", entity.AuditChainEvents).Limit(1).Find(&head).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询审计链头失败: %v", err)
+	}
+
+	if head.ID == 0 {
+		chained, err := auditChained(s.db.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if chained {
+			result.BrokenAt = &AuditBrokenLink{Reason: "链头不存在，但已有审计事件接入哈希链，链头可能被删除"}
+			return result, nil
+		}
+	}
+
+	pending := make(map[uint][]entity.AuditCheckpoint, len(checkpoints))
+	for _, cp := range checkpoints {
+		if s.key != nil && !hmac.Equal([]byte(cp.Signature), []byte(s.sign(&cp))) {
+			result.BrokenAt = &AuditBrokenLink{CheckpointID: cp.ID, EventID: cp.LastEventID, Reason: "检查点签名无效，检查点可能被修改"}
+			return result, nil
+		}
+		pending[cp.LastEventID] = append(pending[cp.LastEventID], cp)
+	}
+
+	var prevHash string
+	var lastID uint
+	for {
+		var events []entity.AuditEvent
+		err := s.db.WithContext(ctx).
+			Where("id > ? AND id <= ?", lastID, head.LastID).
+			Order("id").
+			Limit(auditVerifyBatchSize).
+			Find(&events).Error
+		if err != nil {
+			return nil, fmt.Errorf("查询审计事件失败: %v", err)
+		}
+
+		for i := range events {
+			event := &events[i]
+			switch {
+			case event.PrevHash != prevHash:
+				result.BrokenAt = &AuditBrokenLink{EventID: event.ID, Reason: "与上一条事件的链接断开，之前的事件可能被删除或修改"}
+			case event.ComputeHash() != event.Hash:
+				result.BrokenAt = &AuditBrokenLink{EventID: event.ID, Reason: "事件内容与哈希不一致，事件可能被修改"}
+			}
+			for _, cp := range pending[event.ID] {
+				if result.BrokenAt == nil && cp.LastHash != event.Hash {
+					result.BrokenAt = &AuditBrokenLink{EventID: event.ID, CheckpointID: cp.ID, Reason: "事件哈希与已签名的检查点不一致，哈希链可能被重新计算"}
+				}
+			}
+			if result.BrokenAt != nil {
+				return result, nil
+			}
+			delete(pending, event.ID)
+			prevHash, lastID = event.Hash, event.ID
+			result.Checked++
+		}
+		if len(events) < auditVerifyBatchSize {
+			break
+		}
+	}
+
+	// 检查点引用的事件不存在，说明末尾的事件被删除
+	for _, cps := range pending {
+		for _, cp := range cps {
+			if result.BrokenAt == nil || cp.ID < result.BrokenAt.CheckpointID {
+				result.BrokenAt = &AuditBrokenLink{EventID: cp.LastEventID, CheckpointID: cp.ID, Reason: "已签名的检查点引用的事件不存在，事件可能被删除"}
+			}
+		}
+	}
+	if result.BrokenAt != nil {
+		return result, nil
+	}
+
+	if head.LastID != lastID || head.LastHash != prevHash {
+		result.BrokenAt = &AuditBrokenLink{EventID: head.LastID, Reason: "链头与最后一条事件不一致，末尾的事件可能被删除"}
+		return result, nil
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// sign 计算检查点的签名
+func (s *AuditService) sign(cp *entity.AuditCheckpoint) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(strconv.FormatUint(uint64(cp.LastEventID), 10) + ":" + cp.LastHash + ":" + cp.CreatedAt.UTC().Format("2006-01-02T15:04:05.000Z")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// lockAuditChainHead 锁定审计事件哈希链的链头，还没有事件接入哈希链时先创建链头
+// 同一时刻只有一个事务能追加事件或生成检查点，保证链的顺序
+func lockAuditChainHead(tx *gorm.DB) (*entity.AuditChainHead, error) {
+	var head entity.AuditChainHead
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", entity.AuditChainEvents).Take(&head).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 链头被删除时不能重新开始一条新链，否则之前的事件被删除也无法发现
+		chained, chainErr := auditChained(tx)
+		if chainErr != nil {
+			return nil, chainErr
+		}
+		if chained {
+			return nil, ErrAuditChainHeadMissing
+		}
+		err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.AuditChainHead{Name: entity.AuditChainEvents}).Error
+		if err == nil {
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", entity.AuditChainEvents).Take(&head).Error
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("锁定审计链头失败: %v", err)
+	}
+	return &head, nil
+}
+
+// auditChained 返回是否已有审计事件接入哈希链
+func auditChained(db *gorm.DB) (bool, error) {
+	var count int64
+	if err := db.Model(&entity.AuditEvent{}).Where("hash <> ''").Limit(1).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询审计事件失败: %v", err)
+	}
+	return count > 0, nil
+}
+
+// recordAudit 在变更所在的事务 tx 中记录审计事件并追加到哈希链，操作人取自 ctx，没有字段变化时不记录
+func recordAudit(ctx context.Context, tx *gorm.DB, entityType string, entityID uint, action string, changes []audit.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("记录审计事件失败: %v", err)
+	}
+	head, err := lockAuditChainHead(tx)
+	if err != nil {
+		return err
+	}
+
+	actor := audit.ActorFrom(ctx)
+	event := &entity.AuditEvent{
+		ActorID:    actor.UserID,
+		ActorIP:    actor.IP,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    data,
+		PrevHash:   head.LastHash,
+		CreatedAt:  time.Now().Truncate(time.Millisecond),
+	}
+	event.Hash = event.ComputeHash()
+	if err := tx.Create(event).Error; err != nil {
+		return fmt.Errorf("记录审计事件失败: %v", err)
+	}
+
+	err = tx.Model(head).Updates(map[string]interface{}{"last_id": event.ID, "last_hash": event.Hash}).Error
+	if err != nil {
+		return fmt.Errorf("更新审计链头失败: %v", err)
+	}
+	return nil
+}
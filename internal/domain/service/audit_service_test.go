@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"gva/internal/domain/audit"
+	"gva/internal/domain/entity"
+	"gva/internal/infrastructure/database"
+	"gva/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newAuditChain 创建6条审计事件，并在第4条之后生成检查点
+func newAuditChain(t *testing.T) (*gorm.DB, *AuditService) {
+	db := newTestDB(t)
+	s := NewAuditService(db, config.AuditConfig{HMACKey: "secret"})
+	ctx := audit.WithActor(context.Background(), audit.Actor{UserID: 1, IP: "127.0.0.1"})
+	for i := 1; i <= 6; i++ {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return recordAudit(ctx, tx, audit.EntityRole, 1, audit.ActionUpdate, []audit.Change{{Field: "sort", Before: i - 1, After: i}})
+		})
+		require.NoError(t, err)
+		if i == 4 {
+			_, err := s.Checkpoint(ctx)
+			require.NoError(t, err)
+		}
+	}
+	return db, s
+}
+
+func TestAuditChainValid(t *testing.T) {
+	db, s := newAuditChain(t)
+	ctx := context.Background()
+
+	result, err := s.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.True(t, result.Signed)
+	assert.EqualValues(t, 6, result.Checked)
+	assert.Equal(t, 1, result.Checkpoints)
+
+	// 链头没有变化时不重复生成检查点
+	first, err := s.Checkpoint(ctx)
+	require.NoError(t, err)
+	second, err := s.Checkpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+	assert.EqualValues(t, 6, first.LastEventID)
+
+	_, err = NewAuditService(db, config.AuditConfig{}).Checkpoint(ctx)
+	assert.ErrorIs(t, err, ErrAuditKeyMissing)
+}
+
+func TestAuditVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name         string
+		tamper       func(t *testing.T, db *gorm.DB)
+		eventID      uint
+		checkpointID uint
+	}{
+		{
+			name: "修改事件",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Model(&entity.AuditEvent{}).Where("id = ?", 3).Update("action", "delete").Error)
+			},
+			eventID: 3,
+		},
+		{
+			name: "删除中间的事件",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Delete(&entity.AuditEvent{}, 3).Error)
+			},
+			eventID: 4,
+		},
+		{
+			name: "删除末尾的事件",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Delete(&entity.AuditEvent{}, 6).Error)
+			},
+			eventID: 6,
+		},
+		{
+			name: "删除检查点之后的事件并回退链头",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				var event entity.AuditEvent
+				require.NoError(t, db.First(&event, 2).Error)
+				require.NoError(t, db.Where("id > ?", 2).Delete(&entity.AuditEvent{}).Error)
+				require.NoError(t, db.Model(&entity.AuditChainHead{}).Where("name = ?", entity.AuditChainEvents).
+					Updates(map[string]interface{}{"last_id": event.ID, "last_hash": event.Hash}).Error)
+			},
+			eventID:      4,
+			checkpointID: 1,
+		},
+		{
+			name: "修改事件后重新计算整条链",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				var events []entity.AuditEvent
+				require.NoError(t, db.Order("id").Find(&events).Error)
+				var prevHash string
+				for i := range events {
+					if events[i].ID == 2 {
+						events[i].ActorID = 99
+					}
+					events[i].PrevHash = prevHash
+					events[i].Hash = events[i].ComputeHash()
+					prevHash = events[i].Hash
+					require.NoError(t, db.Save(&events[i]).Error)
+				}
+				require.NoError(t, db.Model(&entity.AuditChainHead{}).Where("name = ?", entity.AuditChainEvents).
+					Update("last_hash", prevHash).Error)
+			},
+			eventID:      4,
+			checkpointID: 1,
+		},
+		{
+			name: "伪造检查点",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				var event entity.AuditEvent
+				require.NoError(t, db.First(&event, 2).Error)
+				require.NoError(t, db.Model(&entity.AuditCheckpoint{}).Where("id = ?", 1).
+					Updates(map[string]interface{}{"last_event_id": event.ID, "last_hash": event.Hash}).Error)
+			},
+			eventID:      2,
+			checkpointID: 1,
+		},
+		{
+			name: "删除链头",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Where("name = ?", entity.AuditChainEvents).Delete(&entity.AuditChainHead{}).Error)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, s := newAuditChain(t)
+			tt.tamper(t, db)
+
+			result, err := s.Verify(context.Background())
+			require.NoError(t, err)
+			assert.False(t, result.Valid)
+			require.NotNil(t, result.BrokenAt)
+			assert.Equal(t, tt.eventID, result.BrokenAt.EventID, result.BrokenAt.Reason)
+			assert.Equal(t, tt.checkpointID, result.BrokenAt.CheckpointID, result.BrokenAt.Reason)
+		})
+	}
+}
+
+// 链头被删除时不能重新开始一条新链，也不能在启动时重新计算哈希
+func TestAuditChainHeadMissing(t *testing.T) {
+	db, _ := newAuditChain(t)
+	require.NoError(t, db.Where("name = ?", entity.AuditChainEvents).Delete(&entity.AuditChainHead{}).Error)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return recordAudit(context.Background(), tx, audit.EntityRole, 1, audit.ActionUpdate, []audit.Change{{Field: "sort", Before: 6, After: 7}})
+	})
+	assert.ErrorIs(t, err, ErrAuditChainHeadMissing)
+
+	assert.Error(t, database.AutoMigrate(db))
+	var count int64
+	db.Model(&entity.AuditChainHead{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+// 启用哈希链之前的审计事件在迁移时接入哈希链
+func TestAuditChainBootstrap(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.Where("name = ?", entity.AuditChainEvents).Delete(&entity.AuditChainHead{}).Error)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.Create(&entity.AuditEvent{EntityType: audit.EntityUser, EntityID: 1, Action: audit.ActionUpdate, Changes: []byte("[]")}).Error)
+	}
+
+	require.NoError(t, database.AutoMigrate(db))
+	result, err := NewAuditService(db, config.AuditConfig{}).Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.EqualValues(t, 3, result.Checked)
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gva/internal/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 定义部门相关的错误
+var (
+	ErrDepartmentNotFound       = errors.New("部门不存在")
+	ErrParentDepartmentNotFound = errors.New("上级部门不存在")
+	ErrDepartmentCycle          = errors.New("不能将部门移动到自身或其下级部门")
+	ErrDepartmentHasChildren    = errors.New("该部门下存在子部门，不能删除")
+	ErrDepartmentHasUsers       = errors.New("该部门下存在用户，不能删除")
+	ErrDepartmentHasPositions   = errors.New("该部门下存在岗位，不能删除")
+	ErrDepartmentCodeConflict   = errors.New("部门编码已存在")
+)
+
+type DepartmentService struct {
+	db *gorm.DB
+}
+
+func NewDepartmentService(db *gorm.DB) *DepartmentService {
+	return &DepartmentService{db: db}
+}
+
+// GetTree 获取部门树
+func (s *DepartmentService) GetTree(ctx context.Context) ([]*entity.Department, error) {
+	var departments []*entity.Department
+	if err := s.db.WithContext(ctx).Order("sort ASC, id ASC").Find(&departments).Error; err != nil {
+		return nil, fmt.Errorf("查询部门列表失败: %v", err)
+	}
+
+	return buildDepartmentTree(departments), nil
+}
+
+// GetByID 根据ID获取部门信息
+func (s *DepartmentService) GetByID(ctx context.Context, id uint) (*entity.Department, error) {
+	var department entity.Department
+	if err := s.db.WithContext(ctx).First(&department, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDepartmentNotFound
+		}
+		return nil, fmt.Errorf("查询部门失败: %v", err)
+	}
+	return &department, nil
+}
+
+// Create 创建部门，并根据上级部门生成部门路径
+func (s *DepartmentService) Create(ctx context.Context, department *entity.Department) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkDepartmentCode(tx, department.Code, 0); err != nil {
+			return err
+		}
+
+		parentPath := "/"
+		if department.ParentID != nil {
+			var parent entity.Department
+			if err := tx.First(&parent, *department.ParentID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrParentDepartmentNotFound
+				}
+				return fmt.Errorf("查询上级部门失败: %v", err)
+			}
+			parentPath = parent.Path
+		}
+
+		if err := tx.Create(department).Error; err != nil {
+			return fmt.Errorf("创建部门失败: %v", err)
+		}
+
+		// 路径依赖自增ID，创建后再回填
+		department.Path = fmt.Sprintf("%s%d/", parentPath, department.ID)
+		if err := tx.Model(department).Update("path", department.Path).Error; err != nil {
+			return fmt.Errorf("更新部门路径失败: %v", err)
+		}
+
+		return nil
+	})
+}
+
+// Update 更新部门信息，上级部门发生变化时整体移动子树
+func (s *DepartmentService) Update(ctx context.Context, id uint, department *entity.Department) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing entity.Department
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDepartmentNotFound
+			}
+			return fmt.Errorf("查询部门失败: %v", err)
+		}
+
+		if department.Code != existing.Code {
+			if err := checkDepartmentCode(tx, department.Code, id); err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{
+			"name":        department.Name,
+			"code":        department.Code,
+			"leader":      department.Leader,
+			"phone":       department.Phone,
+			"email":       department.Email,
+			"sort":        department.Sort,
+			"status":      department.Status,
+			"description": department.Description,
+		}
+		if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新部门失败: %v", err)
+		}
+
+		if !sameParent(existing.ParentID, department.ParentID) {
+			return moveDepartment(tx, &existing, department.ParentID)
+		}
+
+		return nil
+	})
+}
+
+// Move 将部门（连同其子部门）移动到新的上级部门下，parentID 为 nil 时移动为顶级部门
+func (s *DepartmentService) Move(ctx context.Context, id uint, parentID *uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var department entity.Department
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&department, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDepartmentNotFound
+			}
+			return fmt.Errorf("查询部门失败: %v", err)
+		}
+
+		if sameParent(department.ParentID, parentID) {
+			return nil
+		}
+
+		return moveDepartment(tx, &department, parentID)
+	})
+}
+
+// Delete 删除部门，存在子部门、用户或岗位时不允许删除
+func (s *DepartmentService) Delete(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var department entity.Department
+		if err := tx.First(&department, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDepartmentNotFound
+			}
+			return fmt.Errorf("查询部门失败: %v", err)
+		}
+
+		var children int64
+		if err := tx.Model(&entity.Department{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return fmt.Errorf("统计子部门失败: %v", err)
+		}
+		if children > 0 {
+			return ErrDepartmentHasChildren
+		}
+
+		// 包括已软删除、可恢复的用户
+		var users int64
+		if err := tx.Unscoped().Model(&entity.User{}).Where("department_id = ?", id).Count(&users).Error; err != nil {
+			return fmt.Errorf("统计部门用户失败: %v", err)
+		}
+		if users > 0 {
+			return ErrDepartmentHasUsers
+		}
+
+		var positions int64
+		if err := tx.Model(&entity.Position{}).Where("department_id = ?", id).Count(&positions).Error; err != nil {
+			return fmt.Errorf("统计部门岗位失败: %v", err)
+		}
+		if positions > 0 {
+			return ErrDepartmentHasPositions
+		}
+
+		if err := tx.Delete(&department).Error; err != nil {
+			return fmt.Errorf("删除部门失败: %v", err)
+		}
+
+		return nil
+	})
+}
+
+// moveDepartment 修改部门的上级部门，并在同一事务中重写所有下级部门的路径
+func moveDepartment(tx *gorm.DB, department *entity.Department, parentID *uint) error {
+	parentPath := "/"
+	if parentID != nil {
+		var parent entity.Department
+		if err := tx.First(&parent, *parentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrParentDepartmentNotFound
+			}
+			return fmt.Errorf("查询上级部门失败: %v", err)
+		}
+		parentPath = parent.Path
+	}
+
+	oldPath := department.Path
+	newPath := fmt.Sprintf("%s%d/", parentPath, department.ID)
+
+	// 新的上级部门不能是自身或者自身的下级部门
+	if len(newPath) > len(oldPath) && newPath[:len(oldPath)] == oldPath {
+		return ErrDepartmentCycle
+	}
+
+	if err := tx.Model(department).Update("parent_id", parentID).Error; err != nil {
+		return fmt.Errorf("更新上级部门失败: %v", err)
+	}
+
+	// 替换自身及所有下级部门路径的前缀，逐条更新以兼容不同的数据库
+	var descendants []entity.Department
+	if err := tx.Select("id", "path").Where("path LIKE ?", oldPath+"%").Find(&descendants).Error; err != nil {
+		return fmt.Errorf("查询下级部门失败: %v", err)
+	}
+	for _, d := range descendants {
+		path := newPath + strings.TrimPrefix(d.Path, oldPath)
+		if err := tx.Model(&entity.Department{}).Where("id = ?", d.ID).Update("path", path).Error; err != nil {
+			return fmt.Errorf("更新部门路径失败: %v", err)
+		}
+	}
+
+	department.ParentID = parentID
+	department.Path = newPath
+	return nil
+}
+
+// checkDepartmentCode 检查部门编码是否被其他部门占用
+func checkDepartmentCode(tx *gorm.DB, code string, excludeID uint) error {
+	var count int64
+	if err := tx.Unscoped().Model(&entity.Department{}).Where("code = ? AND id != ?", code, excludeID).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查部门编码失败: %v", err)
+	}
+	if count > 0 {
+		return ErrDepartmentCodeConflict
+	}
+	return nil
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// buildDepartmentTree 将扁平的部门列表组装为部门树
+func buildDepartmentTree(departments []*entity.Department) []*entity.Department {
+	byID := make(map[uint]*entity.Department, len(departments))
+	for _, d := range departments {
+		byID[d.ID] = d
+	}
+
+	var roots []*entity.Department
+	for _, d := range departments {
+		if d.ParentID != nil {
+			if parent, ok := byID[*d.ParentID]; ok {
+				parent.Children = append(parent.Children, d)
+				continue
+			}
+		}
+		roots = append(roots, d)
+	}
+	return roots
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gva/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createTestDepartment(t *testing.T, s *DepartmentService, code string, parent *entity.Department) *entity.Department {
+	department := &entity.Department{Name: code, Code: code}
+	if parent != nil {
+		department.ParentID = &parent.ID
+	}
+	require.NoError(t, s.Create(context.Background(), department))
+	return department
+}
+
+// pathOf 按部门ID拼接部门路径
+func pathOf(ids ...uint) string {
+	path := "/"
+	for _, id := range ids {
+		path += fmt.Sprintf("%d/", id)
+	}
+	return path
+}
+
+func departmentPath(t *testing.T, db *gorm.DB, id uint) string {
+	var department entity.Department
+	require.NoError(t, db.First(&department, id).Error)
+	return department.Path
+}
+
+// 移动部门时重写自身及所有下级部门的路径，其他部门不受影响
+func TestDepartmentMove(t *testing.T) {
+	db := newTestDB(t)
+	s := NewDepartmentService(db)
+	ctx := context.Background()
+
+	root := createTestDepartment(t, s, "root", nil)
+	a := createTestDepartment(t, s, "a", root)
+	child := createTestDepartment(t, s, "a1", a)
+	grandchild := createTestDepartment(t, s, "a1x", child)
+	b := createTestDepartment(t, s, "b", root)
+	// 其他部门树中的部门
+	other := createTestDepartment(t, s, "other", nil)
+	require.Equal(t, pathOf(root.ID, a.ID, child.ID, grandchild.ID), grandchild.Path)
+
+	require.NoError(t, s.Move(ctx, a.ID, &b.ID))
+	assert.Equal(t, pathOf(root.ID, b.ID, a.ID), departmentPath(t, db, a.ID))
+	assert.Equal(t, pathOf(root.ID, b.ID, a.ID, child.ID), departmentPath(t, db, child.ID))
+	assert.Equal(t, pathOf(root.ID, b.ID, a.ID, child.ID, grandchild.ID), departmentPath(t, db, grandchild.ID))
+	assert.Equal(t, b.Path, departmentPath(t, db, b.ID))
+	assert.Equal(t, other.Path, departmentPath(t, db, other.ID))
+
+	var moved entity.Department
+	require.NoError(t, db.First(&moved, a.ID).Error)
+	require.NotNil(t, moved.ParentID)
+	assert.Equal(t, b.ID, *moved.ParentID)
+
+	// 移动为顶级部门
+	require.NoError(t, s.Move(ctx, child.ID, nil))
+	assert.Equal(t, pathOf(child.ID, grandchild.ID), departmentPath(t, db, grandchild.ID))
+}
+
+// 不能将部门移动到自身或其下级部门
+func TestDepartmentMoveCycle(t *testing.T) {
+	db := newTestDB(t)
+	s := NewDepartmentService(db)
+	ctx := context.Background()
+
+	a := createTestDepartment(t, s, "a", nil)
+	child := createTestDepartment(t, s, "a1", a)
+	grandchild := createTestDepartment(t, s, "a1x", child)
+
+	assert.ErrorIs(t, s.Move(ctx, a.ID, &a.ID), ErrDepartmentCycle)
+	assert.ErrorIs(t, s.Move(ctx, a.ID, &grandchild.ID), ErrDepartmentCycle)
+	update := &entity.Department{Name: "a", Code: "a", ParentID: &child.ID}
+	assert.ErrorIs(t, s.Update(ctx, a.ID, update), ErrDepartmentCycle)
+
+	missing := uint(999)
+	assert.ErrorIs(t, s.Move(ctx, a.ID, &missing), ErrParentDepartmentNotFound)
+	assert.Equal(t, grandchild.Path, departmentPath(t, db, grandchild.ID), "失败时不修改路径")
+}
+
+// 存在子部门、用户（包括已删除的用户）或岗位时不能删除部门
+func TestDepartmentDeleteGuards(t *testing.T) {
+	db := newTestDB(t)
+	s := NewDepartmentService(db)
+	ctx := context.Background()
+
+	parent := createTestDepartment(t, s, "parent", nil)
+	child := createTestDepartment(t, s, "child", parent)
+	assert.ErrorIs(t, s.Delete(ctx, parent.ID), ErrDepartmentHasChildren)
+
+	user := createTestUser(t, db)
+	require.NoError(t, db.Model(user).Update("department_id", child.ID).Error)
+	require.NoError(t, db.Delete(user).Error)
+	assert.ErrorIs(t, s.Delete(ctx, child.ID), ErrDepartmentHasUsers)
+	require.NoError(t, db.Unscoped().Delete(user).Error)
+
+	position := &entity.Position{Code: "dev", Name: "开发", DepartmentID: &child.ID}
+	require.NoError(t, db.Create(position).Error)
+	assert.ErrorIs(t, s.Delete(ctx, child.ID), ErrDepartmentHasPositions)
+	require.NoError(t, db.Delete(position).Error)
+
+	require.NoError(t, s.Delete(ctx, child.ID))
+	require.NoError(t, s.Delete(ctx, parent.ID))
+	assert.ErrorIs(t, s.Delete(ctx, parent.ID), ErrDepartmentNotFound)
+}
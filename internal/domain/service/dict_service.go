@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"gva/internal/domain/cache"
+	"gva/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// 定义数据字典相关的错误
+var (
+	ErrDictNotFound          = errors.New("字典不存在")
+	ErrDictConflict          = errors.New("字典名称或编码已存在")
+	ErrDictItemNotFound      = errors.New("字典项不存在")
+	ErrDictItemValueConflict = errors.New("字典项的值已存在")
+)
+
+type DictService struct {
+	db    *gorm.DB
+	cache cache.DictCache
+}
+
+func NewDictService(db *gorm.DB, dictCache cache.DictCache) *DictService {
+	// 如果缓存为空，使用不缓存的空实现
+	if dictCache == nil {
+		dictCache = emptyDictCache{}
+	}
+	return &DictService{db: db, cache: dictCache}
+}
+
+// emptyDictCache 空的字典缓存实现
+type emptyDictCache struct{}
+
+func (emptyDictCache) GetDict(ctx context.Context, code string) (*entity.Dict, error) {
+	return nil, nil
+}
+
+func (emptyDictCache) SetDict(ctx context.Context, dict *entity.Dict) error {
+	return nil
+}
+
+func (emptyDictCache) DeleteDict(ctx context.Context, codes ...string) error {
+	return nil
+}
+
+// DictOption 对外公开的字典项
+type DictOption struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+	Sort  int    `json:"sort"`
+}
+
+// DictOptions 对外公开的字典，只包含已启用的字典项
+type DictOptions struct {
+	Code  string       `json:"code"`
+	Name  string       `json:"name"`
+	Items []DictOption `json:"items"`
+}
+
+// GetEnabledDict 根据编码获取已启用的字典及其已启用的字典项，优先读取缓存
+func (s *DictService) GetEnabledDict(ctx context.Context, code string) (*entity.Dict, error) {
+	dict, err := s.cache.GetDict(ctx, code)
+	if err != nil {
+		log.Printf("读取字典缓存失败: %v", err)
+	}
+	if dict != nil {
+		return dict, nil
+	}
+
+	dict = &entity.Dict{}
+	err = s.db.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", 1).Order("sort ASC, id ASC")
+	}).Where("code = ? AND status = ?", code, 1).First(dict).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDictNotFound
+		}
+		return nil, fmt.Errorf("查询字典失败: %v", err)
+	}
+
+	if err := s.cache.SetDict(ctx, dict); err != nil {
+		log.Printf("缓存字典失败: %v", err)
+	}
+	return dict, nil
+}
+
+// GetOptions 获取单个字典的公开字典项
+func (s *DictService) GetOptions(ctx context.Context, code string) (*DictOptions, error) {
+	dict, err := s.GetEnabledDict(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return toDictOptions(dict), nil
+}
+
+// BatchGetOptions 批量获取字典的公开字典项，不存在或已禁用的字典会被忽略
+func (s *DictService) BatchGetOptions(ctx context.Context, codes []string) ([]*DictOptions, error) {
+	options := make([]*DictOptions, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		dict, err := s.GetEnabledDict(ctx, code)
+		if err != nil {
+			if errors.Is(err, ErrDictNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		options = append(options, toDictOptions(dict))
+	}
+	return options, nil
+}
+
+func toDictOptions(dict *entity.Dict) *DictOptions {
+	options := &DictOptions{
+		Code:  dict.Code,
+		Name:  dict.Name,
+		Items: make([]DictOption, 0, len(dict.Items)),
+	}
+	for _, item := range dict.Items {
+		options.Items = append(options.Items, DictOption{
+			Label: item.Label,
+			Value: item.Value,
+			Sort:  item.Sort,
+		})
+	}
+	return options
+}
+
+// invalidate 字典或字典项修改后删除对应的字典缓存
+func (s *DictService) invalidate(ctx context.Context, codes ...string) {
+	if err := s.cache.DeleteDict(ctx, codes...); err != nil {
+		log.Printf("删除字典缓存失败: %v", err)
+	}
+}
+
+// List 获取字典列表（包含字典项）
+func (s *DictService) List(ctx context.Context, keyword string) ([]entity.Dict, error) {
+	db := s.db.WithContext(ctx).Model(&entity.Dict{})
+	if keyword != "" {
+		db = db.Where("code LIKE ? OR name LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+
+	var dicts []entity.Dict
+	err := db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC, id ASC")
+	}).Order("id ASC").Find(&dicts).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询字典列表失败: %v", err)
+	}
+	return dicts, nil
+}
+
+// GetByID 根据ID获取字典（包含字典项）
+func (s *DictService) GetByID(ctx context.Context, id uint) (*entity.Dict, error) {
+	var dict entity.Dict
+	err := s.db.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort ASC, id ASC")
+	}).First(&dict, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDictNotFound
+		}
+		return nil, fmt.Errorf("查询字典失败: %v", err)
+	}
+	return &dict, nil
+}
+
+// Create 创建字典，可同时创建字典项
+func (s *DictService) Create(ctx context.Context, dict *entity.Dict) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkDictConflict(tx, dict.Name, dict.Code, 0); err != nil {
+			return err
+		}
+
+		values := make(map[string]bool, len(dict.Items))
+		for _, item := range dict.Items {
+			if values[item.Value] {
+				return ErrDictItemValueConflict
+			}
+			values[item.Value] = true
+		}
+
+		if err := tx.Create(dict).Error; err != nil {
+			return fmt.Errorf("创建字典失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, dict.Code)
+	return nil
+}
+
+// Update 更新字典基本信息（不包括字典项）
+func (s *DictService) Update(ctx context.Context, id uint, dict *entity.Dict) error {
+	var existing entity.Dict
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&existing, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDictNotFound
+			}
+			return fmt.Errorf("查询字典失败: %v", err)
+		}
+
+		if err := checkDictConflict(tx, dict.Name, dict.Code, id); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"name":        dict.Name,
+			"code":        dict.Code,
+			"description": dict.Description,
+			"status":      dict.Status,
+		}
+		if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新字典失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 编码可能发生变化，新旧编码的缓存都需要失效
+	s.invalidate(ctx, existing.Code, dict.Code)
+	return nil
+}
+
+// Delete 删除字典及其字典项
+func (s *DictService) Delete(ctx context.Context, id uint) error {
+	var dict entity.Dict
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&dict, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDictNotFound
+			}
+			return fmt.Errorf("查询字典失败: %v", err)
+		}
+
+		if err := tx.Where("dict_id = ?", id).Delete(&entity.DictItem{}).Error; err != nil {
+			return fmt.Errorf("删除字典项失败: %v", err)
+		}
+
+		if err := tx.Delete(&dict).Error; err != nil {
+			return fmt.Errorf("删除字典失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, dict.Code)
+	return nil
+}
+
+// AddItem 为字典添加字典项
+func (s *DictService) AddItem(ctx context.Context, dictID uint, item *entity.DictItem) error {
+	var dict entity.Dict
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&dict, dictID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDictNotFound
+			}
+			return fmt.Errorf("查询字典失败: %v", err)
+		}
+
+		if err := checkDictItemValue(tx, dictID, item.Value, 0); err != nil {
+			return err
+		}
+
+		item.DictID = dictID
+		if err := tx.Create(item).Error; err != nil {
+			return fmt.Errorf("创建字典项失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, dict.Code)
+	return nil
+}
+
+// UpdateItem 更新字典项
+func (s *DictService) UpdateItem(ctx context.Context, dictID, itemID uint, item *entity.DictItem) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := findDictItem(tx, dictID, itemID)
+		if err != nil {
+			return err
+		}
+
+		if item.Value != existing.Value {
+			if err := checkDictItemValue(tx, dictID, item.Value, itemID); err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{
+			"label":       item.Label,
+			"value":       item.Value,
+			"sort":        item.Sort,
+			"status":      item.Status,
+			"description": item.Description,
+		}
+		if err := tx.Model(existing).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新字典项失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateByID(ctx, dictID)
+	return nil
+}
+
+// UpdateItemStatus 启用或禁用字典项
+func (s *DictService) UpdateItemStatus(ctx context.Context, dictID, itemID uint, status int) error {
+	item, err := findDictItem(s.db.WithContext(ctx), dictID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Model(item).Update("status", status).Error; err != nil {
+		return fmt.Errorf("更新字典项状态失败: %v", err)
+	}
+
+	s.invalidateByID(ctx, dictID)
+	return nil
+}
+
+// DeleteItem 删除字典项
+func (s *DictService) DeleteItem(ctx context.Context, dictID, itemID uint) error {
+	item, err := findDictItem(s.db.WithContext(ctx), dictID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(item).Error; err != nil {
+		return fmt.Errorf("删除字典项失败: %v", err)
+	}
+
+	s.invalidateByID(ctx, dictID)
+	return nil
+}
+
+// invalidateByID 根据字典ID删除对应的字典缓存
+func (s *DictService) invalidateByID(ctx context.Context, dictID uint) {
+	var dict entity.Dict
+	if err := s.db.WithContext(ctx).Unscoped().Select("code").First(&dict, dictID).Error; err != nil {
+		log.Printf("查询字典编码失败，无法删除字典缓存: %v", err)
+		return
+	}
+	s.invalidate(ctx, dict.Code)
+}
+
+// findDictItem 查询属于指定字典的字典项
+func findDictItem(db *gorm.DB, dictID, itemID uint) (*entity.DictItem, error) {
+	var item entity.DictItem
+	if err := db.Where("dict_id = ?", dictID).First(&item, itemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDictItemNotFound
+		}
+		return nil, fmt.Errorf("查询字典项失败: %v", err)
+	}
+	return &item, nil
+}
+
+// checkDictConflict 检查字典名称或编码是否被其他字典占用
+func checkDictConflict(tx *gorm.DB, name, code string, excludeID uint) error {
+	var count int64
+	err := tx.Unscoped().Model(&entity.Dict{}).
+		Where("(name = ? OR code = ?) AND id != ?", name, code, excludeID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("检查字典编码失败: %v", err)
+	}
+	if count > 0 {
+		return ErrDictConflict
+	}
+	return nil
+}
+
+// checkDictItemValue 检查同一字典下字典项的值是否重复
+func checkDictItemValue(tx *gorm.DB, dictID uint, value string, excludeID uint) error {
+	var count int64
+	err := tx.Model(&entity.DictItem{}).
+		Where("dict_id = ? AND value = ? AND id != ?", dictID, value, excludeID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("检查字典项失败: %v", err)
+	}
+	if count > 0 {
+		return ErrDictItemValueConflict
+	}
+	return nil
+}
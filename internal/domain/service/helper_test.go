@@ -0,0 +1,40 @@
+package service
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"gva/internal/domain/audit"
+	"gva/internal/domain/entity"
+	"gva/internal/infrastructure/database"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 创建迁移了全部表的临时SQLite数据库
+func newTestDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(db))
+	return db
+}
+
+// lastAuditChanges 返回实体最近一条指定操作的审计事件中的字段变更
+func lastAuditChanges(t *testing.T, db *gorm.DB, entityType string, entityID uint, action string) map[string]audit.Change {
+	var event entity.AuditEvent
+	err := db.Where("entity_type = ? AND entity_id = ? AND action = ?", entityType, entityID, action).Order("id DESC").First(&event).Error
+	require.NoError(t, err)
+
+	var changes []audit.Change
+	require.NoError(t, json.Unmarshal(event.Changes, &changes))
+	byField := make(map[string]audit.Change, len(changes))
+	for _, change := range changes {
+		byField[change.Field] = change
+	}
+	return byField
+}
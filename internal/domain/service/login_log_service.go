@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"gva/internal/domain/entity"
+	"gva/internal/pkg/useragent"
+
+	"gorm.io/gorm"
+)
+
+// LoginClient 发起登录的客户端信息
+type LoginClient struct {
+	IP        string
+	UserAgent string
+}
+
+// LoginLogFilter 登录日志查询条件
+type LoginLogFilter struct {
+	UserID    uint
+	Username  string
+	Result    string
+	IP        string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+type LoginLogService struct {
+	db *gorm.DB
+}
+
+func NewLoginLogService(db *gorm.DB) *LoginLogService {
+	return &LoginLogService{db: db}
+}
+
+// Record 记录一次登录尝试，userID 为用户名对应的用户，用户不存在时为0
+func (s *LoginLogService) Record(ctx context.Context, username string, userID uint, result string, client LoginClient) error {
+	info := useragent.Parse(client.UserAgent)
+	log := &entity.LoginLog{
+		Username:  truncateRunes(username, 64),
+		UserID:    userID,
+		Result:    result,
+		IP:        client.IP,
+		UserAgent: truncateRunes(client.UserAgent, 512),
+		Browser:   truncateRunes(info.Browser, 64),
+		OS:        truncateRunes(info.OS, 64),
+		Device:    info.Device,
+	}
+	if err := s.db.WithContext(ctx).Create(log).Error; err != nil {
+		return fmt.Errorf("记录登录日志失败: %v", err)
+	}
+	return nil
+}
+
+// List 分页查询登录日志，按时间倒序
+func (s *LoginLogService) List(ctx context.Context, page, pageSize int, filter LoginLogFilter) ([]entity.LoginLog, int64, error) {
+	db := s.db.WithContext(ctx).Model(&entity.LoginLog{})
+	if filter.UserID > 0 {
+		db = db.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Username != "" {
+		db = db.Where("username = ?", filter.Username)
+	}
+	if filter.Result != "" {
+		db = db.Where("result = ?", filter.Result)
+	}
+	if filter.IP != "" {
+		db = db.Where("ip = ?", filter.IP)
+	}
+	if filter.StartTime != nil {
+		db = db.Where("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		db = db.Where("created_at < ?", *filter.EndTime)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计登录日志失败: %v", err)
+	}
+
+	var logs []entity.LoginLog
+	err := db.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询登录日志失败: %v", err)
+	}
+	return logs, total, nil
+}
+
+// Recent 获取用户最近的登录记录
+func (s *LoginLogService) Recent(ctx context.Context, userID uint, limit int) ([]entity.LoginLog, error) {
+	var logs []entity.LoginLog
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询登录日志失败: %v", err)
+	}
+	return logs, nil
+}
+
+// truncateRunes 将字符串截断到最多 n 个字符，避免超出列长度
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,637 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/message"
+
+	"gorm.io/gorm"
+)
+
+// 定义消息相关的错误
+var (
+	ErrMessageTemplateNotFound = errors.New("消息模板不存在")
+	ErrMessageTemplateConflict = errors.New("消息模板编码已存在")
+	ErrMessageTemplateDisabled = errors.New("消息模板已禁用")
+	ErrMessageTemplateInvalid  = errors.New("消息模板无效")
+	ErrMessageChannel          = errors.New("不支持的消息渠道")
+	ErrMessageDeliveryNotFound = errors.New("投递记录不存在")
+)
+
+const (
+	messageMaxAttempts   = 5           // 每条投递记录的最大尝试次数
+	messageRetryInterval = time.Minute // 首次重试间隔，之后每次翻倍
+	messageRetryBatch    = 100         // 每次重试处理的最大记录数
+	messageSubjectMaxLen = 256         // 主题的最大字符数，与投递记录的列长度一致
+	notificationTitleMax = 128         // 站内通知标题的最大字符数
+	defaultMessageLocale = "zh-CN"
+)
+
+// messageChannels 支持的消息渠道
+var messageChannels = map[string]bool{
+	entity.MessageChannelInApp:   true,
+	entity.MessageChannelEmail:   true,
+	entity.MessageChannelWebhook: true,
+}
+
+type MessageService struct {
+	db            *gorm.DB
+	defaultLocale string
+	senders       map[string]message.Sender
+	wake          chan struct{} // 有新的投递记录时唤醒投递协程
+}
+
+// NewMessageService 创建消息服务，只有注册了发送器的渠道才能投递
+func NewMessageService(db *gorm.DB, defaultLocale string, senders ...message.Sender) *MessageService {
+	if defaultLocale == "" {
+		defaultLocale = defaultMessageLocale
+	}
+	s := &MessageService{
+		db:            db,
+		defaultLocale: defaultLocale,
+		senders:       make(map[string]message.Sender),
+		wake:          make(chan struct{}, 1),
+	}
+	for _, sender := range senders {
+		s.senders[sender.Channel()] = sender
+	}
+	return s
+}
+
+// DispatchRequest 按模板发送消息的请求
+type DispatchRequest struct {
+	TemplateCode string                 // 模板编码
+	UserIDs      []uint                 // 接收用户
+	Channels     []string               // 投递渠道，为空时使用模板的默认渠道
+	Locale       string                 // 语言，模板没有该语言时使用默认语言
+	Vars         map[string]interface{} // 模板变量，另外会自动注入 UserID、Username、Nickname、Email
+}
+
+// DeliveryFilter 投递记录查询条件
+type DeliveryFilter struct {
+	UserID       uint
+	TemplateCode string
+	Channel      string
+	Status       *int
+}
+
+// ListTemplates 获取消息模板列表（包含各语言的内容）
+func (s *MessageService) ListTemplates(ctx context.Context, keyword string) ([]entity.MessageTemplate, error) {
+	var templates []entity.MessageTemplate
+	db := s.db.WithContext(ctx).Preload("Contents")
+	if keyword != "" {
+		db = db.Where("name LIKE ? OR code LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if err := db.Order("id").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("查询消息模板失败: %v", err)
+	}
+	return templates, nil
+}
+
+// GetTemplate 获取消息模板
+func (s *MessageService) GetTemplate(ctx context.Context, id uint) (*entity.MessageTemplate, error) {
+	var tpl entity.MessageTemplate
+	if err := s.db.WithContext(ctx).Preload("Contents").First(&tpl, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageTemplateNotFound
+		}
+		return nil, fmt.Errorf("查询消息模板失败: %v", err)
+	}
+	return &tpl, nil
+}
+
+// CreateTemplate 创建消息模板
+func (s *MessageService) CreateTemplate(ctx context.Context, tpl *entity.MessageTemplate) error {
+	if err := validateMessageTemplate(tpl); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkMessageTemplateCode(tx, tpl.Code, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(tpl).Error; err != nil {
+			return fmt.Errorf("创建消息模板失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// UpdateTemplate 更新消息模板，各语言的内容整体替换
+func (s *MessageService) UpdateTemplate(ctx context.Context, id uint, tpl *entity.MessageTemplate) error {
+	if err := validateMessageTemplate(tpl); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing entity.MessageTemplate
+		if err := tx.First(&existing, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMessageTemplateNotFound
+			}
+			return fmt.Errorf("查询消息模板失败: %v", err)
+		}
+		if err := checkMessageTemplateCode(tx, tpl.Code, id); err != nil {
+			return err
+		}
+
+		err := tx.Model(&existing).Updates(map[string]interface{}{
+			"code":              tpl.Code,
+			"name":              tpl.Name,
+			"channels":          tpl.Channels,
+			"notification_type": tpl.NotificationType,
+			"status":            tpl.Status,
+			"description":       tpl.Description,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("更新消息模板失败: %v", err)
+		}
+
+		// 内容按 (模板, 语言) 唯一，需要物理删除后重新创建
+		if err := tx.Unscoped().Where("template_id = ?", id).Delete(&entity.MessageTemplateContent{}).Error; err != nil {
+			return fmt.Errorf("更新消息模板内容失败: %v", err)
+		}
+		for i := range tpl.Contents {
+			tpl.Contents[i].ID = 0
+			tpl.Contents[i].TemplateID = id
+		}
+		if err := tx.Create(&tpl.Contents).Error; err != nil {
+			return fmt.Errorf("更新消息模板内容失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// DeleteTemplate 删除消息模板
+func (s *MessageService) DeleteTemplate(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&entity.MessageTemplate{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("删除消息模板失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrMessageTemplateNotFound
+		}
+		if err := tx.Unscoped().Where("template_id = ?", id).Delete(&entity.MessageTemplateContent{}).Error; err != nil {
+			return fmt.Errorf("删除消息模板内容失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// Dispatch 渲染模板并按用户和渠道生成待投递的记录，由 RunRetry 在后台投递
+// 请求不等待邮件、Webhook 等外部渠道，投递失败的记录按指数退避重试
+func (s *MessageService) Dispatch(ctx context.Context, req DispatchRequest) ([]entity.MessageDelivery, error) {
+	var tpl entity.MessageTemplate
+	err := s.db.WithContext(ctx).Preload("Contents").Where("code = ?", req.TemplateCode).First(&tpl).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageTemplateNotFound
+		}
+		return nil, fmt.Errorf("查询消息模板失败: %v", err)
+	}
+	if tpl.Status != 1 {
+		return nil, ErrMessageTemplateDisabled
+	}
+
+	channels := req.Channels
+	if len(channels) == 0 {
+		channels = splitTargets(tpl.Channels)
+	}
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("%w: 未指定投递渠道", ErrMessageChannel)
+	}
+	for _, channel := range channels {
+		if s.senders[channel] == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMessageChannel, channel)
+		}
+	}
+
+	content := s.pickContent(tpl.Contents, req.Locale)
+	if content == nil {
+		return nil, fmt.Errorf("%w: 模板没有任何内容", ErrMessageTemplateInvalid)
+	}
+	subjectTpl, bodyTpl, err := parseMessageContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []entity.User
+	if err := s.db.WithContext(ctx).Where("id IN ?", req.UserIDs).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("查询接收用户失败: %v", err)
+	}
+	if len(users) == 0 {
+		return nil, ErrNotificationNoReceivers
+	}
+
+	// 先渲染所有消息，渲染失败时不产生任何投递记录
+	now := time.Now()
+	var deliveries []entity.MessageDelivery
+	for i := range users {
+		user := &users[i]
+		data := messageVars(user, req.Vars)
+		subject, err := renderMessage(subjectTpl, data)
+		if err != nil {
+			return nil, err
+		}
+		subject = truncateRunes(subject, messageSubjectMaxLen)
+		body, err := renderMessage(bodyTpl, data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, channel := range channels {
+			delivery := entity.MessageDelivery{
+				TemplateID:   tpl.ID,
+				TemplateCode: tpl.Code,
+				UserID:       user.ID,
+				Channel:      channel,
+				Recipient:    s.senders[channel].Recipient(user),
+				Locale:       content.Locale,
+				Subject:      subject,
+				Body:         body,
+				Status:       entity.MessageDeliveryPending,
+				NextRetryAt:  &now,
+			}
+			if delivery.Recipient == "" {
+				delivery.Status = entity.MessageDeliveryFailed
+				delivery.LastError = "用户没有该渠道的接收地址"
+				delivery.NextRetryAt = nil
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	if err := s.db.WithContext(ctx).CreateInBatches(&deliveries, notificationBatchSize).Error; err != nil {
+		return nil, fmt.Errorf("创建投递记录失败: %v", err)
+	}
+
+	// 唤醒投递协程，已有待处理的唤醒时不重复通知
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return deliveries, nil
+}
+
+// ListDeliveries 分页查询投递记录
+func (s *MessageService) ListDeliveries(ctx context.Context, page, pageSize int, filter DeliveryFilter) ([]entity.MessageDelivery, int64, error) {
+	db := s.db.WithContext(ctx).Model(&entity.MessageDelivery{})
+	if filter.UserID > 0 {
+		db = db.Where("user_id = ?", filter.UserID)
+	}
+	if filter.TemplateCode != "" {
+		db = db.Where("template_code = ?", filter.TemplateCode)
+	}
+	if filter.Channel != "" {
+		db = db.Where("channel = ?", filter.Channel)
+	}
+	if filter.Status != nil {
+		db = db.Where("status = ?", *filter.Status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计投递记录失败: %v", err)
+	}
+
+	var deliveries []entity.MessageDelivery
+	err := db.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询投递记录失败: %v", err)
+	}
+	return deliveries, total, nil
+}
+
+// Retry 立即重新投递一条未成功的记录，已失败的记录会重新计算尝试次数
+func (s *MessageService) Retry(ctx context.Context, id uint) (*entity.MessageDelivery, error) {
+	var delivery entity.MessageDelivery
+	if err := s.db.WithContext(ctx).First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageDeliveryNotFound
+		}
+		return nil, fmt.Errorf("查询投递记录失败: %v", err)
+	}
+	if delivery.Status == entity.MessageDeliverySuccess {
+		return &delivery, nil
+	}
+
+	if delivery.Status == entity.MessageDeliveryFailed {
+		if sender := s.senders[delivery.Channel]; sender != nil && delivery.Recipient == "" {
+			// 用户可能已经补充了接收地址，如邮箱
+			var user entity.User
+			if err := s.db.WithContext(ctx).First(&user, delivery.UserID).Error; err == nil {
+				delivery.Recipient = sender.Recipient(&user)
+			}
+		}
+		err := s.db.WithContext(ctx).Model(&delivery).Updates(map[string]interface{}{
+			"status":    entity.MessageDeliveryPending,
+			"attempts":  0,
+			"recipient": delivery.Recipient,
+		}).Error
+		if err != nil {
+			return nil, fmt.Errorf("重置投递记录失败: %v", err)
+		}
+		delivery.Status = entity.MessageDeliveryPending
+		delivery.Attempts = 0
+	}
+
+	if err := s.attempt(ctx, &delivery); err != nil {
+		return &delivery, err
+	}
+	return &delivery, nil
+}
+
+// RetryDue 投递待投递和已到重试时间的记录，返回处理的记录数
+func (s *MessageService) RetryDue(ctx context.Context) (int, error) {
+	var deliveries []entity.MessageDelivery
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND next_retry_at <= ?", entity.MessageDeliveryPending, time.Now()).
+		Order("next_retry_at").
+		Limit(messageRetryBatch).
+		Find(&deliveries).Error
+	if err != nil {
+		return 0, fmt.Errorf("查询待重试的投递记录失败: %v", err)
+	}
+
+	for i := range deliveries {
+		if err := s.attempt(ctx, &deliveries[i]); err != nil {
+			log.Printf("重试投递消息失败 - DeliveryID: %d, Error: %v", deliveries[i].ID, err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// RunRetry 投递新的消息，并按固定间隔重试投递失败的消息，直到 ctx 结束
+// 本实例创建的投递记录会立即唤醒投递，其他实例创建的记录在下一个间隔处理
+func (s *MessageService) RunRetry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		// 一批处理满时可能还有更多到期的记录，继续处理
+		for {
+			n, err := s.RetryDue(ctx)
+			if err != nil {
+				log.Printf("投递消息失败: %v", err)
+			}
+			if err != nil || n < messageRetryBatch || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
+
+// attempt 尝试投递一次，返回投递失败的原因
+// 投递前先递增尝试次数并预设下次重试时间，多个实例同时处理时只有一个能抢到本次投递，
+// 进程在投递过程中退出时，记录也会在重试时间到达后被重新投递
+func (s *MessageService) attempt(ctx context.Context, delivery *entity.MessageDelivery) error {
+	attempts := delivery.Attempts + 1
+	nextRetryAt := time.Now().Add(messageRetryInterval << (attempts - 1))
+	result := s.db.WithContext(ctx).Model(&entity.MessageDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, entity.MessageDeliveryPending, delivery.Attempts).
+		Updates(map[string]interface{}{
+			"attempts":      attempts,
+			"next_retry_at": nextRetryAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新投递记录失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil // 已被其他实例处理
+	}
+	delivery.Attempts = attempts
+	delivery.NextRetryAt = &nextRetryAt
+
+	sendErr := s.send(ctx, delivery)
+
+	updates := map[string]interface{}{}
+	if sendErr == nil {
+		now := time.Now()
+		delivery.Status = entity.MessageDeliverySuccess
+		delivery.SentAt = &now
+		delivery.NextRetryAt = nil
+		delivery.LastError = ""
+		updates["sent_at"] = &now
+	} else {
+		delivery.LastError = truncateError(sendErr.Error(), 512)
+		if attempts >= messageMaxAttempts {
+			delivery.Status = entity.MessageDeliveryFailed
+			delivery.NextRetryAt = nil
+		}
+	}
+	updates["status"] = delivery.Status
+	updates["next_retry_at"] = delivery.NextRetryAt
+	updates["last_error"] = delivery.LastError
+
+	if err := s.db.WithContext(ctx).Model(delivery).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新投递记录失败: %v", err)
+	}
+	return sendErr
+}
+
+func (s *MessageService) send(ctx context.Context, delivery *entity.MessageDelivery) error {
+	sender := s.senders[delivery.Channel]
+	if sender == nil {
+		return fmt.Errorf("%w: %s", ErrMessageChannel, delivery.Channel)
+	}
+	if delivery.Recipient == "" {
+		return errors.New("用户没有该渠道的接收地址")
+	}
+
+	msg := &message.Message{
+		DeliveryID:   delivery.ID,
+		TemplateCode: delivery.TemplateCode,
+		UserID:       delivery.UserID,
+		Recipient:    delivery.Recipient,
+		Subject:      delivery.Subject,
+		Body:         delivery.Body,
+	}
+	if delivery.Channel == entity.MessageChannelInApp {
+		var tpl entity.MessageTemplate
+		if err := s.db.WithContext(ctx).Unscoped().Select("notification_type").First(&tpl, delivery.TemplateID).Error; err == nil {
+			msg.Type = tpl.NotificationType
+		}
+	}
+	return sender.Send(ctx, msg)
+}
+
+// pickContent 按请求的语言、语言前缀（如 zh）、默认语言的顺序选择模板内容，
+// 语言前缀可匹配同一语言的其他地区，如 en 匹配 en-US
+func (s *MessageService) pickContent(contents []entity.MessageTemplateContent, locale string) *entity.MessageTemplateContent {
+	if len(contents) == 0 {
+		return nil
+	}
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, s.defaultLocale)
+
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		candidate = strings.ToLower(candidate)
+		for i := range contents {
+			contentLocale := strings.ToLower(contents[i].Locale)
+			if contentLocale == candidate || strings.HasPrefix(contentLocale, candidate+"-") {
+				return &contents[i]
+			}
+		}
+	}
+	return &contents[0]
+}
+
+// InAppSender 将消息作为站内通知发送
+type InAppSender struct {
+	notificationService *NotificationService
+}
+
+func NewInAppSender(notificationService *NotificationService) *InAppSender {
+	return &InAppSender{notificationService: notificationService}
+}
+
+func (s *InAppSender) Channel() string {
+	return entity.MessageChannelInApp
+}
+
+func (s *InAppSender) Recipient(user *entity.User) string {
+	return strconv.FormatUint(uint64(user.ID), 10)
+}
+
+// Send 为用户创建站内通知，同一投递记录重复投递时只创建一次
+// 进程在创建通知后、更新投递状态前退出时，记录会被重新投递
+func (s *InAppSender) Send(ctx context.Context, msg *message.Message) error {
+	if msg.DeliveryID > 0 {
+		var count int64
+		err := s.notificationService.db.WithContext(ctx).Model(&entity.Notification{}).
+			Where("delivery_id = ?", msg.DeliveryID).
+			Count(&count).Error
+		if err != nil {
+			return fmt.Errorf("查询站内通知失败: %v", err)
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+
+	notificationType := msg.Type
+	if notificationType == "" {
+		notificationType = "system"
+	}
+	notification := &entity.Notification{
+		Title:   truncateRunes(msg.Subject, notificationTitleMax),
+		Content: msg.Body,
+		Type:    notificationType,
+	}
+	if msg.DeliveryID > 0 {
+		notification.DeliveryID = &msg.DeliveryID
+	}
+	return s.notificationService.Send(ctx, 0, notification, NotificationTarget{UserIDs: []uint{msg.UserID}})
+}
+
+// validateMessageTemplate 校验模板的渠道、语言和模板语法
+func validateMessageTemplate(tpl *entity.MessageTemplate) error {
+	for _, channel := range splitTargets(tpl.Channels) {
+		if !messageChannels[channel] {
+			return fmt.Errorf("%w: %s", ErrMessageChannel, channel)
+		}
+	}
+	if len(tpl.Contents) == 0 {
+		return fmt.Errorf("%w: 至少需要一种语言的内容", ErrMessageTemplateInvalid)
+	}
+
+	locales := make(map[string]bool)
+	for i := range tpl.Contents {
+		locale := strings.ToLower(tpl.Contents[i].Locale)
+		if locales[locale] {
+			return fmt.Errorf("%w: 语言 %s 重复", ErrMessageTemplateInvalid, tpl.Contents[i].Locale)
+		}
+		locales[locale] = true
+		if _, _, err := parseMessageContent(&tpl.Contents[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkMessageTemplateCode(tx *gorm.DB, code string, excludeID uint) error {
+	var count int64
+	err := tx.Unscoped().Model(&entity.MessageTemplate{}).
+		Where("code = ? AND id != ?", code, excludeID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("检查消息模板编码失败: %v", err)
+	}
+	if count > 0 {
+		return ErrMessageTemplateConflict
+	}
+	return nil
+}
+
+// parseMessageContent 解析模板内容的主题和正文，引用未提供的变量时渲染报错
+func parseMessageContent(content *entity.MessageTemplateContent) (*template.Template, *template.Template, error) {
+	subject, err := template.New("subject").Option("missingkey=error").Parse(content.Subject)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s 主题解析失败: %v", ErrMessageTemplateInvalid, content.Locale, err)
+	}
+	body, err := template.New("body").Option("missingkey=error").Parse(content.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s 正文解析失败: %v", ErrMessageTemplateInvalid, content.Locale, err)
+	}
+	return subject, body, nil
+}
+
+func renderMessage(tpl *template.Template, data map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: 渲染失败: %v", ErrMessageTemplateInvalid, err)
+	}
+	return buf.String(), nil
+}
+
+// messageVars 合并用户信息和请求中的模板变量，请求中的变量优先
+func messageVars(user *entity.User, vars map[string]interface{}) map[string]interface{} {
+	data := map[string]interface{}{
+		"UserID":   user.ID,
+		"Username": user.Username,
+		"Nickname": user.Nickname,
+		"Email":    user.Email,
+	}
+	for k, v := range vars {
+		data[k] = v
+	}
+	return data
+}
+
+func truncateError(msg string, max int) string {
+	if len(msg) <= max {
+		return msg
+	}
+	// 避免截断多字节字符
+	msg = msg[:max]
+	for !utf8.ValidString(msg) {
+		msg = msg[:len(msg)-1]
+	}
+	return msg
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/message"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeSender 记录发送的消息
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []message.Message
+}
+
+func (s *fakeSender) Channel() string { return entity.MessageChannelWebhook }
+
+func (s *fakeSender) Recipient(user *entity.User) string { return "https://example.com/hook" }
+
+func (s *fakeSender) Send(ctx context.Context, msg *message.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, *msg)
+	return nil
+}
+
+func (s *fakeSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func createTestTemplate(t *testing.T, db *gorm.DB, subject string) *entity.MessageTemplate {
+	tpl := &entity.MessageTemplate{
+		Code:     "welcome",
+		Name:     "欢迎",
+		Channels: entity.MessageChannelWebhook,
+		Status:   1,
+		Contents: []entity.MessageTemplateContent{{Locale: "zh-CN", Subject: subject, Body: "{{.Nickname}}，您好"}},
+	}
+	require.NoError(t, db.Create(tpl).Error)
+	return tpl
+}
+
+// Dispatch 只保存投递记录，由投递协程发送
+func TestDispatchQueuesDeliveries(t *testing.T) {
+	db := newTestDB(t)
+	sender := &fakeSender{}
+	s := NewMessageService(db, "", sender)
+	user := createTestUser(t, db)
+	createTestTemplate(t, db, strings.Repeat("长", 300))
+
+	deliveries, err := s.Dispatch(context.Background(), DispatchRequest{TemplateCode: "welcome", UserIDs: []uint{user.ID}})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, entity.MessageDeliveryPending, deliveries[0].Status)
+	assert.Equal(t, messageSubjectMaxLen, utf8.RuneCountInString(deliveries[0].Subject), "主题按列长度截断")
+	assert.Zero(t, sender.count(), "请求中不发送消息")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.RunRetry(ctx, time.Hour)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	assert.Eventually(t, func() bool { return sender.count() == 1 }, 5*time.Second, 10*time.Millisecond, "新的投递记录唤醒投递协程")
+	assert.Eventually(t, func() bool {
+		var delivery entity.MessageDelivery
+		return db.First(&delivery, deliveries[0].ID).Error == nil && delivery.Status == entity.MessageDeliverySuccess
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// 同一投递记录重复投递到站内通知时只创建一条通知
+func TestInAppSenderIdempotent(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db)
+	sender := NewInAppSender(NewNotificationService(db, nil))
+
+	msg := &message.Message{DeliveryID: 42, UserID: user.ID, Subject: strings.Repeat("题", 200), Body: "正文"}
+	require.NoError(t, sender.Send(context.Background(), msg))
+	require.NoError(t, sender.Send(context.Background(), msg))
+
+	var notifications []entity.Notification
+	require.NoError(t, db.Find(&notifications).Error)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, notificationTitleMax, utf8.RuneCountInString(notifications[0].Title))
+
+	var received int64
+	require.NoError(t, db.Model(&entity.UserNotification{}).Where("user_id = ?", user.ID).Count(&received).Error)
+	assert.EqualValues(t, 1, received)
+}
@@ -0,0 +1,506 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/push"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 定义通知相关的错误
+var (
+	ErrNotificationNotFound    = errors.New("通知不存在")
+	ErrNotificationNoReceivers = errors.New("没有有效的接收用户")
+	ErrNotificationTarget      = errors.New("未指定有效的通知接收对象")
+)
+
+// notificationBatchSize 批量写入用户通知的批次大小
+const notificationBatchSize = 500
+
+type NotificationService struct {
+	db        *gorm.DB
+	publisher push.Publisher
+}
+
+func NewNotificationService(db *gorm.DB, publisher push.Publisher) *NotificationService {
+	// 如果没有推送服务，不推送实时消息
+	if publisher == nil {
+		publisher = push.NopPublisher{}
+	}
+	return &NotificationService{db: db, publisher: publisher}
+}
+
+// InboxItem 用户收件箱中的一条通知
+type InboxItem struct {
+	ID         uint       `json:"id"`          // 通知ID
+	Title      string     `json:"title"`       // 标题
+	Content    string     `json:"content"`     // 内容
+	Type       string     `json:"type"`        // 通知类型
+	SenderID   uint       `json:"sender_id"`   // 发送者ID
+	ReadStatus int        `json:"read_status"` // 阅读状态
+	ReadTime   *time.Time `json:"read_time"`   // 阅读时间
+	CreatedAt  time.Time  `json:"created_at"`  // 接收时间
+}
+
+// NotificationTarget 通知的接收对象
+type NotificationTarget struct {
+	Type          string   // 接收对象类型，为空时按指定用户发送
+	UserIDs       []uint   // 指定用户
+	RoleCodes     []string // 指定角色编码
+	DepartmentIDs []uint   // 指定部门，包含下级部门
+}
+
+// Send 按接收对象发送通知，不存在的用户会被忽略
+// notification.ScheduledAt 晚于当前时间时只保存通知，到达发送时间后由 DispatchScheduled 投递
+func (s *NotificationService) Send(ctx context.Context, senderID uint, notification *entity.Notification, target NotificationTarget) error {
+	targetType, targets, err := encodeNotificationTarget(target)
+	if err != nil {
+		return err
+	}
+	notification.SenderID = senderID
+	notification.TargetType = targetType
+	notification.Targets = targets
+
+	if notification.ScheduledAt != nil && notification.ScheduledAt.After(time.Now()) {
+		notification.Status = entity.NotificationStatusScheduled
+		if err := s.db.WithContext(ctx).Create(notification).Error; err != nil {
+			return fmt.Errorf("创建通知失败: %v", err)
+		}
+		return nil
+	}
+
+	var receiverIDs []uint
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		notification.Status = entity.NotificationStatusSent
+		notification.SentAt = &now
+		if err := tx.Create(notification).Error; err != nil {
+			return fmt.Errorf("创建通知失败: %v", err)
+		}
+
+		var count int
+		var err error
+		receiverIDs, count, err = deliverNotification(tx, notification)
+		if err != nil {
+			return err
+		}
+		// 全员广播允许暂时没有用户，之后创建的用户查看收件箱时会补齐
+		if count == 0 && notification.TargetType != entity.NotificationTargetAll {
+			return ErrNotificationNoReceivers
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 事务提交后再推送，避免客户端收到推送时还查询不到通知
+	s.pushSent(ctx, notification, receiverIDs)
+	return nil
+}
+
+// DispatchScheduled 投递已到发送时间的定时通知，返回投递的通知数量
+// 多个实例同时执行时，通过带状态条件的更新保证每条通知只投递一次
+func (s *NotificationService) DispatchScheduled(ctx context.Context) (int, error) {
+	var due []entity.Notification
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND scheduled_at <= ?", entity.NotificationStatusScheduled, time.Now()).
+		Order("scheduled_at").
+		Find(&due).Error
+	if err != nil {
+		return 0, fmt.Errorf("查询待发送通知失败: %v", err)
+	}
+
+	dispatched := 0
+	for i := range due {
+		notification := &due[i]
+		var receiverIDs []uint
+		claimed := false
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			result := tx.Model(&entity.Notification{}).
+				Where("id = ? AND status = ?", notification.ID, entity.NotificationStatusScheduled).
+				Updates(map[string]interface{}{
+					"status":  entity.NotificationStatusSent,
+					"sent_at": &now,
+				})
+			if result.Error != nil {
+				return fmt.Errorf("更新通知状态失败: %v", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return nil // 已被其他实例投递
+			}
+			claimed = true
+			notification.Status = entity.NotificationStatusSent
+			notification.SentAt = &now
+
+			var err error
+			receiverIDs, _, err = deliverNotification(tx, notification)
+			return err
+		})
+		if err != nil {
+			return dispatched, fmt.Errorf("投递定时通知失败 - ID: %d: %v", notification.ID, err)
+		}
+		if claimed {
+			dispatched++
+			s.pushSent(ctx, notification, receiverIDs)
+		}
+	}
+	return dispatched, nil
+}
+
+// RunScheduler 按固定间隔投递定时通知，直到 ctx 结束
+func (s *NotificationService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.DispatchScheduled(ctx); err != nil {
+				log.Printf("投递定时通知失败: %v", err)
+			} else if n > 0 {
+				log.Printf("已投递定时通知: %d 条", n)
+			}
+		}
+	}
+}
+
+// List 获取已发送的通知列表（管理端）
+func (s *NotificationService) List(ctx context.Context, page, pageSize int) ([]entity.Notification, int64, error) {
+	var total int64
+	var notifications []entity.Notification
+
+	db := s.db.WithContext(ctx).Model(&entity.Notification{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计通知数量失败: %v", err)
+	}
+
+	err := db.Preload("Sender").
+		Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询通知列表失败: %v", err)
+	}
+	return notifications, total, nil
+}
+
+// Inbox 获取用户的收件箱，readStatus 不为空时按阅读状态筛选
+func (s *NotificationService) Inbox(ctx context.Context, userID uint, page, pageSize int, readStatus *int) ([]InboxItem, int64, error) {
+	if err := s.syncBroadcasts(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.db.WithContext(ctx).Model(&entity.UserNotification{}).
+		Joins("JOIN notifications ON notifications.id = user_notifications.notification_id AND notifications.deleted_at IS NULL").
+		Where("user_notifications.user_id = ?", userID)
+	if readStatus != nil {
+		db = db.Where("user_notifications.read_status = ?", *readStatus)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计通知数量失败: %v", err)
+	}
+
+	items := []InboxItem{}
+	err := db.Select("notifications.id, notifications.title, notifications.content, notifications.type, notifications.sender_id, " +
+		"user_notifications.read_status, user_notifications.read_time, user_notifications.created_at").
+		Order("user_notifications.id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&items).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询收件箱失败: %v", err)
+	}
+	return items, total, nil
+}
+
+// UnreadCount 获取用户的未读通知数量
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uint) (int64, error) {
+	if err := s.syncBroadcasts(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err := s.db.WithContext(ctx).Model(&entity.UserNotification{}).
+		Joins("JOIN notifications ON notifications.id = user_notifications.notification_id AND notifications.deleted_at IS NULL").
+		Where("user_notifications.user_id = ? AND user_notifications.read_status = ?", userID, entity.NotificationUnread).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("统计未读通知失败: %v", err)
+	}
+	return count, nil
+}
+
+// MarkRead 将用户的一条通知标记为已读
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID uint) error {
+	userNotification, err := s.findUserNotification(ctx, userID, notificationID)
+	if err != nil {
+		return err
+	}
+	if userNotification.ReadStatus == entity.NotificationRead {
+		return nil
+	}
+
+	now := time.Now()
+	err = s.db.WithContext(ctx).Model(userNotification).Updates(map[string]interface{}{
+		"read_status": entity.NotificationRead,
+		"read_time":   &now,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("标记已读失败: %v", err)
+	}
+
+	s.pushUnreadCount(ctx, userID)
+	return nil
+}
+
+// MarkAllRead 将用户的所有未读通知标记为已读，返回标记的数量
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&entity.UserNotification{}).
+		Where("user_id = ? AND read_status = ?", userID, entity.NotificationUnread).
+		Updates(map[string]interface{}{
+			"read_status": entity.NotificationRead,
+			"read_time":   &now,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("标记全部已读失败: %v", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		s.pushUnreadCount(ctx, userID)
+	}
+	return result.RowsAffected, nil
+}
+
+// Delete 从用户的收件箱中删除一条通知
+func (s *NotificationService) Delete(ctx context.Context, userID, notificationID uint) error {
+	userNotification, err := s.findUserNotification(ctx, userID, notificationID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(userNotification).Error; err != nil {
+		return fmt.Errorf("删除通知失败: %v", err)
+	}
+
+	if userNotification.ReadStatus == entity.NotificationUnread {
+		s.pushUnreadCount(ctx, userID)
+	}
+	return nil
+}
+
+// pushSent 推送已发送的通知，全员广播只推送一次通知，由客户端自行刷新未读数量
+func (s *NotificationService) pushSent(ctx context.Context, notification *entity.Notification, receiverIDs []uint) {
+	item := InboxItem{
+		ID:         notification.ID,
+		Title:      notification.Title,
+		Content:    notification.Content,
+		Type:       notification.Type,
+		SenderID:   notification.SenderID,
+		ReadStatus: entity.NotificationUnread,
+		CreatedAt:  notification.CreatedAt,
+	}
+	if notification.SentAt != nil {
+		item.CreatedAt = *notification.SentAt
+	}
+
+	if notification.TargetType == entity.NotificationTargetAll {
+		if err := s.publisher.Broadcast(ctx, push.EventNotification, item); err != nil {
+			log.Printf("广播通知失败 - NotificationID: %d, Error: %v", notification.ID, err)
+		}
+		return
+	}
+
+	for _, userID := range receiverIDs {
+		if err := s.publisher.Publish(ctx, userID, push.EventNotification, item); err != nil {
+			log.Printf("推送通知失败 - UserID: %d, Error: %v", userID, err)
+			continue
+		}
+		s.pushUnreadCount(ctx, userID)
+	}
+}
+
+// pushUnreadCount 推送用户最新的未读通知数量
+func (s *NotificationService) pushUnreadCount(ctx context.Context, userID uint) {
+	count, err := s.UnreadCount(ctx, userID)
+	if err != nil {
+		log.Printf("推送未读数量失败 - UserID: %d, Error: %v", userID, err)
+		return
+	}
+	if err := s.publisher.Publish(ctx, userID, push.EventUnreadCount, map[string]int64{"unread": count}); err != nil {
+		log.Printf("推送未读数量失败 - UserID: %d, Error: %v", userID, err)
+	}
+}
+
+// findUserNotification 查询用户收件箱中的通知
+func (s *NotificationService) findUserNotification(ctx context.Context, userID, notificationID uint) (*entity.UserNotification, error) {
+	var userNotification entity.UserNotification
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND notification_id = ?", userID, notificationID).
+		First(&userNotification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotificationNotFound
+		}
+		return nil, fmt.Errorf("查询通知失败: %v", err)
+	}
+	return &userNotification, nil
+}
+
+// syncBroadcasts 为用户补齐尚未收到的全员广播，使广播之后创建的用户也能看到
+// 已从收件箱删除的广播不会被重新加入
+func (s *NotificationService) syncBroadcasts(ctx context.Context, userID uint) error {
+	received := s.db.Unscoped().Model(&entity.UserNotification{}).
+		Select("notification_id").
+		Where("user_id = ?", userID)
+
+	var notificationIDs []uint
+	err := s.db.WithContext(ctx).Model(&entity.Notification{}).
+		Where("target_type = ? AND status = ?", entity.NotificationTargetAll, entity.NotificationStatusSent).
+		Where("id NOT IN (?)", received).
+		Pluck("id", &notificationIDs).Error
+	if err != nil {
+		return fmt.Errorf("查询广播通知失败: %v", err)
+	}
+	if len(notificationIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]entity.UserNotification, 0, len(notificationIDs))
+	for _, notificationID := range notificationIDs {
+		rows = append(rows, entity.UserNotification{
+			UserID:         userID,
+			NotificationID: notificationID,
+			ReadStatus:     entity.NotificationUnread,
+		})
+	}
+	return insertUserNotifications(s.db.WithContext(ctx), rows)
+}
+
+// deliverNotification 在发送时解析接收用户并分批写入用户通知
+// 返回接收用户ID（全员广播时不返回，改为广播推送）以及接收用户数量
+func deliverNotification(tx *gorm.DB, notification *entity.Notification) ([]uint, int, error) {
+	query, err := notificationReceivers(tx, notification)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var receiverIDs []uint
+	count := 0
+	var users []entity.User
+	err = query.Select("id").FindInBatches(&users, notificationBatchSize, func(batch *gorm.DB, _ int) error {
+		rows := make([]entity.UserNotification, 0, len(users))
+		for _, user := range users {
+			rows = append(rows, entity.UserNotification{
+				UserID:         user.ID,
+				NotificationID: notification.ID,
+				ReadStatus:     entity.NotificationUnread,
+			})
+			if notification.TargetType != entity.NotificationTargetAll {
+				receiverIDs = append(receiverIDs, user.ID)
+			}
+		}
+		count += len(users)
+		return insertUserNotifications(tx, rows)
+	}).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询接收用户失败: %v", err)
+	}
+	return receiverIDs, count, nil
+}
+
+// notificationReceivers 根据通知的接收对象构造查询接收用户的条件
+func notificationReceivers(tx *gorm.DB, notification *entity.Notification) (*gorm.DB, error) {
+	query := tx.Model(&entity.User{})
+	targets := splitTargets(notification.Targets)
+
+	switch notification.TargetType {
+	case entity.NotificationTargetAll:
+		return query, nil
+	case entity.NotificationTargetUser, "":
+		return query.Where("id IN ?", targets), nil
+	case entity.NotificationTargetRole:
+		roleIDs := tx.Model(&entity.Role{}).Select("id").Where("code IN ?", targets)
+		return query.Where("role_id IN (?)", roleIDs), nil
+	case entity.NotificationTargetDepartment:
+		// 部门路径包含自身及所有上级部门ID，按路径匹配即可包含下级部门
+		departments := tx.Model(&entity.Department{}).Select("id")
+		conditions := tx.Where("1 = 0")
+		for _, id := range targets {
+			conditions = conditions.Or("path LIKE ?", "%/"+id+"/%")
+		}
+		return query.Where("department_id IN (?)", departments.Where(conditions)), nil
+	default:
+		return nil, ErrNotificationTarget
+	}
+}
+
+// encodeNotificationTarget 校验接收对象并编码为通知中保存的格式
+func encodeNotificationTarget(target NotificationTarget) (string, string, error) {
+	var values []string
+	switch target.Type {
+	case entity.NotificationTargetAll:
+		return target.Type, "", nil
+	case entity.NotificationTargetUser, "":
+		target.Type = entity.NotificationTargetUser
+		for _, id := range target.UserIDs {
+			values = append(values, strconv.FormatUint(uint64(id), 10))
+		}
+	case entity.NotificationTargetRole:
+		for _, code := range target.RoleCodes {
+			if code = strings.TrimSpace(code); code != "" {
+				values = append(values, code)
+			}
+		}
+	case entity.NotificationTargetDepartment:
+		for _, id := range target.DepartmentIDs {
+			values = append(values, strconv.FormatUint(uint64(id), 10))
+		}
+	default:
+		return "", "", ErrNotificationTarget
+	}
+
+	if len(values) == 0 {
+		return "", "", ErrNotificationTarget
+	}
+	return target.Type, strings.Join(values, ","), nil
+}
+
+func splitTargets(targets string) []string {
+	var values []string
+	for _, v := range strings.Split(targets, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// insertUserNotifications 分批写入用户通知，已存在的记录会被忽略
+func insertUserNotifications(tx *gorm.DB, rows []entity.UserNotification) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(rows, notificationBatchSize).Error
+	if err != nil {
+		return fmt.Errorf("创建用户通知失败: %v", err)
+	}
+	return nil
+}
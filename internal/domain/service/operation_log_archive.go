@@ -0,0 +1,258 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gva/internal/domain/cache"
+	"gva/internal/domain/entity"
+	"gva/internal/pkg/config"
+
+	"gorm.io/gorm"
+)
+
+// 定义操作日志归档相关的错误
+var (
+	ErrArchiveRunning  = errors.New("操作日志归档正在执行")
+	ErrArchiveNotFound = errors.New("归档文件不存在")
+)
+
+const (
+	// archiveSuffix 归档文件的扩展名
+	archiveSuffix = ".jsonl.gz"
+	// archiveLockKey 归档锁，多个实例同一时间只有一个实例执行归档
+	archiveLockKey = "operation_log:archive:lock"
+	// archiveLockTTL 归档锁的有效期，归档期间自动续期
+	archiveLockTTL = time.Minute
+)
+
+// ArchiveResult 一次归档的结果
+type ArchiveResult struct {
+	File     string    `json:"file"`     // 归档文件名，没有需要归档的日志时为空
+	Cutoff   time.Time `json:"cutoff"`   // 早于该时间的日志被归档
+	Archived int64     `json:"archived"` // 写入归档文件的条数
+	Deleted  int64     `json:"deleted"`  // 从数据库删除的条数
+}
+
+// ArchiveFile 归档文件信息
+type ArchiveFile struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// OperationLogArchiver 按保留策略归档并删除过期的操作日志
+type OperationLogArchiver struct {
+	db        *gorm.DB
+	locker    cache.Locker
+	days      int
+	dir       string
+	batchSize int
+
+	running sync.Mutex
+}
+
+// NewOperationLogArchiver 创建归档服务，locker 为空时按单实例部署处理，只在当前实例内互斥
+func NewOperationLogArchiver(db *gorm.DB, cfg config.OperationLogRetention, locker cache.Locker) *OperationLogArchiver {
+	a := &OperationLogArchiver{db: db, locker: locker, days: cfg.Days, dir: cfg.ArchiveDir, batchSize: cfg.BatchSize}
+	if a.days <= 0 {
+		a.days = 90
+	}
+	if a.dir == "" {
+		a.dir = "storage/archives/operation_logs"
+	}
+	if a.batchSize <= 0 {
+		a.batchSize = 1000
+	}
+	return a
+}
+
+// RetentionDays 返回配置的保留天数
+func (a *OperationLogArchiver) RetentionDays() int {
+	return a.days
+}
+
+// Archive 将早于 days 天前的操作日志归档后删除，days 不大于 0 时使用配置的保留天数
+// 先将日志完整写入归档文件，文件落盘后再分批删除，删除中断时重新执行会再次归档未删除的日志
+// 同一时间只有一次归档，其他实例（定时任务或管理接口）正在归档时返回 ErrArchiveRunning
+func (a *OperationLogArchiver) Archive(ctx context.Context, days int) (*ArchiveResult, error) {
+	if !a.running.TryLock() {
+		return nil, ErrArchiveRunning
+	}
+	defer a.running.Unlock()
+
+	if a.locker != nil {
+		unlock, ok, err := a.locker.TryLock(ctx, archiveLockKey, archiveLockTTL)
+		if err != nil {
+			return nil, fmt.Errorf("获取归档锁失败: %v", err)
+		}
+		if !ok {
+			return nil, ErrArchiveRunning
+		}
+		defer unlock()
+	}
+
+	if days <= 0 {
+		days = a.days
+	}
+	now := time.Now()
+	result := &ArchiveResult{Cutoff: now.AddDate(0, 0, -days)}
+
+	name := fmt.Sprintf("operation_logs_%s_%s%s", result.Cutoff.Format("20060102"), now.Format("20060102_150405"), archiveSuffix)
+	maxID, archived, err := a.writeArchive(ctx, name, result.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	if archived == 0 {
+		return result, nil
+	}
+	result.File = name
+	result.Archived = archived
+
+	deleted, err := a.purge(ctx, result.Cutoff, maxID)
+	result.Deleted = deleted
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// writeArchive 按ID顺序分批读取早于 cutoff 的日志写入归档文件，返回归档的最大ID和条数
+// 写入完成并同步到磁盘后才将临时文件重命名为归档文件，没有需要归档的日志时不生成文件
+func (a *OperationLogArchiver) writeArchive(ctx context.Context, name string, cutoff time.Time) (uint, int64, error) {
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return 0, 0, fmt.Errorf("创建归档目录失败: %v", err)
+	}
+
+	path := filepath.Join(a.dir, name)
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return 0, 0, fmt.Errorf("创建归档文件失败: %v", err)
+	}
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+	gz := gzip.NewWriter(buffered)
+	encoder := json.NewEncoder(gz)
+	encoder.SetEscapeHTML(false)
+
+	var lastID uint
+	var count int64
+	for {
+		var logs []entity.OperationLog
+		err := a.db.WithContext(ctx).
+			Where("id > ? AND created_at < ?", lastID, cutoff).
+			Order("id").
+			Limit(a.batchSize).
+			Find(&logs).Error
+		if err != nil {
+			return 0, 0, fmt.Errorf("查询待归档日志失败: %v", err)
+		}
+		for i := range logs {
+			if err := encoder.Encode(&logs[i]); err != nil {
+				return 0, 0, fmt.Errorf("写入归档文件失败: %v", err)
+			}
+		}
+		if len(logs) > 0 {
+			lastID = logs[len(logs)-1].ID
+			count += int64(len(logs))
+		}
+		if len(logs) < a.batchSize {
+			break
+		}
+	}
+	if count == 0 {
+		return 0, 0, nil
+	}
+
+	if err := gz.Close(); err != nil {
+		return 0, 0, fmt.Errorf("写入归档文件失败: %v", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return 0, 0, fmt.Errorf("写入归档文件失败: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		return 0, 0, fmt.Errorf("写入归档文件失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, 0, fmt.Errorf("写入归档文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, 0, fmt.Errorf("保存归档文件失败: %v", err)
+	}
+	return lastID, count, nil
+}
+
+// purge 分批删除已归档的日志，即ID不大于 maxID 且早于 cutoff 的日志
+func (a *OperationLogArchiver) purge(ctx context.Context, cutoff time.Time, maxID uint) (int64, error) {
+	var total int64
+	for {
+		var ids []uint
+		err := a.db.WithContext(ctx).Model(&entity.OperationLog{}).
+			Where("id <= ? AND created_at < ?", maxID, cutoff).
+			Order("id").
+			Limit(a.batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return total, fmt.Errorf("查询已归档日志失败: %v", err)
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := a.db.WithContext(ctx).Delete(&entity.OperationLog{}, ids)
+		if result.Error != nil {
+			return total, fmt.Errorf("删除已归档日志失败: %v", result.Error)
+		}
+		total += result.RowsAffected
+	}
+}
+
+// List 按修改时间倒序列出归档文件
+func (a *OperationLogArchiver) List() ([]ArchiveFile, error) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []ArchiveFile{}, nil
+		}
+		return nil, fmt.Errorf("读取归档目录失败: %v", err)
+	}
+
+	files := make([]ArchiveFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), archiveSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, ArchiveFile{Name: entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime.After(files[j].ModTime) })
+	return files, nil
+}
+
+// Path 返回归档文件的路径，只允许访问归档目录下的归档文件
+func (a *OperationLogArchiver) Path(name string) (string, error) {
+	if name != filepath.Base(name) || !strings.HasSuffix(name, archiveSuffix) {
+		return "", ErrArchiveNotFound
+	}
+	path := filepath.Join(a.dir, name)
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return "", ErrArchiveNotFound
+	}
+	return path, nil
+}
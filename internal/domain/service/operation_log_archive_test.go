@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLocker 在内存中模拟多个实例共享的锁
+type fakeLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] {
+		return nil, false, nil
+	}
+	l.held[key] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, key)
+	}, true, nil
+}
+
+// readArchive 读取归档文件中的日志ID
+func readArchive(t *testing.T, path string) []uint {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+
+	var ids []uint
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var log entity.OperationLog
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &log))
+		ids = append(ids, log.ID)
+	}
+	require.NoError(t, scanner.Err())
+	return ids
+}
+
+// 早于保留天数的日志写入归档文件后删除，重复执行不会再次归档
+func TestOperationLogArchive(t *testing.T) {
+	db := newTestDB(t)
+	a := NewOperationLogArchiver(db, config.OperationLogRetention{Days: 30, ArchiveDir: t.TempDir(), BatchSize: 2}, &fakeLocker{held: map[string]bool{}})
+	ctx := context.Background()
+
+	var expired []uint
+	for i := 0; i < 5; i++ {
+		log := &entity.OperationLog{Path: "/old", CreatedAt: time.Now().AddDate(0, 0, -31-i)}
+		require.NoError(t, db.Create(log).Error)
+		expired = append(expired, log.ID)
+	}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, db.Create(&entity.OperationLog{Path: "/new"}).Error)
+	}
+
+	result, err := a.Archive(ctx, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, result.Archived)
+	assert.EqualValues(t, 5, result.Deleted)
+	require.NotEmpty(t, result.File)
+
+	path, err := a.Path(result.File)
+	require.NoError(t, err)
+	assert.Equal(t, expired, readArchive(t, path))
+
+	var remaining []entity.OperationLog
+	require.NoError(t, db.Find(&remaining).Error)
+	require.Len(t, remaining, 2)
+	for _, log := range remaining {
+		assert.Equal(t, "/new", log.Path)
+	}
+
+	files, err := a.List()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, result.File, files[0].Name)
+
+	result, err = a.Archive(ctx, 0)
+	require.NoError(t, err)
+	assert.Zero(t, result.Archived)
+	assert.Empty(t, result.File, "没有需要归档的日志时不生成文件")
+}
+
+// 其他实例正在归档时不执行归档
+func TestOperationLogArchiveLocked(t *testing.T) {
+	db := newTestDB(t)
+	locker := &fakeLocker{held: map[string]bool{archiveLockKey: true}}
+	a := NewOperationLogArchiver(db, config.OperationLogRetention{Days: 30, ArchiveDir: t.TempDir()}, locker)
+	require.NoError(t, db.Create(&entity.OperationLog{Path: "/old", CreatedAt: time.Now().AddDate(0, 0, -31)}).Error)
+
+	_, err := a.Archive(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrArchiveRunning)
+	var count int64
+	require.NoError(t, db.Model(&entity.OperationLog{}).Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+
+	delete(locker.held, archiveLockKey)
+	result, err := a.Archive(context.Background(), 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, result.Deleted)
+	assert.Empty(t, locker.held, "归档结束后释放锁")
+}
@@ -4,20 +4,213 @@ import (
 	"context"
 	"gva/internal/domain/entity"
 	"gva/internal/domain/repository"
+	"gva/internal/pkg/config"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// 操作日志队列已满时的策略
+const (
+	OperationLogFullDrop  = "drop"  // 直接丢弃
+	OperationLogFullBlock = "block" // 等待队列空闲
+)
+
+// operationLogWriteTimeout 每批日志写入数据库的超时时间
+const operationLogWriteTimeout = 10 * time.Second
+
+// OperationLogStats 操作日志写入队列的统计信息
+type OperationLogStats struct {
+	Queued   int    `json:"queued"`   // 队列中等待写入的条数
+	Capacity int    `json:"capacity"` // 队列长度
+	Enqueued uint64 `json:"enqueued"` // 累计进入队列的条数
+	Dropped  uint64 `json:"dropped"`  // 累计因队列已满或服务关闭而丢弃的条数
+	Written  uint64 `json:"written"`  // 累计写入成功的条数
+	Failed   uint64 `json:"failed"`   // 累计写入失败的条数
+	Batches  uint64 `json:"batches"`  // 累计写入的批次数
+}
+
 type OperationLogService struct {
 	logRepo repository.OperationLogRepository
+
+	queue         chan *entity.OperationLog
+	workers       int
+	batchSize     int
+	flushInterval time.Duration
+	fullPolicy    string
+	blockTimeout  time.Duration
+
+	mu     sync.RWMutex // 保护 closed，关闭队列时不能再有写入
+	closed bool
+	wg     sync.WaitGroup
+
+	enqueued atomic.Uint64
+	dropped  atomic.Uint64
+	written  atomic.Uint64
+	failed   atomic.Uint64
+	batches  atomic.Uint64
+}
+
+// NewOperationLogService 创建操作日志服务，需要调用 Start 启动批量写入，服务关闭时调用 Close 写入剩余日志
+func NewOperationLogService(logRepo repository.OperationLogRepository, cfg config.OperationLogConfig) *OperationLogService {
+	s := &OperationLogService{
+		logRepo:       logRepo,
+		workers:       cfg.Workers,
+		batchSize:     cfg.BatchSize,
+		flushInterval: time.Duration(cfg.FlushInterval) * time.Millisecond,
+		fullPolicy:    cfg.FullPolicy,
+		blockTimeout:  time.Duration(cfg.BlockTimeout) * time.Millisecond,
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 10000
+	}
+	if s.workers <= 0 {
+		s.workers = 2
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = 100
+	}
+	if s.flushInterval <= 0 {
+		s.flushInterval = time.Second
+	}
+	if s.fullPolicy != OperationLogFullBlock {
+		s.fullPolicy = OperationLogFullDrop
+	}
+	s.queue = make(chan *entity.OperationLog, queueSize)
+	return s
 }
 
-func NewOperationLogService(logRepo repository.OperationLogRepository) *OperationLogService {
-	return &OperationLogService{logRepo: logRepo}
+// Start 启动批量写入协程
+func (s *OperationLogService) Start() {
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+}
+
+// Close 停止接收新的日志，并等待队列中的日志全部写入，ctx 结束时不再等待
+func (s *OperationLogService) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
+// Create 同步写入一条日志
 func (s *OperationLogService) Create(ctx context.Context, log *entity.OperationLog) error {
 	return s.logRepo.Create(ctx, log)
 }
 
-func (s *OperationLogService) List(ctx context.Context, page, size int) ([]*entity.OperationLog, int64, error) {
-	return s.logRepo.List(ctx, page, size)
+// Record 将日志放入写入队列，不等待写入完成
+// 队列已满时按策略丢弃或等待，丢弃的日志计入统计信息，返回是否成功进入队列
+func (s *OperationLogService) Record(entry *entity.OperationLog) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case s.queue <- entry:
+		s.enqueued.Add(1)
+		return true
+	default:
+	}
+	if s.fullPolicy != OperationLogFullBlock {
+		s.dropped.Add(1)
+		return false
+	}
+
+	var timeout <-chan time.Time
+	if s.blockTimeout > 0 {
+		timer := time.NewTimer(s.blockTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case s.queue <- entry:
+		s.enqueued.Add(1)
+		return true
+	case <-timeout:
+		s.dropped.Add(1)
+		return false
+	}
+}
+
+// Stats 返回写入队列的统计信息
+func (s *OperationLogService) Stats() OperationLogStats {
+	return OperationLogStats{
+		Queued:   len(s.queue),
+		Capacity: cap(s.queue),
+		Enqueued: s.enqueued.Load(),
+		Dropped:  s.dropped.Load(),
+		Written:  s.written.Load(),
+		Failed:   s.failed.Load(),
+		Batches:  s.batches.Load(),
+	}
+}
+
+func (s *OperationLogService) List(ctx context.Context, page, size int, filter repository.OperationLogFilter) ([]*entity.OperationLog, int64, error) {
+	return s.logRepo.List(ctx, page, size, filter)
+}
+
+// worker 从队列中攒批写入，队列关闭后写入剩余的日志再退出
+func (s *OperationLogService) worker() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*entity.OperationLog, 0, s.batchSize)
+	for {
+		select {
+		case item, ok := <-s.queue:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= s.batchSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush 写入一批日志，使用独立的上下文，不受请求结束的影响
+func (s *OperationLogService) flush(batch []*entity.OperationLog) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), operationLogWriteTimeout)
+	defer cancel()
+	if err := s.logRepo.CreateBatch(ctx, batch); err != nil {
+		s.failed.Add(uint64(len(batch)))
+		log.Printf("写入操作日志失败 - Count: %d, Error: %v", len(batch), err)
+		return
+	}
+	s.written.Add(uint64(len(batch)))
+	s.batches.Add(1)
 }
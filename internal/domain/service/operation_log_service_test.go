@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/repository"
+	infraRepo "gva/internal/infrastructure/repository"
+	"gva/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOperationLogRepo 在内存中记录写入的日志和批次
+type fakeOperationLogRepo struct {
+	mu      sync.Mutex
+	logs    []*entity.OperationLog
+	batches [][]*entity.OperationLog
+	err     error
+}
+
+func (r *fakeOperationLogRepo) Create(ctx context.Context, log *entity.OperationLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func (r *fakeOperationLogRepo) CreateBatch(ctx context.Context, logs []*entity.OperationLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	// worker 会复用批次的底层数组，需要复制
+	batch := append([]*entity.OperationLog(nil), logs...)
+	r.batches = append(r.batches, batch)
+	r.logs = append(r.logs, batch...)
+	return nil
+}
+
+func (r *fakeOperationLogRepo) List(ctx context.Context, page, size int, filter repository.OperationLogFilter) ([]*entity.OperationLog, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.logs, int64(len(r.logs)), nil
+}
+
+func (r *fakeOperationLogRepo) written() (logs, batches int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.logs), len(r.batches)
+}
+
+func closeOperationLogService(t *testing.T, s *OperationLogService) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Close(ctx))
+}
+
+// 队列已满时 drop 策略直接丢弃并计数，关闭时写入队列中剩余的日志
+func TestOperationLogRecordDrop(t *testing.T) {
+	repo := &fakeOperationLogRepo{}
+	s := NewOperationLogService(repo, config.OperationLogConfig{QueueSize: 2, Workers: 1, FlushInterval: 3600000})
+
+	assert.True(t, s.Record(&entity.OperationLog{Path: "/1"}))
+	assert.True(t, s.Record(&entity.OperationLog{Path: "/2"}))
+	assert.False(t, s.Record(&entity.OperationLog{Path: "/3"}), "队列已满")
+	stats := s.Stats()
+	assert.Equal(t, OperationLogStats{Queued: 2, Capacity: 2, Enqueued: 2, Dropped: 1}, stats)
+
+	s.Start()
+	closeOperationLogService(t, s)
+	logs, _ := repo.written()
+	assert.Equal(t, 2, logs, "关闭时写入剩余的日志")
+	assert.EqualValues(t, 2, s.Stats().Written)
+
+	assert.False(t, s.Record(&entity.OperationLog{Path: "/4"}), "关闭后不再接收")
+	assert.EqualValues(t, 2, s.Stats().Dropped)
+}
+
+// 队列已满时 block 策略等待队列空闲，超过等待时间后丢弃
+func TestOperationLogRecordBlock(t *testing.T) {
+	t.Run("超时丢弃", func(t *testing.T) {
+		s := NewOperationLogService(&fakeOperationLogRepo{}, config.OperationLogConfig{
+			QueueSize: 1, FullPolicy: OperationLogFullBlock, BlockTimeout: 50,
+		})
+		require.True(t, s.Record(&entity.OperationLog{}))
+
+		start := time.Now()
+		assert.False(t, s.Record(&entity.OperationLog{}))
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "等待到超时")
+		assert.EqualValues(t, 1, s.Stats().Dropped)
+	})
+
+	t.Run("等待队列空闲", func(t *testing.T) {
+		repo := &fakeOperationLogRepo{}
+		s := NewOperationLogService(repo, config.OperationLogConfig{
+			QueueSize: 1, Workers: 1, FullPolicy: OperationLogFullBlock, FlushInterval: 10,
+		})
+		require.True(t, s.Record(&entity.OperationLog{}))
+
+		recorded := make(chan bool)
+		go func() { recorded <- s.Record(&entity.OperationLog{}) }()
+		select {
+		case <-recorded:
+			t.Fatal("队列已满时应等待")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		s.Start()
+		assert.True(t, <-recorded)
+		closeOperationLogService(t, s)
+		logs, _ := repo.written()
+		assert.Equal(t, 2, logs)
+		assert.Zero(t, s.Stats().Dropped)
+	})
+}
+
+// worker 攒满一批或到达写入间隔时写入
+func TestOperationLogWorker(t *testing.T) {
+	t.Run("攒满一批写入", func(t *testing.T) {
+		repo := &fakeOperationLogRepo{}
+		s := NewOperationLogService(repo, config.OperationLogConfig{Workers: 1, BatchSize: 3, FlushInterval: 3600000})
+		s.Start()
+		for i := 0; i < 7; i++ {
+			require.True(t, s.Record(&entity.OperationLog{}))
+		}
+
+		assert.Eventually(t, func() bool {
+			_, batches := repo.written()
+			return batches == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		logs, _ := repo.written()
+		assert.Equal(t, 6, logs, "不足一批的日志等待写入")
+
+		closeOperationLogService(t, s)
+		logs, batches := repo.written()
+		assert.Equal(t, 7, logs)
+		assert.Equal(t, 3, batches)
+		assert.Equal(t, uint64(3), s.Stats().Batches)
+	})
+
+	t.Run("按间隔写入", func(t *testing.T) {
+		repo := &fakeOperationLogRepo{}
+		s := NewOperationLogService(repo, config.OperationLogConfig{Workers: 1, BatchSize: 100, FlushInterval: 20})
+		s.Start()
+		defer closeOperationLogService(t, s)
+
+		require.True(t, s.Record(&entity.OperationLog{}))
+		assert.Eventually(t, func() bool {
+			logs, _ := repo.written()
+			return logs == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("写入失败计数", func(t *testing.T) {
+		repo := &fakeOperationLogRepo{err: errors.New("db down")}
+		s := NewOperationLogService(repo, config.OperationLogConfig{Workers: 1})
+		s.Start()
+		require.True(t, s.Record(&entity.OperationLog{}))
+		require.True(t, s.Record(&entity.OperationLog{}))
+		closeOperationLogService(t, s)
+
+		stats := s.Stats()
+		assert.EqualValues(t, 2, stats.Failed)
+		assert.Zero(t, stats.Written)
+	})
+}
+
+// 写入数据库时保留日志记录时的时间，而不是后台写入的时间
+func TestOperationLogKeepsCreatedAt(t *testing.T) {
+	db := newTestDB(t)
+	s := NewOperationLogService(infraRepo.NewOperationLogRepository(db), config.OperationLogConfig{Workers: 1, FlushInterval: 3600000})
+	s.Start()
+
+	createdAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.True(t, s.Record(&entity.OperationLog{Path: "/old", CreatedAt: createdAt}))
+	closeOperationLogService(t, s)
+
+	var log entity.OperationLog
+	require.NoError(t, db.Where("path = ?", "/old").First(&log).Error)
+	assert.True(t, createdAt.Equal(log.CreatedAt), "created_at = %v", log.CreatedAt)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gva/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// 定义岗位相关的错误
+var (
+	ErrPositionNotFound     = errors.New("岗位不存在")
+	ErrPositionCodeConflict = errors.New("岗位编码已存在")
+	ErrPositionDepartment   = errors.New("岗位所属部门不存在")
+)
+
+type PositionService struct {
+	db *gorm.DB
+}
+
+func NewPositionService(db *gorm.DB) *PositionService {
+	return &PositionService{db: db}
+}
+
+// List 获取岗位列表，departmentID 不为空时只返回该部门的岗位
+func (s *PositionService) List(ctx context.Context, keyword string, status *int, departmentID *uint) ([]entity.Position, error) {
+	db := s.db.WithContext(ctx).Model(&entity.Position{}).Preload("Department")
+	if keyword != "" {
+		db = db.Where("code LIKE ? OR name LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if status != nil {
+		db = db.Where("status = ?", *status)
+	}
+	if departmentID != nil {
+		db = db.Where("department_id = ?", *departmentID)
+	}
+
+	var positions []entity.Position
+	if err := db.Order("sort ASC, id ASC").Find(&positions).Error; err != nil {
+		return nil, fmt.Errorf("查询岗位列表失败: %v", err)
+	}
+	return positions, nil
+}
+
+// GetByID 根据ID获取岗位信息
+func (s *PositionService) GetByID(ctx context.Context, id uint) (*entity.Position, error) {
+	var position entity.Position
+	if err := s.db.WithContext(ctx).Preload("Department").First(&position, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPositionNotFound
+		}
+		return nil, fmt.Errorf("查询岗位失败: %v", err)
+	}
+	return &position, nil
+}
+
+// Create 创建岗位
+func (s *PositionService) Create(ctx context.Context, position *entity.Position) error {
+	if err := s.checkCode(ctx, position.Code, 0); err != nil {
+		return err
+	}
+	if err := s.checkDepartment(ctx, position.DepartmentID); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Create(position).Error; err != nil {
+		return fmt.Errorf("创建岗位失败: %v", err)
+	}
+	return nil
+}
+
+// Update 更新岗位信息
+func (s *PositionService) Update(ctx context.Context, id uint, position *entity.Position) error {
+	existing, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if position.Code != existing.Code {
+		if err := s.checkCode(ctx, position.Code, id); err != nil {
+			return err
+		}
+	}
+	if err := s.checkDepartment(ctx, position.DepartmentID); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"code":          position.Code,
+		"name":          position.Name,
+		"department_id": position.DepartmentID,
+		"sort":          position.Sort,
+		"status":        position.Status,
+		"description":   position.Description,
+	}
+	if err := s.db.WithContext(ctx).Model(&entity.Position{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新岗位失败: %v", err)
+	}
+	return nil
+}
+
+// Delete 删除岗位，并解除与用户的关联
+func (s *PositionService) Delete(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var position entity.Position
+		if err := tx.First(&position, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPositionNotFound
+			}
+			return fmt.Errorf("查询岗位失败: %v", err)
+		}
+
+		if err := tx.Exec("DELETE FROM user_positions WHERE position_id = ?", id).Error; err != nil {
+			return fmt.Errorf("解除岗位关联失败: %v", err)
+		}
+
+		if err := tx.Delete(&position).Error; err != nil {
+			return fmt.Errorf("删除岗位失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// checkCode 检查岗位编码是否被其他岗位占用
+func (s *PositionService) checkCode(ctx context.Context, code string, excludeID uint) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Unscoped().Model(&entity.Position{}).Where("code = ? AND id != ?", code, excludeID).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查岗位编码失败: %v", err)
+	}
+	if count > 0 {
+		return ErrPositionCodeConflict
+	}
+	return nil
+}
+
+// checkDepartment 检查岗位所属部门是否存在
+func (s *PositionService) checkDepartment(ctx context.Context, departmentID *uint) error {
+	if departmentID == nil {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&entity.Department{}).Where("id = ?", *departmentID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询岗位所属部门失败: %v", err)
+	}
+	if count == 0 {
+		return ErrPositionDepartment
+	}
+	return nil
+}
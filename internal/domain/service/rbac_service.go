@@ -0,0 +1,598 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gva/internal/domain/audit"
+	"gva/internal/domain/entity"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// RBACPolicyVersion 当前支持的 RBAC 策略文档版本
+const RBACPolicyVersion = 1
+
+// 策略变更动作
+const (
+	PolicyActionCreate  = "create"  // 新建
+	PolicyActionUpdate  = "update"  // 更新
+	PolicyActionRestore = "restore" // 恢复已删除的记录
+	PolicyActionGrant   = "grant"   // 为角色授予权限
+	PolicyActionRevoke  = "revoke"  // 收回角色权限
+)
+
+var ErrInvalidPolicy = errors.New("RBAC 策略文档无效")
+
+// RBACPolicy 可导出/导入的 RBAC 策略文档，角色与权限之间均通过 Code 关联
+type RBACPolicy struct {
+	Version     int                `yaml:"version" json:"version"`
+	ExportedAt  time.Time          `yaml:"exported_at" json:"exported_at"`
+	Permissions []PolicyPermission `yaml:"permissions" json:"permissions"` // 权限树
+	Roles       []PolicyRole       `yaml:"roles" json:"roles"`
+}
+
+// PolicyPermission 策略文档中的权限节点
+type PolicyPermission struct {
+	Code        string             `yaml:"code" json:"code"`
+	Name        string             `yaml:"name" json:"name"`
+	Type        string             `yaml:"type" json:"type"`
+	Path        string             `yaml:"path,omitempty" json:"path,omitempty"`
+	Component   string             `yaml:"component,omitempty" json:"component,omitempty"`
+	Redirect    string             `yaml:"redirect,omitempty" json:"redirect,omitempty"`
+	Icon        string             `yaml:"icon,omitempty" json:"icon,omitempty"`
+	Sort        int                `yaml:"sort" json:"sort"`
+	Hidden      bool               `yaml:"hidden,omitempty" json:"hidden,omitempty"`
+	Status      int                `yaml:"status" json:"status"`
+	Description string             `yaml:"description,omitempty" json:"description,omitempty"`
+	Children    []PolicyPermission `yaml:"children,omitempty" json:"children,omitempty"`
+}
+
+// PolicyRole 策略文档中的角色
+type PolicyRole struct {
+	Code        string   `yaml:"code" json:"code"`
+	Name        string   `yaml:"name" json:"name"`
+	Parent      string   `yaml:"parent,omitempty" json:"parent,omitempty"` // 父角色编码
+	DataScope   string   `yaml:"data_scope,omitempty" json:"data_scope,omitempty"`
+	Status      int      `yaml:"status" json:"status"`
+	Sort        int      `yaml:"sort" json:"sort"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Permissions []string `yaml:"permissions" json:"permissions"` // 权限编码列表
+}
+
+// PolicyChange 导入时产生的一条变更
+type PolicyChange struct {
+	Kind   string   `json:"kind"`             // permission、role 或 role_permission
+	Code   string   `json:"code"`             // 权限或角色编码
+	Action string   `json:"action"`           // 变更动作
+	Fields []string `json:"fields,omitempty"` // 更新的字段
+	Target string   `json:"target,omitempty"` // 授予/收回的权限编码
+}
+
+// RBACImportResult 导入结果
+type RBACImportResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Changes []PolicyChange `json:"changes"`
+}
+
+type RBACService struct {
+	db *gorm.DB
+}
+
+func NewRBACService(db *gorm.DB) *RBACService {
+	return &RBACService{db: db}
+}
+
+// Export 导出完整的 RBAC 模型（角色、权限树、角色权限）为 YAML
+func (s *RBACService) Export(ctx context.Context) ([]byte, error) {
+	var permissions []entity.Permission
+	if err := s.db.WithContext(ctx).Order("sort ASC, id ASC").Find(&permissions).Error; err != nil {
+		return nil, fmt.Errorf("查询权限列表失败: %v", err)
+	}
+
+	var roles []entity.Role
+	if err := s.db.WithContext(ctx).Preload("Permissions").Order("sort ASC, id ASC").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("查询角色列表失败: %v", err)
+	}
+
+	policy := RBACPolicy{
+		Version:     RBACPolicyVersion,
+		ExportedAt:  time.Now(),
+		Permissions: buildPolicyPermissionTree(permissions),
+	}
+
+	roleCodes := make(map[uint]string, len(roles))
+	for _, role := range roles {
+		roleCodes[role.ID] = role.Code
+	}
+
+	for _, role := range roles {
+		item := PolicyRole{
+			Code:        role.Code,
+			Name:        role.Name,
+			DataScope:   role.DataScope,
+			Status:      role.Status,
+			Sort:        role.Sort,
+			Description: role.Description,
+			Permissions: make([]string, 0, len(role.Permissions)),
+		}
+		if role.ParentID != nil {
+			item.Parent = roleCodes[*role.ParentID]
+		}
+		for _, p := range role.Permissions {
+			item.Permissions = append(item.Permissions, p.Code)
+		}
+		sort.Strings(item.Permissions)
+		policy.Roles = append(policy.Roles, item)
+	}
+
+	return yaml.Marshal(&policy)
+}
+
+// Import 导入 YAML 格式的 RBAC 策略，按 Code 匹配已有记录
+// dryRun 为 true 时只计算差异，不写入数据库；文档中没有出现的角色和权限不会被删除
+func (s *RBACService) Import(ctx context.Context, data []byte, dryRun bool) (*RBACImportResult, error) {
+	var policy RBACPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if policy.Version != RBACPolicyVersion {
+		return nil, fmt.Errorf("%w: 不支持的版本 %d", ErrInvalidPolicy, policy.Version)
+	}
+
+	result := &RBACImportResult{DryRun: dryRun}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		changes, err := applyPolicy(ctx, tx, &policy, dryRun)
+		if err != nil {
+			return err
+		}
+		result.Changes = changes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// policyPermissionNode 展开后的权限节点
+type policyPermissionNode struct {
+	PolicyPermission
+	parent string
+}
+
+// applyPolicy 在事务 tx 中应用策略文档，返回变更列表；实际写入时每个新建或修改的权限、角色都记录一条审计事件
+func applyPolicy(ctx context.Context, tx *gorm.DB, policy *RBACPolicy, dryRun bool) ([]PolicyChange, error) {
+	changes := []PolicyChange{}
+
+	// 展开权限树（父节点在前），并检查编码是否重复
+	var nodes []policyPermissionNode
+	seen := make(map[string]bool)
+	var walk func(items []PolicyPermission, parent string) error
+	walk = func(items []PolicyPermission, parent string) error {
+		for _, item := range items {
+			if item.Code == "" {
+				return fmt.Errorf("%w: 权限编码不能为空", ErrInvalidPolicy)
+			}
+			if seen[item.Code] {
+				return fmt.Errorf("%w: 权限编码 %s 重复", ErrInvalidPolicy, item.Code)
+			}
+			seen[item.Code] = true
+			nodes = append(nodes, policyPermissionNode{PolicyPermission: item, parent: parent})
+			if err := walk(item.Children, item.Code); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(policy.Permissions, ""); err != nil {
+		return nil, err
+	}
+
+	// 加载现有权限（包括已软删除的，避免唯一索引冲突）
+	var existingPermissions []entity.Permission
+	if err := tx.Unscoped().Find(&existingPermissions).Error; err != nil {
+		return nil, fmt.Errorf("查询权限列表失败: %v", err)
+	}
+	permissionIDs := make(map[string]uint)
+	permissionByCode := make(map[string]entity.Permission)
+	permissionCodes := make(map[uint]string)
+	for _, p := range existingPermissions {
+		permissionIDs[p.Code] = p.ID
+		permissionByCode[p.Code] = p
+		permissionCodes[p.ID] = p.Code
+	}
+
+	// 1. 权限
+	for _, node := range nodes {
+		existing, exists := permissionByCode[node.Code]
+		var parentID *uint
+		if node.parent != "" {
+			id := permissionIDs[node.parent]
+			parentID = &id
+		}
+
+		permission := entity.Permission{
+			Name:        node.Name,
+			Code:        node.Code,
+			Type:        node.Type,
+			ParentID:    parentID,
+			Path:        node.Path,
+			Component:   node.Component,
+			Redirect:    node.Redirect,
+			Icon:        node.Icon,
+			Sort:        node.Sort,
+			Hidden:      node.Hidden,
+			Status:      node.Status,
+			Description: node.Description,
+		}
+		after := permissionAuditFields(&permission, node.parent)
+
+		if !exists {
+			changes = append(changes, PolicyChange{Kind: "permission", Code: node.Code, Action: PolicyActionCreate})
+			if !dryRun {
+				if err := tx.Create(&permission).Error; err != nil {
+					return nil, fmt.Errorf("创建权限 %s 失败: %v", node.Code, err)
+				}
+				if err := recordAudit(ctx, tx, audit.EntityPermission, permission.ID, audit.ActionImport, audit.Diff(nil, after)); err != nil {
+					return nil, err
+				}
+			}
+			permissionIDs[node.Code] = permission.ID
+			continue
+		}
+
+		updates := make(map[string]interface{})
+		var fields []string
+		set := func(column string, changed bool, value interface{}) {
+			if changed {
+				updates[column] = value
+				fields = append(fields, column)
+			}
+		}
+		existingParent := ""
+		if existing.ParentID != nil {
+			existingParent = permissionCodes[*existing.ParentID]
+		}
+		set("name", existing.Name != node.Name, node.Name)
+		set("type", existing.Type != node.Type, node.Type)
+		set("parent_id", existingParent != node.parent, parentID)
+		set("path", existing.Path != node.Path, node.Path)
+		set("component", existing.Component != node.Component, node.Component)
+		set("redirect", existing.Redirect != node.Redirect, node.Redirect)
+		set("icon", existing.Icon != node.Icon, node.Icon)
+		set("sort", existing.Sort != node.Sort, node.Sort)
+		set("hidden", existing.Hidden != node.Hidden, node.Hidden)
+		set("status", existing.Status != node.Status, node.Status)
+		set("description", existing.Description != node.Description, node.Description)
+
+		before := permissionAuditFields(&existing, existingParent)
+		action := PolicyActionUpdate
+		if existing.DeletedAt.Valid {
+			action = PolicyActionRestore
+			updates["deleted_at"] = nil
+			before["deleted"], after["deleted"] = true, false
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		changes = append(changes, PolicyChange{Kind: "permission", Code: node.Code, Action: action, Fields: fields})
+		if !dryRun {
+			if err := tx.Unscoped().Model(&entity.Permission{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+				return nil, fmt.Errorf("更新权限 %s 失败: %v", node.Code, err)
+			}
+			if err := recordAudit(ctx, tx, audit.EntityPermission, existing.ID, audit.ActionImport, audit.Diff(before, after)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 2. 角色
+	var existingRoles []entity.Role
+	if err := tx.Unscoped().Preload("Permissions").Find(&existingRoles).Error; err != nil {
+		return nil, fmt.Errorf("查询角色列表失败: %v", err)
+	}
+	roleByCode := make(map[string]entity.Role)
+	roleIDs := make(map[string]uint)
+	roleCodes := make(map[uint]string)
+	for _, r := range existingRoles {
+		roleByCode[r.Code] = r
+		roleIDs[r.Code] = r.ID
+		roleCodes[r.ID] = r.Code
+	}
+
+	// 导入后的父角色：现有角色的父角色被文档中的同名角色覆盖
+	parents := make(map[string]string, len(existingRoles)+len(policy.Roles))
+	for _, r := range existingRoles {
+		if r.ParentID != nil {
+			parents[r.Code] = roleCodes[*r.ParentID]
+		}
+	}
+
+	seenRoles := make(map[string]bool)
+	for _, item := range policy.Roles {
+		if item.Code == "" {
+			return nil, fmt.Errorf("%w: 角色编码不能为空", ErrInvalidPolicy)
+		}
+		if seenRoles[item.Code] {
+			return nil, fmt.Errorf("%w: 角色编码 %s 重复", ErrInvalidPolicy, item.Code)
+		}
+		seenRoles[item.Code] = true
+		parents[item.Code] = item.Parent
+		for _, code := range item.Permissions {
+			if p, ok := permissionByCode[code]; !seen[code] && (!ok || p.DeletedAt.Valid) {
+				return nil, fmt.Errorf("%w: 角色 %s 引用了不存在的权限 %s", ErrInvalidPolicy, item.Code, code)
+			}
+		}
+	}
+
+	for _, item := range policy.Roles {
+		if item.Parent != "" && !seenRoles[item.Parent] {
+			if _, ok := roleIDs[item.Parent]; !ok {
+				return nil, fmt.Errorf("%w: 角色 %s 的父角色 %s 不存在", ErrInvalidPolicy, item.Code, item.Parent)
+			}
+		}
+		if cycle := roleParentCycle(parents, item.Code); cycle != nil {
+			return nil, fmt.Errorf("%w: 角色的父角色形成循环 %s", ErrInvalidPolicy, strings.Join(cycle, " -> "))
+		}
+	}
+
+	for _, item := range policy.Roles {
+		role := entity.Role{
+			Name:        item.Name,
+			Code:        item.Code,
+			DataScope:   item.DataScope,
+			Status:      item.Status,
+			Sort:        item.Sort,
+			Description: item.Description,
+		}
+		// 父角色和权限在之后的步骤中更新，审计事件中与角色字段一起记录
+		after := policyRoleAuditFields(&role, item.Parent, item.Permissions)
+
+		existing, exists := roleByCode[item.Code]
+		if !exists {
+			changes = append(changes, PolicyChange{Kind: "role", Code: item.Code, Action: PolicyActionCreate})
+			if !dryRun {
+				if err := tx.Create(&role).Error; err != nil {
+					return nil, fmt.Errorf("创建角色 %s 失败: %v", item.Code, err)
+				}
+				if err := recordAudit(ctx, tx, audit.EntityRole, role.ID, audit.ActionImport, audit.Diff(nil, after)); err != nil {
+					return nil, err
+				}
+			}
+			roleIDs[item.Code] = role.ID
+			continue
+		}
+
+		updates := make(map[string]interface{})
+		var fields []string
+		set := func(column string, changed bool, value interface{}) {
+			if changed {
+				updates[column] = value
+				fields = append(fields, column)
+			}
+		}
+		set("name", existing.Name != item.Name, item.Name)
+		set("data_scope", existing.DataScope != item.DataScope, item.DataScope)
+		set("status", existing.Status != item.Status, item.Status)
+		set("sort", existing.Sort != item.Sort, item.Sort)
+		set("description", existing.Description != item.Description, item.Description)
+
+		existingParent := ""
+		if existing.ParentID != nil {
+			existingParent = roleCodes[*existing.ParentID]
+		}
+		currentPermissions := make([]string, 0, len(existing.Permissions))
+		for _, p := range existing.Permissions {
+			currentPermissions = append(currentPermissions, p.Code)
+		}
+		before := policyRoleAuditFields(&existing, existingParent, currentPermissions)
+
+		action := PolicyActionUpdate
+		if existing.DeletedAt.Valid {
+			action = PolicyActionRestore
+			updates["deleted_at"] = nil
+			before["deleted"], after["deleted"] = true, false
+		}
+		if !dryRun {
+			if err := recordAudit(ctx, tx, audit.EntityRole, existing.ID, audit.ActionImport, audit.Diff(before, after)); err != nil {
+				return nil, err
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		changes = append(changes, PolicyChange{Kind: "role", Code: item.Code, Action: action, Fields: fields})
+		if !dryRun {
+			if err := tx.Unscoped().Model(&entity.Role{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+				return nil, fmt.Errorf("更新角色 %s 失败: %v", item.Code, err)
+			}
+		}
+	}
+
+	// 3. 父角色（所有角色创建完成后再关联）
+	for _, item := range policy.Roles {
+		existingParent := ""
+		if existing, ok := roleByCode[item.Code]; ok && existing.ParentID != nil {
+			existingParent = roleCodes[*existing.ParentID]
+		}
+		if existingParent == item.Parent {
+			continue
+		}
+
+		changes = append(changes, PolicyChange{Kind: "role", Code: item.Code, Action: PolicyActionUpdate, Fields: []string{"parent_id"}})
+		if dryRun {
+			continue
+		}
+		var parentID *uint
+		if item.Parent != "" {
+			id := roleIDs[item.Parent]
+			parentID = &id
+		}
+		if err := tx.Model(&entity.Role{}).Where("id = ?", roleIDs[item.Code]).Update("parent_id", parentID).Error; err != nil {
+			return nil, fmt.Errorf("更新角色 %s 的父角色失败: %v", item.Code, err)
+		}
+	}
+
+	// 4. 角色权限，以文档为准进行授予和收回
+	for _, item := range policy.Roles {
+		current := make(map[string]bool)
+		if existing, ok := roleByCode[item.Code]; ok {
+			for _, p := range existing.Permissions {
+				current[p.Code] = true
+			}
+		}
+		wanted := make(map[string]bool)
+		for _, code := range item.Permissions {
+			wanted[code] = true
+		}
+
+		var grants, revokes []string
+		for code := range wanted {
+			if !current[code] {
+				grants = append(grants, code)
+			}
+		}
+		for code := range current {
+			if !wanted[code] {
+				revokes = append(revokes, code)
+			}
+		}
+		sort.Strings(grants)
+		sort.Strings(revokes)
+
+		for _, code := range grants {
+			changes = append(changes, PolicyChange{Kind: "role_permission", Code: item.Code, Action: PolicyActionGrant, Target: code})
+		}
+		for _, code := range revokes {
+			changes = append(changes, PolicyChange{Kind: "role_permission", Code: item.Code, Action: PolicyActionRevoke, Target: code})
+		}
+
+		if dryRun || (len(grants) == 0 && len(revokes) == 0) {
+			continue
+		}
+
+		roleID := roleIDs[item.Code]
+		for _, code := range revokes {
+			if err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ? AND permission_id = ?", roleID, permissionIDs[code]).Error; err != nil {
+				return nil, fmt.Errorf("收回角色 %s 的权限失败: %v", item.Code, err)
+			}
+		}
+		if len(grants) > 0 {
+			var rolePermissions []map[string]interface{}
+			for _, code := range grants {
+				rolePermissions = append(rolePermissions, map[string]interface{}{
+					"role_id":       roleID,
+					"permission_id": permissionIDs[code],
+				})
+			}
+			if err := tx.Table("role_permissions").Create(rolePermissions).Error; err != nil {
+				return nil, fmt.Errorf("授予角色 %s 的权限失败: %v", item.Code, err)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// roleParentCycle 从角色 code 开始沿父角色向上查找，存在循环时返回循环经过的角色编码（首尾相同），否则返回 nil
+func roleParentCycle(parents map[string]string, code string) []string {
+	index := make(map[string]int)
+	var chain []string
+	for code != "" {
+		if i, ok := index[code]; ok {
+			return append(chain[i:], code)
+		}
+		index[code] = len(chain)
+		chain = append(chain, code)
+		code = parents[code]
+	}
+	return nil
+}
+
+// permissionAuditFields 权限需要审计的字段，父权限记录为编码
+func permissionAuditFields(p *entity.Permission, parent string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        p.Name,
+		"code":        p.Code,
+		"type":        p.Type,
+		"parent":      parent,
+		"path":        p.Path,
+		"component":   p.Component,
+		"redirect":    p.Redirect,
+		"icon":        p.Icon,
+		"sort":        p.Sort,
+		"hidden":      p.Hidden,
+		"status":      p.Status,
+		"description": p.Description,
+	}
+}
+
+// policyRoleAuditFields 导入策略时角色需要审计的字段，父角色和权限记录为编码，权限按编码排序并去重
+func policyRoleAuditFields(role *entity.Role, parent string, permissions []string) map[string]interface{} {
+	fields := roleAuditFields(role)
+	fields["data_scope"] = role.DataScope
+	fields["parent"] = parent
+
+	codes := make([]string, 0, len(permissions))
+	seen := make(map[string]bool, len(permissions))
+	for _, code := range permissions {
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	fields["permissions"] = codes
+	return fields
+}
+
+// buildPolicyPermissionTree 将扁平的权限列表组装为权限树
+func buildPolicyPermissionTree(permissions []entity.Permission) []PolicyPermission {
+	ids := make(map[uint]bool, len(permissions))
+	for _, p := range permissions {
+		ids[p.ID] = true
+	}
+
+	children := make(map[uint][]entity.Permission)
+	var roots []entity.Permission
+	for _, p := range permissions {
+		if p.ParentID == nil || !ids[*p.ParentID] {
+			roots = append(roots, p)
+			continue
+		}
+		children[*p.ParentID] = append(children[*p.ParentID], p)
+	}
+
+	var build func(items []entity.Permission) []PolicyPermission
+	build = func(items []entity.Permission) []PolicyPermission {
+		var nodes []PolicyPermission
+		for _, p := range items {
+			nodes = append(nodes, PolicyPermission{
+				Code:        p.Code,
+				Name:        p.Name,
+				Type:        p.Type,
+				Path:        p.Path,
+				Component:   p.Component,
+				Redirect:    p.Redirect,
+				Icon:        p.Icon,
+				Sort:        p.Sort,
+				Hidden:      p.Hidden,
+				Status:      p.Status,
+				Description: p.Description,
+				Children:    build(children[p.ID]),
+			})
+		}
+		return nodes
+	}
+
+	return build(roots)
+}
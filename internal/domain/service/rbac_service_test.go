@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"gva/internal/domain/audit"
+	"gva/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `version: 1
+permissions:
+  - code: system:user
+    name: 用户管理
+    type: menu
+    status: 1
+    children:
+      - code: system:user:list
+        name: 查看用户
+        type: button
+        status: 1
+roles:
+  - code: auditor
+    name: 审计员
+    status: 1
+    permissions: [system:user, system:user:list]
+`
+
+// 导入策略时为新建和修改的角色、权限记录审计事件，试运行不记录
+func TestRBACImportAudit(t *testing.T) {
+	db := newTestDB(t)
+	s := NewRBACService(db)
+	ctx := context.Background()
+
+	_, err := s.Import(ctx, []byte(testPolicy), true)
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, db.Model(&entity.AuditEvent{}).Count(&count).Error)
+	assert.Zero(t, count, "试运行不记录审计事件")
+
+	_, err = s.Import(ctx, []byte(testPolicy), false)
+	require.NoError(t, err)
+
+	var permission entity.Permission
+	require.NoError(t, db.Where("code = ?", "system:user:list").First(&permission).Error)
+	changes := lastAuditChanges(t, db, audit.EntityPermission, permission.ID, audit.ActionImport)
+	assert.Equal(t, "system:user", changes["parent"].After)
+
+	var role entity.Role
+	require.NoError(t, db.Where("code = ?", "auditor").First(&role).Error)
+	changes = lastAuditChanges(t, db, audit.EntityRole, role.ID, audit.ActionImport)
+	assert.Equal(t, "审计员", changes["name"].After)
+	assert.Equal(t, []interface{}{"system:user", "system:user:list"}, changes["permissions"].After)
+
+	// 只修改角色权限时同样记录审计事件
+	policy := testPolicy[:len(testPolicy)-len("[system:user, system:user:list]\n")] + "[system:user]\n"
+	_, err = s.Import(ctx, []byte(policy), false)
+	require.NoError(t, err)
+	changes = lastAuditChanges(t, db, audit.EntityRole, role.ID, audit.ActionImport)
+	assert.Equal(t, audit.Change{
+		Field:  "permissions",
+		Before: []interface{}{"system:user", "system:user:list"},
+		After:  []interface{}{"system:user"},
+	}, changes["permissions"])
+	assert.Len(t, changes, 1)
+}
+
+// 试运行返回与实际导入相同的变更，但不写入数据库
+func TestRBACImportDryRun(t *testing.T) {
+	db := newTestDB(t)
+	s := NewRBACService(db)
+	ctx := context.Background()
+	role := createTestRole(t, db, "auditor", "system:user", "system:log")
+	require.NoError(t, db.Model(&entity.Permission{}).Where("code = ?", "system:user").Update("name", "用户").Error)
+
+	want := []PolicyChange{
+		{Kind: "permission", Code: "system:user", Action: PolicyActionUpdate, Fields: []string{"name", "type"}},
+		{Kind: "permission", Code: "system:user:list", Action: PolicyActionCreate},
+		{Kind: "role", Code: "auditor", Action: PolicyActionUpdate, Fields: []string{"name"}},
+		{Kind: "role_permission", Code: "auditor", Action: PolicyActionGrant, Target: "system:user:list"},
+		{Kind: "role_permission", Code: "auditor", Action: PolicyActionRevoke, Target: "system:log"},
+	}
+
+	result, err := s.Import(ctx, []byte(testPolicy), true)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, want, result.Changes)
+
+	var permissions int64
+	require.NoError(t, db.Model(&entity.Permission{}).Count(&permissions).Error)
+	assert.EqualValues(t, 2, permissions, "试运行不创建权限")
+	codes, err := rolePermissionCodes(db, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"system:log", "system:user"}, codes, "试运行不修改角色权限")
+
+	result, err = s.Import(ctx, []byte(testPolicy), false)
+	require.NoError(t, err)
+	assert.False(t, result.DryRun)
+	assert.Equal(t, want, result.Changes)
+
+	// 数据库与文档一致后再次导入没有变更，导出的文档同样没有变更
+	result, err = s.Import(ctx, []byte(testPolicy), true)
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+	exported, err := s.Export(ctx)
+	require.NoError(t, err)
+	result, err = s.Import(ctx, exported, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+}
+
+// 角色引用不存在的权限时拒绝导入，不写入任何变更
+func TestRBACImportUnknownPermission(t *testing.T) {
+	db := newTestDB(t)
+	s := NewRBACService(db)
+
+	policy := testPolicy[:len(testPolicy)-len("[system:user, system:user:list]\n")] + "[system:user, system:unknown]\n"
+	_, err := s.Import(context.Background(), []byte(policy), false)
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+	assert.Contains(t, err.Error(), "system:unknown")
+
+	var permissions, roles int64
+	require.NoError(t, db.Model(&entity.Permission{}).Count(&permissions).Error)
+	require.NoError(t, db.Model(&entity.Role{}).Count(&roles).Error)
+	assert.Zero(t, permissions)
+	assert.Zero(t, roles)
+}
+
+// 父角色形成循环时拒绝导入（包括试运行），不写入任何变更
+func TestRBACImportRoleCycle(t *testing.T) {
+	db := newTestDB(t)
+	s := NewRBACService(db)
+	ctx := context.Background()
+	boss := createTestRole(t, db, "boss")
+	manager := createTestRole(t, db, "manager")
+	require.NoError(t, db.Model(manager).Update("parent_id", boss.ID).Error)
+
+	tests := []struct {
+		name   string
+		roles  string
+		cycle  string
+		dryRun bool
+	}{
+		{
+			name:  "自身为父角色",
+			roles: "  - {code: a, name: A, parent: a}\n",
+			cycle: "a -> a",
+		},
+		{
+			name:   "文档中的角色互为父角色",
+			roles:  "  - {code: a, name: A, parent: b}\n  - {code: b, name: B, parent: a}\n",
+			cycle:  "a -> b -> a",
+			dryRun: true,
+		},
+		{
+			name:  "与现有角色的父角色形成循环",
+			roles: "  - {code: boss, name: B, parent: manager}\n",
+			cycle: "boss -> manager -> boss",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Import(ctx, []byte("version: 1\nroles:\n"+tt.roles), tt.dryRun)
+			assert.ErrorIs(t, err, ErrInvalidPolicy)
+			assert.Contains(t, err.Error(), tt.cycle)
+
+			var roles []entity.Role
+			require.NoError(t, db.Order("id").Find(&roles).Error)
+			require.Len(t, roles, 2)
+			assert.Nil(t, roles[0].ParentID)
+			assert.Equal(t, boss.ID, *roles[1].ParentID)
+		})
+	}
+
+	// 同时修改现有角色的父角色时按导入后的父角色检查
+	_, err := s.Import(ctx, []byte("version: 1\nroles:\n  - {code: boss, name: B, parent: manager}\n  - {code: manager, name: M}\n"), false)
+	require.NoError(t, err)
+	var reloaded entity.Role
+	require.NoError(t, db.First(&reloaded, boss.ID).Error)
+	require.NotNil(t, reloaded.ParentID)
+	assert.Equal(t, manager.ID, *reloaded.ParentID)
+}
@@ -4,11 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"gva/internal/domain/audit"
 	"gva/internal/domain/entity"
+	"sort"
 
 	"gorm.io/gorm"
 )
 
+// 定义角色相关的错误
+var (
+	ErrRoleNotFound            = errors.New("角色不存在")
+	ErrRoleExists              = errors.New("角色已存在")
+	ErrSystemRoleProtected     = errors.New("系统内置角色不允许删除")
+	ErrSystemRoleCodeProtected = errors.New("系统内置角色不允许修改编码")
+	ErrRoleInUse               = errors.New("该角色下仍有用户，请指定用户要转移到的角色")
+	ErrInvalidTransferRole     = errors.New("用户转移的目标角色无效")
+)
+
 type RoleService struct {
 	db *gorm.DB
 }
@@ -29,7 +41,13 @@ func (s *RoleService) GetRolePermissions(ctx context.Context, roleID uint) ([]en
 
 // AssignPermissions 为角色分配权限
 func (s *RoleService) AssignPermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 记录分配前的权限，用于审计
+		before, err := rolePermissionCodes(tx, roleID)
+		if err != nil {
+			return err
+		}
+
 		// 先清除原有权限
 		if err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ?", roleID).Error; err != nil {
 			return err
@@ -44,12 +62,34 @@ func (s *RoleService) AssignPermissions(ctx context.Context, roleID uint, permis
 					"permission_id": pid,
 				})
 			}
-			return tx.Table("role_permissions").Create(rolePermissions).Error
+			if err := tx.Table("role_permissions").Create(rolePermissions).Error; err != nil {
+				return err
+			}
 		}
-		return nil
+
+		after, err := rolePermissionCodes(tx, roleID)
+		if err != nil {
+			return err
+		}
+		changes := audit.Diff(map[string]interface{}{"permissions": before}, map[string]interface{}{"permissions": after})
+		return recordAudit(ctx, tx, audit.EntityRole, roleID, audit.ActionAssignPermissions, changes)
 	})
 }
 
+// rolePermissionCodes 获取角色直接拥有的权限编码，按编码排序
+func rolePermissionCodes(tx *gorm.DB, roleID uint) ([]string, error) {
+	codes := make([]string, 0)
+	err := tx.Model(&entity.Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Where("role_permissions.role_id = ?", roleID).
+		Order("permissions.code").
+		Pluck("permissions.code", &codes).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询角色权限失败: %v", err)
+	}
+	return codes, nil
+}
+
 // GetAllRoles 获取所有角色列表
 func (s *RoleService) GetAllRoles(ctx context.Context) ([]entity.Role, error) {
 	var roles []entity.Role
@@ -95,15 +135,14 @@ func (s *RoleService) UpdateRole(ctx context.Context, id uint, role *entity.Role
 		return fmt.Errorf("查询角色失败: %v", err)
 	}
 
-	// 如果修改了角色编码，检查新编码是否已存在
-	if role.Code != existingRole.Code {
-		var count int64
-		if err := s.db.Model(&entity.Role{}).Where("code = ? AND id != ?", role.Code, id).Count(&count).Error; err != nil {
-			return fmt.Errorf("检查角色编码失败: %v", err)
-		}
-		if count > 0 {
-			return fmt.Errorf("角色编码 %s 已存在", role.Code)
-		}
+	// 系统内置角色按编码引用（如注册时分配 user 角色），不允许修改编码
+	if role.Code != existingRole.Code && existingRole.IsSystem() {
+		return ErrSystemRoleCodeProtected
+	}
+
+	// 检查新的名称和编码是否已被其他角色使用
+	if err := checkRoleUnique(s.db, role.Name, role.Code, id); err != nil {
+		return err
 	}
 
 	// 如果未提供状态，默认设置为1
@@ -120,16 +159,162 @@ func (s *RoleService) UpdateRole(ctx context.Context, id uint, role *entity.Role
 		"status":      role.Status,
 	}
 
-	if err := s.db.Model(&existingRole).Updates(updates).Error; err != nil {
-		return fmt.Errorf("更新角色失败: %v", err)
+	before := roleAuditFields(&existingRole)
+
+	// 更新角色，并在同一事务中记录审计事件
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&existingRole).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新角色失败: %v", err)
+		}
+		return recordAudit(ctx, tx, audit.EntityRole, id, audit.ActionUpdate, audit.Diff(before, updates))
+	})
+}
+
+// CloneRole 复制角色及其权限，生成一个新角色
+func (s *RoleService) CloneRole(ctx context.Context, id uint, name, code, description string) (*entity.Role, error) {
+	var clone entity.Role
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var source entity.Role
+		if err := tx.Preload("Permissions").First(&source, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return fmt.Errorf("查询角色失败: %v", err)
+		}
+
+		if err := checkRoleUnique(tx, name, code, 0); err != nil {
+			return err
+		}
+
+		clone = entity.Role{
+			Name:        name,
+			Code:        code,
+			ParentID:    source.ParentID,
+			DataScope:   source.DataScope,
+			Status:      1, // 默认启用
+			Sort:        source.Sort,
+			Description: description,
+			Permissions: source.Permissions,
+		}
+		if err := tx.Create(&clone).Error; err != nil {
+			return fmt.Errorf("创建角色失败: %v", err)
+		}
+
+		after := roleAuditFields(&clone)
+		after["source_role"] = source.Code
+		permissions := make([]string, 0, len(source.Permissions))
+		for _, p := range source.Permissions {
+			permissions = append(permissions, p.Code)
+		}
+		sort.Strings(permissions)
+		after["permissions"] = permissions
+		return recordAudit(ctx, tx, audit.EntityRole, clone.ID, audit.ActionClone, audit.Diff(nil, after))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &clone, nil
 }
 
 // DeleteRole 删除角色
-func (s *RoleService) DeleteRole(ctx context.Context, id uint) error {
-	return s.db.Delete(&entity.Role{}, id).Error
+// 系统内置角色不允许删除；角色下仍有用户时必须指定 transferRoleID，
+// 用户会在同一事务中转移到目标角色，并清理角色的权限关联
+func (s *RoleService) DeleteRole(ctx context.Context, id uint, transferRoleID uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role entity.Role
+		if err := tx.First(&role, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return fmt.Errorf("查询角色失败: %v", err)
+		}
+
+		if role.IsSystem() {
+			return ErrSystemRoleProtected
+		}
+
+		// 记录删除前的字段和权限，用于审计
+		before := roleAuditFields(&role)
+		permissions, err := rolePermissionCodes(tx, id)
+		if err != nil {
+			return err
+		}
+		before["permissions"] = permissions
+		after := map[string]interface{}{}
+
+		// 统计引用该角色的用户（包括已软删除、可恢复的用户）
+		var userCount int64
+		if err := tx.Unscoped().Model(&entity.User{}).Where("role_id = ?", id).Count(&userCount).Error; err != nil {
+			return fmt.Errorf("统计角色用户失败: %v", err)
+		}
+
+		if userCount > 0 {
+			if transferRoleID == 0 {
+				return ErrRoleInUse
+			}
+			if transferRoleID == id {
+				return ErrInvalidTransferRole
+			}
+
+			var target entity.Role
+			if err := tx.First(&target, transferRoleID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrInvalidTransferRole
+				}
+				return fmt.Errorf("查询目标角色失败: %v", err)
+			}
+
+			if err := tx.Unscoped().Model(&entity.User{}).Where("role_id = ?", id).Update("role_id", transferRoleID).Error; err != nil {
+				return fmt.Errorf("转移角色用户失败: %v", err)
+			}
+			after["transfer_role"] = target.Code
+			after["transferred_users"] = userCount
+		}
+
+		// 清理角色权限关联
+		if err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ?", id).Error; err != nil {
+			return fmt.Errorf("清理角色权限失败: %v", err)
+		}
+
+		if err := tx.Delete(&role).Error; err != nil {
+			return fmt.Errorf("删除角色失败: %v", err)
+		}
+
+		return recordAudit(ctx, tx, audit.EntityRole, id, audit.ActionDelete, audit.Diff(before, after))
+	})
+}
+
+// checkRoleUnique 检查名称和编码是否已被 excludeID 以外的角色使用
+// 名称和编码都有唯一索引，已软删除的角色同样占用
+func checkRoleUnique(db *gorm.DB, name, code string, excludeID uint) error {
+	var existing []entity.Role
+	err := db.Unscoped().Select("name", "code").
+		Where("(name = ? OR code = ?) AND id != ?", name, code, excludeID).
+		Find(&existing).Error
+	if err != nil {
+		return fmt.Errorf("检查角色名称和编码失败: %v", err)
+	}
+	for _, role := range existing {
+		if role.Code == code {
+			return fmt.Errorf("%w: 编码 %s", ErrRoleExists, code)
+		}
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("%w: 名称 %s", ErrRoleExists, name)
+	}
+	return nil
+}
+
+// roleAuditFields 角色需要审计的字段
+func roleAuditFields(role *entity.Role) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        role.Name,
+		"code":        role.Code,
+		"description": role.Description,
+		"sort":        role.Sort,
+		"status":      role.Status,
+	}
 }
 
 // TODO: 添加角色相关的业务逻辑
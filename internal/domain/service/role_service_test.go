@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"gva/internal/domain/audit"
+	"gva/internal/domain/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createTestRole 创建拥有指定权限的角色，权限不存在时一并创建
+func createTestRole(t *testing.T, db *gorm.DB, code string, permissionCodes ...string) *entity.Role {
+	role := &entity.Role{Name: code, Code: code, Status: 1}
+	for _, permissionCode := range permissionCodes {
+		permission := entity.Permission{Name: permissionCode, Code: permissionCode, Type: "button", Status: 1}
+		require.NoError(t, db.Where("code = ?", permissionCode).FirstOrCreate(&permission).Error)
+		role.Permissions = append(role.Permissions, permission)
+	}
+	require.NoError(t, db.Create(role).Error)
+	return role
+}
+
+func TestCloneRoleAudit(t *testing.T) {
+	db := newTestDB(t)
+	s := NewRoleService(db)
+	source := createTestRole(t, db, "editor", "system:user:list", "system:user:add")
+
+	clone, err := s.CloneRole(context.Background(), source.ID, "审核员", "reviewer", "")
+	require.NoError(t, err)
+
+	changes := lastAuditChanges(t, db, audit.EntityRole, clone.ID, audit.ActionClone)
+	assert.Equal(t, "reviewer", changes["code"].After)
+	assert.Equal(t, "editor", changes["source_role"].After)
+	assert.Equal(t, []interface{}{"system:user:add", "system:user:list"}, changes["permissions"].After)
+}
+
+func TestDeleteRoleAudit(t *testing.T) {
+	db := newTestDB(t)
+	s := NewRoleService(db)
+	role := createTestRole(t, db, "editor", "system:user:list")
+	target := createTestRole(t, db, "viewer")
+	require.NoError(t, db.Create(&entity.User{Username: "alice", Password: "secret", RoleID: role.ID}).Error)
+
+	require.NoError(t, s.DeleteRole(context.Background(), role.ID, target.ID))
+
+	changes := lastAuditChanges(t, db, audit.EntityRole, role.ID, audit.ActionDelete)
+	assert.Equal(t, "editor", changes["code"].Before)
+	assert.Nil(t, changes["code"].After)
+	assert.Equal(t, []interface{}{"system:user:list"}, changes["permissions"].Before)
+	assert.Equal(t, "viewer", changes["transfer_role"].After)
+	assert.EqualValues(t, 1, changes["transferred_users"].After)
+}
+
+// 系统内置角色可以修改名称等信息，但不能修改编码
+func TestUpdateSystemRoleCode(t *testing.T) {
+	db := newTestDB(t)
+	s := NewRoleService(db)
+	ctx := context.Background()
+	role := createTestRole(t, db, entity.RoleCodeUser)
+
+	err := s.UpdateRole(ctx, role.ID, &entity.Role{Name: "用户", Code: "member", Status: 1})
+	assert.ErrorIs(t, err, ErrSystemRoleCodeProtected)
+
+	require.NoError(t, s.UpdateRole(ctx, role.ID, &entity.Role{Name: "用户", Code: entity.RoleCodeUser, Status: 1}))
+	var updated entity.Role
+	require.NoError(t, db.First(&updated, role.ID).Error)
+	assert.Equal(t, "用户", updated.Name)
+	assert.Equal(t, entity.RoleCodeUser, updated.Code)
+
+	custom := createTestRole(t, db, "editor")
+	require.NoError(t, s.UpdateRole(ctx, custom.ID, &entity.Role{Name: "编辑", Code: "writer", Status: 1}))
+}
+
+// 复制或修改角色时名称和编码不能与其他角色（包括已删除的角色）重复
+func TestRoleUniqueness(t *testing.T) {
+	db := newTestDB(t)
+	s := NewRoleService(db)
+	ctx := context.Background()
+	source := createTestRole(t, db, "editor")
+	deleted := createTestRole(t, db, "archived")
+	require.NoError(t, db.Delete(deleted).Error)
+
+	_, err := s.CloneRole(ctx, source.ID, "editor", "editor_copy", "")
+	assert.ErrorIs(t, err, ErrRoleExists, "名称重复")
+	_, err = s.CloneRole(ctx, source.ID, "副本", "archived", "")
+	assert.ErrorIs(t, err, ErrRoleExists, "编码与已删除的角色重复")
+	_, err = s.CloneRole(ctx, source.ID, "副本", "editor_copy", "")
+	require.NoError(t, err)
+
+	err = s.UpdateRole(ctx, source.ID, &entity.Role{Name: "副本", Code: "editor", Status: 1})
+	assert.ErrorIs(t, err, ErrRoleExists, "名称重复")
+	err = s.UpdateRole(ctx, source.ID, &entity.Role{Name: "editor", Code: "editor_copy", Status: 1})
+	assert.ErrorIs(t, err, ErrRoleExists, "编码重复")
+}
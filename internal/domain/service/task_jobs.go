@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/job"
+
+	"gorm.io/gorm"
+)
+
+// purgeBatchSize 清理任务每批删除的记录数，避免长时间锁表
+const purgeBatchSize = 1000
+
+// purgeParams 清理类任务的参数
+type purgeParams struct {
+	Days int `json:"days"`
+}
+
+// purgeSchema 清理类任务的参数定义，defaultDays 为默认保留天数
+func purgeSchema(defaultDays int) job.Schema {
+	return job.Schema{
+		Properties: map[string]job.Property{
+			"days": {
+				Type:        "integer",
+				Description: "保留最近多少天的记录",
+				Default:     defaultDays,
+				Minimum:     job.Float(1),
+				Maximum:     job.Float(3650),
+			},
+		},
+	}
+}
+
+// archiveParams 操作日志归档任务的参数
+type archiveParams struct {
+	Days int `json:"days"`
+}
+
+// RegisterTaskJobs 注册内置的任务类型
+func RegisterTaskJobs(registry *job.Registry, db *gorm.DB, archiver *OperationLogArchiver, auditService *AuditService) error {
+	definitions := []job.Definition{
+		{
+			Name:        "archive_operation_logs",
+			Title:       "归档操作日志",
+			Description: fmt.Sprintf("将超过保留天数的操作日志归档为压缩文件后删除，未指定天数时使用配置的保留天数（%d天）", archiver.RetentionDays()),
+			Params: job.Schema{
+				Properties: map[string]job.Property{
+					"days": {
+						Type:        "integer",
+						Description: "保留最近多少天的日志，为空时使用配置的保留天数",
+						Minimum:     job.Float(1),
+						Maximum:     job.Float(3650),
+					},
+				},
+			},
+			Run: job.Typed(func(ctx context.Context, p archiveParams) (string, error) {
+				result, err := archiver.Archive(ctx, p.Days)
+				if err != nil {
+					if result != nil {
+						return fmt.Sprintf("已归档 %d 条日志到 %s，已删除 %d 条", result.Archived, result.File, result.Deleted), err
+					}
+					return "", err
+				}
+				if result.File == "" {
+					return fmt.Sprintf("没有 %s 之前的日志需要归档", result.Cutoff.Format("2006-01-02 15:04:05")), nil
+				}
+				return fmt.Sprintf("已归档 %s 之前的 %d 条日志到 %s，已删除 %d 条",
+					result.Cutoff.Format("2006-01-02 15:04:05"), result.Archived, result.File, result.Deleted), nil
+			}),
+		},
+		{
+			Name:        "checkpoint_audit_events",
+			Title:       "签名审计检查点",
+			Description: "使用配置的密钥对审计事件哈希链的链头签名，生成检查点",
+			Run: func(ctx context.Context, _ json.RawMessage) (string, error) {
+				checkpoint, err := auditService.Checkpoint(ctx)
+				if err != nil {
+					return "", err
+				}
+				if checkpoint == nil {
+					return "没有审计事件需要签名", nil
+				}
+				return fmt.Sprintf("检查点 %d 已覆盖到审计事件 %d", checkpoint.ID, checkpoint.LastEventID), nil
+			},
+		},
+		{
+			Name:        "purge_operation_logs",
+			Title:       "清理操作日志",
+			Description: "删除超过保留天数的操作日志，不归档",
+			Params:      purgeSchema(90),
+			Run: job.Typed(func(ctx context.Context, p purgeParams) (string, error) {
+				return purgeBefore(ctx, db, &entity.OperationLog{}, "created_at < ?", p.Days)
+			}),
+		},
+		{
+			Name:        "purge_login_logs",
+			Title:       "清理登录日志",
+			Description: "删除超过保留天数的登录日志",
+			Params:      purgeSchema(180),
+			Run: job.Typed(func(ctx context.Context, p purgeParams) (string, error) {
+				return purgeBefore(ctx, db, &entity.LoginLog{}, "created_at < ?", p.Days)
+			}),
+		},
+		{
+			Name:        "purge_task_logs",
+			Title:       "清理任务日志",
+			Description: "删除超过保留天数且已结束的定时任务执行日志",
+			Params:      purgeSchema(30),
+			Run: job.Typed(func(ctx context.Context, p purgeParams) (string, error) {
+				return purgeBefore(ctx, db, &entity.TaskLog{}, fmt.Sprintf("created_at < ? AND status <> %d", entity.TaskLogRunning), p.Days)
+			}),
+		},
+		{
+			Name:        "purge_message_deliveries",
+			Title:       "清理消息投递记录",
+			Description: "删除超过保留天数且不再重试的消息投递记录",
+			Params:      purgeSchema(30),
+			Run: job.Typed(func(ctx context.Context, p purgeParams) (string, error) {
+				return purgeBefore(ctx, db, &entity.MessageDelivery{}, fmt.Sprintf("created_at < ? AND status <> %d", entity.MessageDeliveryPending), p.Days)
+			}),
+		},
+	}
+
+	for _, def := range definitions {
+		if err := registry.Register(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeBefore 分批永久删除 model 中早于 days 天前且满足 condition 的记录，condition 的唯一参数为截止时间
+func purgeBefore(ctx context.Context, db *gorm.DB, model interface{}, condition string, days int) (string, error) {
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Sprintf("已删除 %d 条记录", total), err
+		}
+
+		var ids []uint
+		err := db.WithContext(ctx).Unscoped().Model(model).
+			Where(condition, cutoff).
+			Limit(purgeBatchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return fmt.Sprintf("已删除 %d 条记录", total), fmt.Errorf("查询待清理记录失败: %v", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		result := db.WithContext(ctx).Unscoped().Delete(model, ids)
+		if result.Error != nil {
+			return fmt.Sprintf("已删除 %d 条记录", total), fmt.Errorf("删除记录失败: %v", result.Error)
+		}
+		total += result.RowsAffected
+		if len(ids) < purgeBatchSize {
+			break
+		}
+	}
+	return fmt.Sprintf("已删除 %s 之前的 %d 条记录", cutoff.Format("2006-01-02 15:04:05"), total), nil
+}
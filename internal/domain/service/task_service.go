@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/job"
+
+	"gorm.io/gorm"
+)
+
+// 定义定时任务相关的错误
+var (
+	ErrTaskNotFound = errors.New("定时任务不存在")
+	ErrTaskCron     = errors.New("无效的cron表达式")
+	ErrTaskHandler  = errors.New("无效的任务类型或参数")
+	ErrTaskRunning  = errors.New("定时任务正在执行")
+)
+
+// maxTaskPreview 预览执行时间的最大次数
+const maxTaskPreview = 20
+
+// TaskScheduler 定时任务调度器
+type TaskScheduler interface {
+	// Schedule 添加或更新任务的调度，未启用的任务会被移除
+	Schedule(task *entity.Task) error
+	// Remove 移除任务的调度
+	Remove(taskID uint)
+	// RunNow 立即在后台执行一次任务，任务正在执行且不允许重叠执行时返回 job.ErrBusy
+	RunNow(taskID uint) (*entity.TaskLog, error)
+	// NextRunTimes 计算cron表达式接下来的 n 次执行时间
+	NextRunTimes(spec string, n int) ([]time.Time, error)
+}
+
+type TaskService struct {
+	db        *gorm.DB
+	scheduler TaskScheduler
+	registry  *job.Registry
+}
+
+func NewTaskService(db *gorm.DB, scheduler TaskScheduler, registry *job.Registry) *TaskService {
+	return &TaskService{db: db, scheduler: scheduler, registry: registry}
+}
+
+// Handlers 获取所有可用的任务类型及其参数定义
+func (s *TaskService) Handlers() []*job.Definition {
+	return s.registry.List()
+}
+
+// List 分页获取定时任务列表
+func (s *TaskService) List(ctx context.Context, page, pageSize int, keyword string, status *int) ([]entity.Task, int64, error) {
+	db := s.db.WithContext(ctx).Model(&entity.Task{})
+	if keyword != "" {
+		db = db.Where("name LIKE ? OR description LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if status != nil {
+		db = db.Where("status = ?", *status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计定时任务失败: %v", err)
+	}
+
+	var tasks []entity.Task
+	err := db.Order("id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询定时任务失败: %v", err)
+	}
+	return tasks, total, nil
+}
+
+// GetByID 获取定时任务
+func (s *TaskService) GetByID(ctx context.Context, id uint) (*entity.Task, error) {
+	var task entity.Task
+	if err := s.db.WithContext(ctx).First(&task, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("查询定时任务失败: %v", err)
+	}
+	return &task, nil
+}
+
+// Create 创建定时任务，启用的任务会立即加入调度
+func (s *TaskService) Create(ctx context.Context, task *entity.Task) error {
+	if err := s.validate(task); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Create(task).Error; err != nil {
+		return fmt.Errorf("创建定时任务失败: %v", err)
+	}
+	return s.reschedule(ctx, task.ID)
+}
+
+// Update 更新定时任务的配置，启用状态通过 Pause/Resume 修改，task.OwnerID 为 0 时不修改负责人
+func (s *TaskService) Update(ctx context.Context, id uint, task *entity.Task) error {
+	if err := s.validate(task); err != nil {
+		return err
+	}
+
+	existing, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"name":           task.Name,
+		"cron":           task.Cron,
+		"handler":        task.Handler,
+		"params":         task.Params,
+		"description":    task.Description,
+		"timeout":        task.Timeout,
+		"max_retries":    task.MaxRetries,
+		"retry_interval": task.RetryInterval,
+		"overlap_policy": task.OverlapPolicy,
+	}
+	if task.OwnerID != 0 {
+		updates["owner_id"] = task.OwnerID
+	}
+	err = s.db.WithContext(ctx).Model(existing).Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("更新定时任务失败: %v", err)
+	}
+	return s.reschedule(ctx, id)
+}
+
+// Delete 删除定时任务，保留执行日志
+func (s *TaskService) Delete(ctx context.Context, id uint) error {
+	result := s.db.WithContext(ctx).Delete(&entity.Task{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除定时任务失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotFound
+	}
+	s.scheduler.Remove(id)
+	return nil
+}
+
+// Pause 暂停定时任务
+func (s *TaskService) Pause(ctx context.Context, id uint) error {
+	return s.updateStatus(ctx, id, 0)
+}
+
+// Resume 恢复定时任务
+func (s *TaskService) Resume(ctx context.Context, id uint) error {
+	return s.updateStatus(ctx, id, 1)
+}
+
+// RunNow 立即执行一次定时任务，返回本次执行的日志，执行结果需通过日志查询
+func (s *TaskService) RunNow(ctx context.Context, id uint) (*entity.TaskLog, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	taskLog, err := s.scheduler.RunNow(id)
+	if err != nil {
+		if errors.Is(err, job.ErrBusy) {
+			return nil, ErrTaskRunning
+		}
+		return nil, fmt.Errorf("执行定时任务失败: %v", err)
+	}
+	return taskLog, nil
+}
+
+// Logs 分页获取定时任务的执行日志，status 不为空时按执行状态筛选
+func (s *TaskService) Logs(ctx context.Context, taskID uint, page, pageSize int, status *int) ([]entity.TaskLog, int64, error) {
+	if _, err := s.GetByID(ctx, taskID); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.db.WithContext(ctx).Model(&entity.TaskLog{}).Where("task_id = ?", taskID)
+	if status != nil {
+		db = db.Where("status = ?", *status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计任务日志失败: %v", err)
+	}
+
+	var logs []entity.TaskLog
+	err := db.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询任务日志失败: %v", err)
+	}
+	return logs, total, nil
+}
+
+// Preview 校验cron表达式并返回接下来的 n 次执行时间
+func (s *TaskService) Preview(spec string, n int) ([]time.Time, error) {
+	if n <= 0 {
+		n = 1
+	}
+	if n > maxTaskPreview {
+		n = maxTaskPreview
+	}
+
+	times, err := s.scheduler.NextRunTimes(spec, n)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTaskCron, err)
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("%w: 表达式不会触发", ErrTaskCron)
+	}
+	return times, nil
+}
+
+// validate 校验cron表达式、任务类型和参数，参数会被替换为填充了默认值的结果
+func (s *TaskService) validate(task *entity.Task) error {
+	if _, err := s.Preview(task.Cron, 1); err != nil {
+		return err
+	}
+
+	switch task.OverlapPolicy {
+	case "":
+		task.OverlapPolicy = entity.TaskOverlapSkip
+	case entity.TaskOverlapSkip, entity.TaskOverlapQueue, entity.TaskOverlapAllow:
+	default:
+		return fmt.Errorf("%w: 不支持的重叠执行策略 %s", ErrTaskHandler, task.OverlapPolicy)
+	}
+
+	params, err := s.registry.Validate(task.Handler, task.Params)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTaskHandler, err)
+	}
+	task.Params = params
+	return nil
+}
+
+func (s *TaskService) updateStatus(ctx context.Context, id uint, status int) error {
+	result := s.db.WithContext(ctx).Model(&entity.Task{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		return fmt.Errorf("更新定时任务状态失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// 状态未变化时 RowsAffected 也可能为 0，需要确认任务是否存在
+		if _, err := s.GetByID(ctx, id); err != nil {
+			return err
+		}
+	}
+	return s.reschedule(ctx, id)
+}
+
+// reschedule 按数据库中的最新配置重新调度任务
+func (s *TaskService) reschedule(ctx context.Context, id uint) error {
+	task, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.scheduler.Schedule(task); err != nil {
+		return fmt.Errorf("调度定时任务失败: %v", err)
+	}
+	return nil
+}
+
+// TaskAlerter 通过站内通知发送定时任务失败告警
+type TaskAlerter struct {
+	notificationService *NotificationService
+}
+
+func NewTaskAlerter(notificationService *NotificationService) *TaskAlerter {
+	return &TaskAlerter{notificationService: notificationService}
+}
+
+// TaskFailed 通知任务负责人任务最终执行失败，任务没有负责人时通知超级管理员
+func (a *TaskAlerter) TaskFailed(ctx context.Context, task *entity.Task, taskLog *entity.TaskLog) error {
+	notification := &entity.Notification{
+		Title: fmt.Sprintf("定时任务执行失败：%s", task.Name),
+		Content: fmt.Sprintf("任务 %s（ID: %d，类型: %s）于 %s 执行失败，共尝试 %d 次。\n最后一次执行结果：\n%s",
+			task.Name, task.ID, task.Handler, taskLog.StartTime.Format("2006-01-02 15:04:05"), taskLog.Attempt, taskLog.Result),
+		Type: "system",
+	}
+
+	target := NotificationTarget{UserIDs: []uint{task.OwnerID}}
+	if task.OwnerID == 0 {
+		target = NotificationTarget{Type: entity.NotificationTargetRole, RoleCodes: []string{"super_admin"}}
+	}
+	return a.notificationService.Send(ctx, 0, notification, target)
+}
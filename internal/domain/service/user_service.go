@@ -3,10 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"gva/internal/domain/audit"
 	"gva/internal/domain/cache"
 	"gva/internal/domain/entity"
+	"gva/internal/domain/push"
 	"gva/internal/domain/repository"
 	"gva/internal/pkg/jwt"
+	"gva/internal/pkg/mask"
 	"gva/internal/pkg/utils"
 
 	"log"
@@ -15,6 +18,9 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -31,20 +37,28 @@ var (
 )
 
 type UserService struct {
-	userRepo repository.UserRepository
-	db       *gorm.DB
-	cache    cache.UserCache
+	userRepo  repository.UserRepository
+	db        *gorm.DB
+	cache     cache.UserCache
+	publisher push.Publisher
+	loginLogs *LoginLogService
 }
 
-func NewUserService(userRepo repository.UserRepository, db *gorm.DB, cache cache.UserCache) *UserService {
+func NewUserService(userRepo repository.UserRepository, db *gorm.DB, cache cache.UserCache, publisher push.Publisher) *UserService {
 	// 如果缓存为空，创建一个空实现
 	if cache == nil {
 		cache = &EmptyCache{}
 	}
+	// 如果没有推送服务，不推送实时消息
+	if publisher == nil {
+		publisher = push.NopPublisher{}
+	}
 	return &UserService{
-		userRepo: userRepo,
-		db:       db,
-		cache:    cache,
+		userRepo:  userRepo,
+		db:        db,
+		cache:     cache,
+		publisher: publisher,
+		loginLogs: NewLoginLogService(db),
 	}
 }
 
@@ -101,32 +115,61 @@ func (s *UserService) Register(ctx context.Context, username, password string) e
 	user := &entity.User{
 		Username: username,
 		Password: string(hashedPassword),
-		Status:   1,
+		Status:   entity.UserStatusNormal,
 		RoleID:   defaultRole.ID,
 	}
 
 	return s.userRepo.Create(ctx, user)
 }
 
-// Login 用户登录
-func (s *UserService) Login(ctx context.Context, username, password string) (*entity.User, string, error) {
+// Login 用户登录，每次登录尝试都会记录登录日志
+func (s *UserService) Login(ctx context.Context, username, password string, client LoginClient) (*entity.User, string, error) {
+	user, err := s.authenticate(ctx, username, password)
+
+	var userID uint
+	if user != nil {
+		userID = user.ID
+	}
+	if logErr := s.loginLogs.Record(ctx, username, userID, loginResult(err), client); logErr != nil {
+		log.Printf("%v", logErr)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 生成token
+	token, err := jwt.GenerateToken(user.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("生成token失败: %v", err)
+	}
+
+	// 预加载角色信息
+	if err := s.db.Preload("Role").First(user, user.ID).Error; err != nil {
+		return nil, "", fmt.Errorf("加载用户角色失败: %v", err)
+	}
+
+	return user, token, nil
+}
+
+// authenticate 校验用户状态和密码，用户名对应的用户存在时即使校验失败也返回该用户
+func (s *UserService) authenticate(ctx context.Context, username, password string) (*entity.User, error) {
 	// 缓存未命中，从数据库查询
 	user, err := s.userRepo.FindByUsername(ctx, username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", ErrUserNotFound
+			return nil, ErrUserNotFound
 		}
-		return nil, "", fmt.Errorf("查询用户失败: %v", err)
+		return nil, fmt.Errorf("查询用户失败: %v", err)
 	}
 
-	if user.Status == 2 {
+	if user.Status == entity.UserStatusFrozen {
 		// 您的账号已被冻结，请联系客服
-		return nil, "", ErrUserFrozen
+		return user, ErrUserFrozen
 	}
 
 	if user.Phone == "" && len(user.Phone) < 11 && len(user.Phone) > 11 {
 		// 您的账号未绑定手机号或手机号码格式不正确，请联系客服
-		return nil, "", ErrUserPhoneNotVerified
+		return user, ErrUserPhoneNotVerified
 	}
 
 	// 将用户信息存入缓存
@@ -136,70 +179,131 @@ func (s *UserService) Login(ctx context.Context, username, password string) (*en
 
 	// 检查用户状态
 	switch user.Status {
-	case 0:
-		return nil, "", ErrUserDisabled
-	case 2:
-		return nil, "", ErrUserNotVerified
+	case entity.UserStatusDisabled:
+		return user, ErrUserDisabled
+	case entity.UserStatusPending:
+		return user, ErrUserNotVerified
 	}
 
-	fmt.Println("222user.Password", user.Password)
-
 	// 验证密码
 	if !utils.CheckPassword(password, user.Password) {
-		return nil, "", ErrIncorrectPass
+		return user, ErrIncorrectPass
 	}
+	return user, nil
+}
 
-	// 生成token
-	token, err := jwt.GenerateToken(user.ID)
-	if err != nil {
-		return nil, "", fmt.Errorf("生成token失败: %v", err)
-	}
-
-	// 预加载角色信息
-	if err := s.db.Preload("Role").First(user, user.ID).Error; err != nil {
-		return nil, "", fmt.Errorf("加载用户角色失败: %v", err)
+// loginResult 将登录错误转换为登录日志中的登录结果
+func loginResult(err error) string {
+	switch {
+	case err == nil:
+		return entity.LoginResultSuccess
+	case errors.Is(err, ErrIncorrectPass):
+		return entity.LoginResultWrongPassword
+	case errors.Is(err, ErrUserNotFound):
+		return entity.LoginResultNotFound
+	case errors.Is(err, ErrUserFrozen):
+		return entity.LoginResultFrozen
+	case errors.Is(err, ErrUserDisabled):
+		return entity.LoginResultDisabled
+	case errors.Is(err, ErrUserNotVerified):
+		return entity.LoginResultPending
+	default:
+		return entity.LoginResultError
 	}
+}
 
-	return user, token, nil
+// RecentLogins 获取用户最近的登录记录
+func (s *UserService) RecentLogins(ctx context.Context, userID uint, limit int) ([]entity.LoginLog, error) {
+	return s.loginLogs.Recent(ctx, userID, limit)
 }
 
 // UpdateProfile 更新用户信息
-func (s *UserService) UpdateProfile(ctx context.Context, userID uint, username, nickname, email, phone string, roleID uint) error {
-	// 获取用户信息
-	user, err := s.userRepo.FindByID(ctx, userID)
-	if err != nil {
-		return fmt.Errorf("查询用户失败: %v", err)
-	}
+// departmentID 为 nil 时不修改所属部门，为 0 时清除所属部门；positionIDs 为 nil 时不修改岗位
+func (s *UserService) UpdateProfile(ctx context.Context, userID uint, username, nickname, email, phone string, roleID uint, departmentID *uint, positionIDs []uint) error {
+	// 查询、校验和保存在同一事务中进行，避免校验后的数据在保存前被并发修改
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 获取用户信息
+		var user entity.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
 
-	// 如果用户名发生变化，检查新用户名是否已存在
-	if username != user.Username {
-		existingUser, err := s.userRepo.FindByUsername(ctx, username)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("检查用户名失败: %v", err)
+		// 如果用户名发生变化，检查新用户名是否已存在
+		if username != user.Username {
+			var existingUser entity.User
+			err := tx.Where("username = ?", username).First(&existingUser).Error
+			if err == nil {
+				return fmt.Errorf("用户名 %s 已被使用", username)
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("检查用户名失败: %v", err)
+			}
 		}
-		if existingUser != nil {
-			return fmt.Errorf("用户名 %s 已被使用", username)
+
+		// 记录修改前的字段，用于审计
+		before := userAuditFields(&user)
+		if positionIDs != nil {
+			ids, err := userPositionIDs(tx, user.ID)
+			if err != nil {
+				return err
+			}
+			before["position_ids"] = ids
 		}
-	}
 
-	// 如果要更新角色，先检查角色是否存在
-	if roleID > 0 {
-		var role entity.Role
-		if err := s.db.First(&role, roleID).Error; err != nil {
-			return fmt.Errorf("角色不存在或已被删除")
+		// 如果要更新角色，先检查角色是否存在
+		if roleID > 0 {
+			var role entity.Role
+			if err := tx.First(&role, roleID).Error; err != nil {
+				return fmt.Errorf("角色不存在或已被删除")
+			}
+			user.RoleID = roleID
 		}
-		user.RoleID = roleID
-	}
 
-	// 更新用户信息
-	user.Username = username
-	user.Nickname = nickname
-	user.Email = email
-	user.Phone = phone
+		// 如果要更新部门，先检查部门是否存在
+		if departmentID != nil {
+			if *departmentID == 0 {
+				user.DepartmentID = nil
+			} else {
+				if err := checkDepartment(tx, *departmentID); err != nil {
+					return err
+				}
+				user.DepartmentID = departmentID
+			}
+		}
 
-	// 保存更新
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		return fmt.Errorf("更新用户信息失败: %v", err)
+		// 如果要更新岗位，先检查岗位是否存在
+		var positions []entity.Position
+		if positionIDs != nil {
+			var err error
+			if positions, err = findPositions(tx, positionIDs); err != nil {
+				return err
+			}
+		}
+
+		// 更新用户信息
+		user.Username = username
+		user.Nickname = nickname
+		user.Email = email
+		user.Phone = phone
+
+		after := userAuditFields(&user)
+		if positionIDs != nil {
+			after["position_ids"] = sortedPositionIDs(positions)
+		}
+
+		// 保存更新，并在同一事务中记录审计事件
+		if err := tx.Save(&user).Error; err != nil {
+			return fmt.Errorf("更新用户信息失败: %v", err)
+		}
+		if positionIDs != nil {
+			if err := tx.Model(&user).Association("Positions").Replace(positions); err != nil {
+				return fmt.Errorf("更新用户岗位失败: %v", err)
+			}
+		}
+		return recordAudit(ctx, tx, audit.EntityUser, user.ID, audit.ActionUpdate, userAuditChanges(before, after))
+	})
+	if err != nil {
+		return err
 	}
 
 	// 删除缓存
@@ -275,8 +379,8 @@ func (s *UserService) GetUserByID(ctx context.Context, id uint) (*entity.User, e
 }
 
 // ListUsers 获取用户列表
-func (s *UserService) ListUsers(ctx context.Context, page, pageSize int, keyword string, status *int) ([]*entity.User, int64, error) {
-	return s.userRepo.List(ctx, page, pageSize, keyword, status)
+func (s *UserService) ListUsers(ctx context.Context, page, pageSize int, filter repository.UserFilter) ([]*entity.User, int64, error) {
+	return s.userRepo.List(ctx, page, pageSize, filter)
 }
 
 // UpdateUserStatus 更新用户状态
@@ -294,25 +398,56 @@ func (s *UserService) UpdateUserStatus(ctx context.Context, userID uint, status
 
 	log.Printf("找到用户 - UserID: %d, Username: %s", user.ID, user.Username)
 
-	if err := s.db.Model(&user).Update("status", status).Error; err != nil {
+	changes := audit.Diff(map[string]interface{}{"status": user.Status}, map[string]interface{}{"status": status})
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("status", status).Error; err != nil {
+			return fmt.Errorf("更新状态失败: %v", err)
+		}
+		return recordAudit(ctx, tx, audit.EntityUser, user.ID, audit.ActionUpdateStatus, changes)
+	})
+	if err != nil {
 		log.Printf("更新状态失败 - UserID: %d, Error: %v", userID, err)
-		return fmt.Errorf("更新状态失败: %v", err)
+		return err
 	}
 
 	log.Printf("更新状态成功 - UserID: %d, NewStatus: %d", userID, status)
+
+	// 删除缓存
+	if err := s.cache.DeleteUserByID(ctx, userID); err != nil {
+		log.Printf("删除用户缓存失败: %v", err)
+	}
+
+	// 账号不再可用时，通知在线的客户端下线
+	if status != entity.UserStatusNormal {
+		s.forceLogout(ctx, userID, "账号状态已变更")
+	}
 	return nil
 }
 
 // DeleteUser 删除用户（硬删除）
 func (s *UserService) DeleteUser(ctx context.Context, userID uint) error {
 	// 先检查用户是否存在
-	if err := s.db.First(&entity.User{}, userID).Error; err != nil {
+	var user entity.User
+	if err := s.db.First(&user, userID).Error; err != nil {
 		return fmt.Errorf("用户不存在: %v", err)
 	}
 
-	// 使用 Unscoped 来执行硬删除
-	if err := s.db.Unscoped().Delete(&entity.User{}, userID).Error; err != nil {
-		return fmt.Errorf("删除用户失败: %v", err)
+	before := userAuditFields(&user)
+	positionIDs, err := userPositionIDs(s.db, userID)
+	if err != nil {
+		return err
+	}
+	before["position_ids"] = positionIDs
+
+	// 使用 Unscoped 来执行硬删除，并在同一事务中记录审计事件
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&entity.User{}, userID).Error; err != nil {
+			return fmt.Errorf("删除用户失败: %v", err)
+		}
+		return recordAudit(ctx, tx, audit.EntityUser, userID, audit.ActionDelete, userAuditChanges(before, nil))
+	})
+	if err != nil {
+		return err
 	}
 
 	// 删除缓存
@@ -320,32 +455,33 @@ func (s *UserService) DeleteUser(ctx context.Context, userID uint) error {
 		log.Printf("删除用户缓存失败: %v", err)
 	}
 
+	s.forceLogout(ctx, userID, "账号已被删除")
 	return nil
 }
 
+// forceLogout 推送强制下线事件给用户的所有在线客户端
+func (s *UserService) forceLogout(ctx context.Context, userID uint, reason string) {
+	if err := s.publisher.Publish(ctx, userID, push.EventForceLogout, map[string]string{"reason": reason}); err != nil {
+		log.Printf("推送强制下线消息失败 - UserID: %d, Error: %v", userID, err)
+	}
+}
+
 // ExportUsers 导出所有用户
 func (s *UserService) ExportUsers(ctx context.Context) ([]*entity.User, error) {
 	return s.userRepo.FindAll(ctx)
 }
 
 // ImportUsers 导入用户
+// 先解析并校验所有行，再在同一事务中创建全部用户，任一行失败时整个导入回滚，不会留下部分导入的用户
 func (s *UserService) ImportUsers(ctx context.Context, reader io.Reader) ([]*entity.User, error) {
-	// 获取默认角色
-	var defaultRole entity.Role
-	if err := s.db.Where("code = ?", "user").First(&defaultRole).Error; err != nil {
-		// 如果找不到默认角色，创建一个
-		defaultRole = entity.Role{
-			Name: "普通用户",
-			Code: "user",
-		}
-		if err := s.db.Create(&defaultRole).Error; err != nil {
-			return nil, err
-		}
-	}
-
 	r := csv.NewReader(reader)
 	r.FieldsPerRecord = -1 // 允许字段数量不固定
 
+	// 部门编码 -> 部门ID
+	departmentIDs := make(map[string]uint)
+	// 岗位编码 -> 岗位
+	positionsByCode := make(map[string]entity.Position)
+
 	// 跳过表头
 	if _, err := r.Read(); err != nil {
 		return nil, err
@@ -365,6 +501,47 @@ func (s *UserService) ImportUsers(ctx context.Context, reader io.Reader) ([]*ent
 			continue
 		}
 
+		// 第5列为可选的部门编码
+		var departmentID *uint
+		if len(record) > 4 && record[4] != "" {
+			code := record[4]
+			id, ok := departmentIDs[code]
+			if !ok {
+				var department entity.Department
+				if err := s.db.Where("code = ?", code).First(&department).Error; err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return nil, fmt.Errorf("用户 %s 的部门编码 %s 不存在", record[0], code)
+					}
+					return nil, fmt.Errorf("查询部门失败: %v", err)
+				}
+				id = department.ID
+				departmentIDs[code] = id
+			}
+			departmentID = &id
+		}
+
+		// 第6列为可选的岗位编码，多个岗位以 | 分隔
+		var positions []entity.Position
+		if len(record) > 5 && record[5] != "" {
+			for _, code := range strings.Split(record[5], "|") {
+				code = strings.TrimSpace(code)
+				if code == "" {
+					continue
+				}
+				position, ok := positionsByCode[code]
+				if !ok {
+					if err := s.db.Where("code = ?", code).First(&position).Error; err != nil {
+						if errors.Is(err, gorm.ErrRecordNotFound) {
+							return nil, fmt.Errorf("用户 %s 的岗位编码 %s 不存在", record[0], code)
+						}
+						return nil, fmt.Errorf("查询岗位失败: %v", err)
+					}
+					positionsByCode[code] = position
+				}
+				positions = append(positions, position)
+			}
+		}
+
 		// 生成随机密码
 		password := fmt.Sprintf("%08d", rand.Intn(100000000))
 		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -375,16 +552,50 @@ func (s *UserService) ImportUsers(ctx context.Context, reader io.Reader) ([]*ent
 			Email:    record[2],
 			Phone:    record[3],
 			Password: string(hashedPassword),
-			Status:   1,              // 默认正常状态
-			RoleID:   defaultRole.ID, // 设置默认角色ID
-		}
+			Status:   entity.UserStatusNormal, // 默认正常状态
 
-		if err := s.userRepo.Create(ctx, user); err != nil {
-			return nil, err
+			DepartmentID: departmentID,
+			Positions:    positions,
 		}
+
 		users = append(users, user)
 	}
 
+	// 创建用户，并在同一事务中记录审计事件
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 获取默认角色
+		var defaultRole entity.Role
+		if err := tx.Where("code = ?", entity.RoleCodeUser).First(&defaultRole).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("查询默认角色失败: %v", err)
+			}
+			// 如果找不到默认角色，创建一个
+			defaultRole = entity.Role{
+				Name: "普通用户",
+				Code: entity.RoleCodeUser,
+			}
+			if err := tx.Create(&defaultRole).Error; err != nil {
+				return fmt.Errorf("创建默认角色失败: %v", err)
+			}
+		}
+
+		for _, user := range users {
+			user.RoleID = defaultRole.ID
+			if err := tx.Create(user).Error; err != nil {
+				return fmt.Errorf("导入用户 %s 失败: %v", user.Username, err)
+			}
+			after := userAuditFields(user)
+			after["position_ids"] = sortedPositionIDs(user.Positions)
+			if err := recordAudit(ctx, tx, audit.EntityUser, user.ID, audit.ActionImport, userAuditChanges(nil, after)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return users, nil
 }
 
@@ -393,6 +604,8 @@ func (s *UserService) GetUserWithRole(ctx context.Context, userID uint) (*entity
 	var user entity.User
 	err := s.db.Preload("Role").
 		Preload("Role.Permissions").
+		Preload("Department").
+		Preload("Positions").
 		First(&user, userID).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -464,7 +677,7 @@ func (s *UserService) RestoreUser(ctx context.Context, userID uint) error {
 }
 
 // CreateUser 创建用户
-func (s *UserService) CreateUser(ctx context.Context, username, password, nickname, email, phone string, roleID uint) error {
+func (s *UserService) CreateUser(ctx context.Context, username, password, nickname, email, phone string, roleID uint, departmentID *uint, positionIDs []uint) error {
 	// 检查用户名是否已存在
 	existingUser, err := s.userRepo.FindByUsername(ctx, username)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -482,6 +695,22 @@ func (s *UserService) CreateUser(ctx context.Context, username, password, nickna
 		}
 	}
 
+	// 检查部门是否存在
+	if departmentID != nil && *departmentID == 0 {
+		departmentID = nil
+	}
+	if departmentID != nil {
+		if err := checkDepartment(s.db, *departmentID); err != nil {
+			return err
+		}
+	}
+
+	// 检查岗位是否存在
+	positions, err := findPositions(s.db, positionIDs)
+	if err != nil {
+		return err
+	}
+
 	// 如果没有提供密码，使用默认密码
 	if password == "" {
 		password = "123456"
@@ -501,7 +730,10 @@ func (s *UserService) CreateUser(ctx context.Context, username, password, nickna
 		Email:    email,
 		Phone:    phone,
 		RoleID:   roleID,
-		Status:   1, // 默认启用
+		Status:   entity.UserStatusNormal, // 默认启用
+
+		DepartmentID: departmentID,
+		Positions:    positions,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
@@ -510,3 +742,141 @@ func (s *UserService) CreateUser(ctx context.Context, username, password, nickna
 
 	return nil
 }
+
+// GetDepartmentChain 获取部门链（从顶级部门到指定部门）
+func (s *UserService) GetDepartmentChain(ctx context.Context, department *entity.Department) ([]entity.Department, error) {
+	if department == nil {
+		return []entity.Department{}, nil
+	}
+
+	// 部门路径形如 /1/3/7/，依次为各级部门ID
+	var ids []uint
+	for _, part := range strings.Split(strings.Trim(department.Path, "/"), "/") {
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+
+	var departments []entity.Department
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&departments).Error; err != nil {
+		return nil, fmt.Errorf("查询部门链失败: %v", err)
+	}
+
+	byID := make(map[uint]entity.Department, len(departments))
+	for _, d := range departments {
+		byID[d.ID] = d
+	}
+
+	chain := make([]entity.Department, 0, len(ids))
+	for _, id := range ids {
+		if d, ok := byID[id]; ok {
+			chain = append(chain, d)
+		}
+	}
+	return chain, nil
+}
+
+// checkDepartment 检查部门是否存在
+func checkDepartment(db *gorm.DB, departmentID uint) error {
+	var department entity.Department
+	if err := db.First(&department, departmentID).Error; err != nil {
+		return fmt.Errorf("部门不存在或已被删除")
+	}
+	return nil
+}
+
+// GetUserPositions 获取用户的岗位列表
+func (s *UserService) GetUserPositions(ctx context.Context, userID uint) ([]entity.Position, error) {
+	var positions []entity.Position
+	err := s.db.WithContext(ctx).
+		Joins("JOIN user_positions ON user_positions.position_id = positions.id").
+		Where("user_positions.user_id = ?", userID).
+		Order("positions.sort ASC, positions.id ASC").
+		Find(&positions).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询用户岗位失败: %v", err)
+	}
+	return positions, nil
+}
+
+// findPositions 根据ID查询岗位，任一岗位不存在时返回错误
+func findPositions(db *gorm.DB, positionIDs []uint) ([]entity.Position, error) {
+	positions := []entity.Position{}
+	if len(positionIDs) == 0 {
+		return positions, nil
+	}
+
+	if err := db.Where("id IN ?", positionIDs).Find(&positions).Error; err != nil {
+		return nil, fmt.Errorf("查询岗位失败: %v", err)
+	}
+
+	found := make(map[uint]bool, len(positions))
+	for _, p := range positions {
+		found[p.ID] = true
+	}
+	for _, id := range positionIDs {
+		if !found[id] {
+			return nil, fmt.Errorf("岗位 %d 不存在或已被删除", id)
+		}
+	}
+	return positions, nil
+}
+
+// userAuditFields 用户需要审计的字段
+func userAuditFields(user *entity.User) map[string]interface{} {
+	fields := map[string]interface{}{
+		"username":      user.Username,
+		"nickname":      user.Nickname,
+		"email":         user.Email,
+		"phone":         user.Phone,
+		"role_id":       user.RoleID,
+		"department_id": nil,
+	}
+	if user.DepartmentID != nil {
+		fields["department_id"] = *user.DepartmentID
+	}
+	return fields
+}
+
+// userAuditChanges 比较用户变更前后的审计字段，手机号、邮箱按原值比较，记录脱敏后的值，避免审计日志泄露明文
+func userAuditChanges(before, after map[string]interface{}) []audit.Change {
+	changes := audit.Diff(before, after)
+	for i := range changes {
+		field := changes[i].Field
+		if field != entity.UserFieldPhone && field != entity.UserFieldEmail {
+			continue
+		}
+		if v, ok := changes[i].Before.(string); ok {
+			changes[i].Before = mask.Field(field, v)
+		}
+		if v, ok := changes[i].After.(string); ok {
+			changes[i].After = mask.Field(field, v)
+		}
+	}
+	return changes
+}
+
+// sortedPositionIDs 返回岗位的ID，按ID排序
+func sortedPositionIDs(positions []entity.Position) []uint {
+	ids := make([]uint, 0, len(positions))
+	for _, p := range positions {
+		ids = append(ids, p.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// userPositionIDs 获取用户当前的岗位ID，按ID排序
+func userPositionIDs(db *gorm.DB, userID uint) ([]uint, error) {
+	ids := make([]uint, 0)
+	err := db.Table("user_positions").
+		Where("user_id = ?", userID).
+		Order("position_id").
+		Pluck("position_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询用户岗位失败: %v", err)
+	}
+	return ids, nil
+}
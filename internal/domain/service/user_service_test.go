@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gva/internal/domain/audit"
+	"gva/internal/domain/entity"
+	"gva/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestUserService(t *testing.T) (*UserService, *gorm.DB) {
+	db := newTestDB(t)
+	return NewUserService(repository.NewUserRepository(db), db, nil, nil), db
+}
+
+func createTestUser(t *testing.T, db *gorm.DB) *entity.User {
+	role := &entity.Role{Name: "普通用户", Code: "user", Status: 1}
+	require.NoError(t, db.Create(role).Error)
+	user := &entity.User{
+		Username: "alice",
+		Password: "secret",
+		Nickname: "Alice",
+		Email:    "alice@example.com",
+		Phone:    "13800001111",
+		RoleID:   role.ID,
+		Status:   1,
+	}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+// 审计事件中的手机号、邮箱只记录脱敏后的值
+func TestUpdateProfileAuditMasksContactFields(t *testing.T) {
+	s, db := newTestUserService(t)
+	user := createTestUser(t, db)
+
+	err := s.UpdateProfile(context.Background(), user.ID, user.Username, user.Nickname, "bob@example.com", "13900002222", 0, nil, nil)
+	require.NoError(t, err)
+
+	changes := lastAuditChanges(t, db, audit.EntityUser, user.ID, audit.ActionUpdate)
+	assert.Equal(t, audit.Change{Field: "phone", Before: "138****1111", After: "139****2222"}, changes["phone"])
+	assert.Equal(t, audit.Change{Field: "email", Before: "a***@example.com", After: "b***@example.com"}, changes["email"])
+
+	var events []entity.AuditEvent
+	require.NoError(t, db.Find(&events).Error)
+	for _, event := range events {
+		for _, plain := range []string{"13800001111", "13900002222", "alice@example.com", "bob@example.com"} {
+			assert.NotContains(t, string(event.Changes), plain)
+		}
+	}
+}
+
+// 保存用户、更新岗位和记录审计事件在同一事务中，任一步失败时全部回滚
+func TestUpdateProfileTransaction(t *testing.T) {
+	s, db := newTestUserService(t)
+	user := createTestUser(t, db)
+	positions := []entity.Position{{Code: "dev", Name: "开发"}, {Code: "ops", Name: "运维"}}
+	require.NoError(t, db.Create(&positions).Error)
+	require.NoError(t, db.Model(user).Association("Positions").Replace(positions[:1]))
+
+	// 岗位不存在时不保存用户信息
+	err := s.UpdateProfile(context.Background(), user.ID, user.Username, "Alice2", user.Email, user.Phone, 0, nil, []uint{positions[1].ID, 999})
+	require.Error(t, err)
+
+	// 记录审计事件失败时回滚已保存的用户信息和岗位
+	failAudit := func(tx *gorm.DB) {
+		if tx.Statement.Table == "audit_events" {
+			tx.AddError(errors.New("audit down"))
+		}
+	}
+	require.NoError(t, db.Callback().Create().Before("gorm:create").Register("test:fail_audit", failAudit))
+	err = s.UpdateProfile(context.Background(), user.ID, user.Username, "Alice2", user.Email, user.Phone, 0, nil, []uint{positions[1].ID})
+	require.Error(t, err)
+	require.NoError(t, db.Callback().Create().Remove("test:fail_audit"))
+
+	var saved entity.User
+	require.NoError(t, db.Preload("Positions").First(&saved, user.ID).Error)
+	assert.Equal(t, "Alice", saved.Nickname)
+	require.Len(t, saved.Positions, 1)
+	assert.Equal(t, positions[0].ID, saved.Positions[0].ID)
+
+	require.NoError(t, s.UpdateProfile(context.Background(), user.ID, user.Username, "Alice2", user.Email, user.Phone, 0, nil, []uint{positions[1].ID}))
+	require.NoError(t, db.Preload("Positions").First(&saved, user.ID).Error)
+	assert.Equal(t, "Alice2", saved.Nickname)
+	require.Len(t, saved.Positions, 1)
+	assert.Equal(t, positions[1].ID, saved.Positions[0].ID)
+
+	changes := lastAuditChanges(t, db, audit.EntityUser, user.ID, audit.ActionUpdate)
+	assert.Equal(t, "Alice2", changes["nickname"].After)
+	assert.NotNil(t, changes["position_ids"].After)
+}
+
+func TestDeleteUserAudit(t *testing.T) {
+	s, db := newTestUserService(t)
+	user := createTestUser(t, db)
+
+	require.NoError(t, s.DeleteUser(context.Background(), user.ID))
+
+	changes := lastAuditChanges(t, db, audit.EntityUser, user.ID, audit.ActionDelete)
+	assert.Equal(t, "alice", changes["username"].Before)
+	assert.Nil(t, changes["username"].After)
+	assert.Equal(t, "138****1111", changes["phone"].Before)
+	assert.Equal(t, "a***@example.com", changes["email"].Before)
+}
+
+func TestImportUsersAudit(t *testing.T) {
+	s, db := newTestUserService(t)
+
+	csv := "username,nickname,email,phone\ncarol,Carol,carol@example.com,13700003333\n"
+	users, err := s.ImportUsers(context.Background(), strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+
+	changes := lastAuditChanges(t, db, audit.EntityUser, users[0].ID, audit.ActionImport)
+	assert.Nil(t, changes["username"].Before)
+	assert.Equal(t, "carol", changes["username"].After)
+	assert.Equal(t, "137****3333", changes["phone"].After)
+	assert.Equal(t, "c***@example.com", changes["email"].After)
+}
+
+// 任一行导入失败时整个导入回滚，不留下部分导入的用户和审计事件
+func TestImportUsersRollback(t *testing.T) {
+	s, db := newTestUserService(t)
+
+	csv := "username,nickname,email,phone\ncarol,Carol,carol@example.com,13700003333\ncarol,Carol2,carol2@example.com,13700004444\n"
+	users, err := s.ImportUsers(context.Background(), strings.NewReader(csv))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "导入用户 carol 失败")
+	assert.Nil(t, users)
+
+	var count int64
+	require.NoError(t, db.Model(&entity.User{}).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&entity.AuditEvent{}).Where("action = ?", audit.ActionImport).Count(&count).Error)
+	assert.Zero(t, count)
+}
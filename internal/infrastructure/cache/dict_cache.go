@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gva/internal/domain/entity"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// dictCacheTTL 字典缓存的过期时间，字典修改时会主动失效
+const dictCacheTTL = time.Hour
+
+func dictCacheKey(code string) string {
+	return fmt.Sprintf("dict:code:%s", code)
+}
+
+// RedisDictCache Redis实现的字典缓存
+type RedisDictCache struct {
+	rdb *redis.Client
+}
+
+func NewRedisDictCache(rdb *redis.Client) *RedisDictCache {
+	return &RedisDictCache{rdb: rdb}
+}
+
+// GetDict 获取字典，未命中时返回 nil
+func (c *RedisDictCache) GetDict(ctx context.Context, code string) (*entity.Dict, error) {
+	data, err := c.rdb.Get(ctx, dictCacheKey(code)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var dict entity.Dict
+	if err := json.Unmarshal(data, &dict); err != nil {
+		return nil, err
+	}
+	return &dict, nil
+}
+
+// SetDict 缓存字典
+func (c *RedisDictCache) SetDict(ctx context.Context, dict *entity.Dict) error {
+	data, err := json.Marshal(dict)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, dictCacheKey(dict.Code), data, dictCacheTTL).Err()
+}
+
+// DeleteDict 删除字典缓存
+func (c *RedisDictCache) DeleteDict(ctx context.Context, codes ...string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(codes))
+	for _, code := range codes {
+		keys = append(keys, dictCacheKey(code))
+	}
+	return c.rdb.Del(ctx, keys...).Err()
+}
+
+// MemoryDictCache 进程内存实现的字典缓存，用于未配置Redis的单实例部署
+type MemoryDictCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryDictEntry
+}
+
+type memoryDictEntry struct {
+	dict     entity.Dict
+	expireAt time.Time
+}
+
+func NewMemoryDictCache() *MemoryDictCache {
+	return &MemoryDictCache{entries: make(map[string]memoryDictEntry)}
+}
+
+// GetDict 获取字典，未命中或已过期时返回 nil
+func (c *MemoryDictCache) GetDict(ctx context.Context, code string) (*entity.Dict, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[code]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(entry.expireAt) {
+		return nil, nil
+	}
+	return copyDict(&entry.dict), nil
+}
+
+// SetDict 缓存字典
+func (c *MemoryDictCache) SetDict(ctx context.Context, dict *entity.Dict) error {
+	c.mu.Lock()
+	c.entries[dict.Code] = memoryDictEntry{
+		dict:     *copyDict(dict),
+		expireAt: time.Now().Add(dictCacheTTL),
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// DeleteDict 删除字典缓存
+func (c *MemoryDictCache) DeleteDict(ctx context.Context, codes ...string) error {
+	c.mu.Lock()
+	for _, code := range codes {
+		delete(c.entries, code)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// copyDict 复制字典及其字典项，避免调用方修改缓存内容
+func copyDict(dict *entity.Dict) *entity.Dict {
+	copied := *dict
+	copied.Items = append([]entity.DictItem(nil), dict.Items...)
+	return &copied
+}
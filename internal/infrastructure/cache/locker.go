@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// renewLockScript 仅当锁仍属于自己时续期
+var renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseLockScript 仅当锁仍属于自己时释放
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisLocker 基于Redis SETNX 的互斥锁，多个实例共享
+type RedisLocker struct {
+	rdb *redis.Client
+}
+
+func NewRedisLocker(rdb *redis.Client) *RedisLocker {
+	return &RedisLocker{rdb: rdb}
+}
+
+// TryLock 尝试获取锁，成功时返回释放函数，持有期间按 ttl 的三分之一续期
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	b := make([]byte, 8)
+	rand.Read(b)
+	holder := hex.EncodeToString(b)
+	ok, err := l.rdb.SetNX(ctx, key, holder, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	renewCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			renewed, err := renewLockScript.Run(renewCtx, l.rdb, []string{key}, holder, ttl.Milliseconds()).Int()
+			if renewCtx.Err() != nil {
+				return
+			}
+			if err != nil || renewed == 0 {
+				log.Printf("锁续期失败 - Key: %s, Error: %v", key, err)
+			}
+		}
+	}()
+
+	unlock := func() {
+		stop()
+		<-done
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := releaseLockScript.Run(ctx, l.rdb, []string{key}, holder).Err(); err != nil {
+			log.Printf("释放锁失败 - Key: %s, Error: %v", key, err)
+		}
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisLocker(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := NewRedisLocker(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	b := NewRedisLocker(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+
+	unlock, ok, err := a.TryLock(ctx, "lock", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, ok, err = b.TryLock(ctx, "lock", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "锁已被其他实例持有")
+
+	// 过期后被其他实例获取的锁不会被原持有者释放
+	mr.FastForward(2 * time.Minute)
+	unlockB, ok, err := b.TryLock(ctx, "lock", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	unlock()
+	assert.True(t, mr.Exists("lock"))
+
+	unlockB()
+	assert.False(t, mr.Exists("lock"))
+}
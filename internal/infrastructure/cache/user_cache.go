@@ -15,6 +15,9 @@ type RedisUserCache struct {
 	rdb *redis.Client
 }
 
+// cachedUser 缓存中保存的用户信息，与 entity.User 字段相同但序列化时不脱敏
+type cachedUser entity.User
+
 func NewRedisUserCache(rdb *redis.Client) *RedisUserCache {
 	return &RedisUserCache{rdb: rdb}
 }
@@ -69,7 +72,8 @@ func (c *RedisUserCache) SetUser(ctx context.Context, user *entity.User) error {
 	// 设置两个缓存键，一个用ID索引，一个用用户名索引
 	idKey := fmt.Sprintf("user:id:%d", user.ID)
 	usernameKey := fmt.Sprintf("user:username:%s", user.Username)
-	data, err := json.Marshal(user)
+	// 缓存需要保存明文，不使用 entity.User 序列化时的脱敏
+	data, err := json.Marshal((*cachedUser)(user))
 	if err != nil {
 		return err
 	}
@@ -7,11 +7,15 @@ import (
 )
 
 type Config struct {
-	Server config.ServerConfig `mapstructure:"server"`
-	MySQL  config.MySQLConfig  `mapstructure:"mysql"`
-	Redis  config.RedisConfig  `mapstructure:"redis"`
-	JWT    config.JWTConfig    `mapstructure:"jwt"`
-	Export config.ExportConfig `mapstructure:"export"`
+	Server       config.ServerConfig       `mapstructure:"server"`
+	MySQL        config.MySQLConfig        `mapstructure:"mysql"`
+	Redis        config.RedisConfig        `mapstructure:"redis"`
+	JWT          config.JWTConfig          `mapstructure:"jwt"`
+	Export       config.ExportConfig       `mapstructure:"export"`
+	Mask         config.MaskConfig         `mapstructure:"mask"`
+	Message      config.MessageConfig      `mapstructure:"message"`
+	OperationLog config.OperationLogConfig `mapstructure:"operation_log"`
+	Audit        config.AuditConfig        `mapstructure:"audit"`
 }
 
 func LoadConfig(file string) (*Config, error) {
@@ -1,10 +1,12 @@
 package database
 
 import (
+	"encoding/json"
 	"fmt"
 	"gva/internal/domain/entity"
 	"gva/internal/pkg/utils"
 	"log"
+	"strconv"
 
 	"gorm.io/gorm"
 )
@@ -90,6 +92,20 @@ func InitData(db *gorm.DB) error {
 			Status:      1,
 			Description: "导入用户数据",
 		},
+		{
+			Name:        "查看用户手机号",
+			Code:        "system:user:field:phone",
+			Type:        "data",
+			Status:      1,
+			Description: "查看未脱敏的用户手机号",
+		},
+		{
+			Name:        "查看用户邮箱",
+			Code:        "system:user:field:email",
+			Type:        "data",
+			Status:      1,
+			Description: "查看未脱敏的用户邮箱",
+		},
 
 		// 权限管理权限
 		{
@@ -172,6 +188,193 @@ func InitData(db *gorm.DB) error {
 			Description: "为角色分配权限",
 		},
 
+		// 部门管理权限
+		{
+			Name:        "部门管理",
+			Code:        "system:dept",
+			Type:        "menu",
+			Status:      1,
+			Description: "部门管理菜单",
+		},
+		{
+			Name:        "查看部门",
+			Code:        "system:dept:list",
+			Type:        "button",
+			Status:      1,
+			Description: "查看部门树",
+		},
+		{
+			Name:        "创建部门",
+			Code:        "system:dept:create",
+			Type:        "button",
+			Status:      1,
+			Description: "创建新部门",
+		},
+		{
+			Name:        "更新部门",
+			Code:        "system:dept:update",
+			Type:        "button",
+			Status:      1,
+			Description: "更新部门信息或移动部门",
+		},
+		{
+			Name:        "删除部门",
+			Code:        "system:dept:delete",
+			Type:        "button",
+			Status:      1,
+			Description: "删除部门",
+		},
+
+		// 岗位管理权限
+		{
+			Name:        "岗位管理",
+			Code:        "system:position",
+			Type:        "menu",
+			Status:      1,
+			Description: "岗位管理菜单",
+		},
+		{
+			Name:        "查看岗位",
+			Code:        "system:position:list",
+			Type:        "button",
+			Status:      1,
+			Description: "查看岗位列表",
+		},
+		{
+			Name:        "创建岗位",
+			Code:        "system:position:create",
+			Type:        "button",
+			Status:      1,
+			Description: "创建新岗位",
+		},
+		{
+			Name:        "更新岗位",
+			Code:        "system:position:update",
+			Type:        "button",
+			Status:      1,
+			Description: "更新岗位信息",
+		},
+		{
+			Name:        "删除岗位",
+			Code:        "system:position:delete",
+			Type:        "button",
+			Status:      1,
+			Description: "删除岗位",
+		},
+
+		// 数据字典权限
+		{
+			Name:        "字典管理",
+			Code:        "system:dict",
+			Type:        "menu",
+			Status:      1,
+			Description: "数据字典管理菜单",
+		},
+		{
+			Name:        "查看字典",
+			Code:        "system:dict:list",
+			Type:        "button",
+			Status:      1,
+			Description: "查看字典及字典项",
+		},
+		{
+			Name:        "创建字典",
+			Code:        "system:dict:create",
+			Type:        "button",
+			Status:      1,
+			Description: "创建字典或字典项",
+		},
+		{
+			Name:        "更新字典",
+			Code:        "system:dict:update",
+			Type:        "button",
+			Status:      1,
+			Description: "更新字典、字典项或启用/禁用字典项",
+		},
+		{
+			Name:        "删除字典",
+			Code:        "system:dict:delete",
+			Type:        "button",
+			Status:      1,
+			Description: "删除字典或字典项",
+		},
+
+		// 通知管理权限
+		{
+			Name:        "通知管理",
+			Code:        "system:notification",
+			Type:        "menu",
+			Status:      1,
+			Description: "通知管理菜单",
+		},
+		{
+			Name:        "查看通知",
+			Code:        "system:notification:list",
+			Type:        "button",
+			Status:      1,
+			Description: "查看已发送的通知",
+		},
+		{
+			Name:        "发送通知",
+			Code:        "system:notification:send",
+			Type:        "button",
+			Status:      1,
+			Description: "编写并发送通知",
+		},
+
+		// 消息模板权限
+		{
+			Name:        "消息管理",
+			Code:        "system:message",
+			Type:        "menu",
+			Status:      1,
+			Description: "消息模板与投递记录管理菜单",
+		},
+		{
+			Name:        "管理消息模板",
+			Code:        "system:message:template",
+			Type:        "button",
+			Status:      1,
+			Description: "创建、更新、删除消息模板",
+		},
+		{
+			Name:        "发送消息",
+			Code:        "system:message:send",
+			Type:        "button",
+			Status:      1,
+			Description: "按模板发送消息、重新投递",
+		},
+
+		// 定时任务权限
+		{
+			Name:        "定时任务",
+			Code:        "system:task",
+			Type:        "menu",
+			Status:      1,
+			Description: "定时任务管理菜单",
+		},
+		{
+			Name:        "查看定时任务",
+			Code:        "system:task:list",
+			Type:        "button",
+			Status:      1,
+			Description: "查看定时任务及执行日志",
+		},
+		{
+			Name:        "编辑定时任务",
+			Code:        "system:task:update",
+			Type:        "button",
+			Status:      1,
+			Description: "创建、更新、删除、暂停或恢复定时任务",
+		},
+		{
+			Name:        "执行定时任务",
+			Code:        "system:task:run",
+			Type:        "button",
+			Status:      1,
+			Description: "立即执行一次定时任务",
+		},
+
 		// 日志管理权限
 		{
 			Name:        "日志管理",
@@ -189,7 +392,153 @@ func InitData(db *gorm.DB) error {
 		},
 	}
 
-	// 3. 创建默认管理员用户
+	// 3. 创建默认岗位
+	positions := []entity.Position{
+		{
+			Code:        "ceo",
+			Name:        "董事长",
+			Sort:        1,
+			Status:      1,
+			Description: "公司董事长",
+		},
+		{
+			Code:        "manager",
+			Name:        "部门经理",
+			Sort:        2,
+			Status:      1,
+			Description: "部门负责人",
+		},
+		{
+			Code:        "hr",
+			Name:        "人事专员",
+			Sort:        3,
+			Status:      1,
+			Description: "负责招聘与员工关系",
+		},
+		{
+			Code:        "staff",
+			Name:        "普通员工",
+			Sort:        4,
+			Status:      1,
+			Description: "普通员工",
+		},
+	}
+
+	// 4. 创建默认数据字典，用户状态字典由 AutoMigrate 创建
+	dicts := []entity.Dict{
+		{
+			Name:        "权限类型",
+			Code:        "permission_type",
+			Status:      1,
+			Description: "权限的类型",
+			Items: []entity.DictItem{
+				{Label: "菜单", Value: entity.MenuPermission, Sort: 1, Status: 1},
+				{Label: "按钮", Value: entity.ButtonPermission, Sort: 2, Status: 1},
+				{Label: "数据", Value: entity.DataPermission, Sort: 3, Status: 1},
+			},
+		},
+		{
+			Name:        "通知类型",
+			Code:        entity.NotificationTypeDictCode,
+			Status:      1,
+			Description: "站内通知的类型",
+			Items: []entity.DictItem{
+				{Label: "系统通知", Value: "system", Sort: 1, Status: 1},
+				{Label: "公告", Value: "announcement", Sort: 2, Status: 1},
+				{Label: "消息", Value: "message", Sort: 3, Status: 1},
+			},
+		},
+	}
+
+	// 5. 创建默认消息模板
+	messageTemplates := []entity.MessageTemplate{
+		{
+			Code:             "password_reset",
+			Name:             "密码重置",
+			Channels:         "inapp,email",
+			NotificationType: "system",
+			Status:           1,
+			Description:      "密码被重置时通知用户",
+			Contents: []entity.MessageTemplateContent{
+				{Locale: "zh-CN", Subject: "密码已重置", Body: "{{.Nickname}}，您好：\n您的账号 {{.Username}} 的密码已被重置，如非本人操作请及时联系管理员。"},
+				{Locale: "en-US", Subject: "Your password has been reset", Body: "Hi {{.Nickname}},\nThe password of your account {{.Username}} has been reset. Please contact the administrator if this was not you."},
+			},
+		},
+		{
+			Code:             "account_approved",
+			Name:             "账号审核通过",
+			Channels:         "inapp,email",
+			NotificationType: "system",
+			Status:           1,
+			Description:      "账号审核通过时通知用户",
+			Contents: []entity.MessageTemplateContent{
+				{Locale: "zh-CN", Subject: "账号审核通过", Body: "{{.Nickname}}，您好：\n您的账号 {{.Username}} 已审核通过，现在可以登录使用。"},
+				{Locale: "en-US", Subject: "Your account has been approved", Body: "Hi {{.Nickname}},\nYour account {{.Username}} has been approved. You can sign in now."},
+			},
+		},
+		{
+			Code:             "account_locked",
+			Name:             "账号锁定",
+			Channels:         "inapp,email",
+			NotificationType: "system",
+			Status:           1,
+			Description:      "账号被冻结时通知用户",
+			Contents: []entity.MessageTemplateContent{
+				{Locale: "zh-CN", Subject: "账号已被冻结", Body: "{{.Nickname}}，您好：\n您的账号 {{.Username}} 已被冻结，如有疑问请联系管理员。"},
+				{Locale: "en-US", Subject: "Your account has been locked", Body: "Hi {{.Nickname}},\nYour account {{.Username}} has been locked. Please contact the administrator if you have any questions."},
+			},
+		},
+		{
+			Code:             "announcement",
+			Name:             "系统公告",
+			Channels:         "inapp",
+			NotificationType: "announcement",
+			Status:           1,
+			Description:      "通用公告模板，使用变量 Title 和 Content",
+			Contents: []entity.MessageTemplateContent{
+				{Locale: "zh-CN", Subject: "{{.Title}}", Body: "{{.Content}}"},
+				{Locale: "en-US", Subject: "{{.Title}}", Body: "{{.Content}}"},
+			},
+		},
+	}
+
+	// 6. 创建默认定时任务
+	tasks := []entity.Task{
+		{
+			Name:        "归档操作日志",
+			Cron:        "0 0 3 * * *",
+			Handler:     "archive_operation_logs",
+			Params:      json.RawMessage(`{}`),
+			Status:      1,
+			Description: "每天凌晨3点将超过保留天数的操作日志归档后删除，保留天数见配置 operation_log.retention.days",
+		},
+		{
+			Name:        "清理任务日志",
+			Cron:        "0 30 3 * * *",
+			Handler:     "purge_task_logs",
+			Params:      json.RawMessage(`{"days":30}`),
+			Status:      1,
+			Description: "每天凌晨3点半删除30天前的定时任务执行日志",
+		},
+		{
+			Name:        "清理登录日志",
+			Cron:        "0 45 3 * * *",
+			Handler:     "purge_login_logs",
+			Params:      json.RawMessage(`{"days":180}`),
+			Status:      1,
+			Description: "每天凌晨3点45分删除180天前的登录日志",
+		},
+		{
+			Name:        "签名审计检查点",
+			Cron:        "0 0 * * * *",
+			Handler:     "checkpoint_audit_events",
+			Params:      json.RawMessage(`{}`),
+			Status:      1,
+			Description: "每小时对审计事件哈希链的链头签名，需要配置 audit.hmac_key",
+		},
+	}
+
+	// 7. 创建默认管理员用户
 	hashedPassword, err := utils.HashPassword("123456")
 	if err != nil {
 		return fmt.Errorf("密码加密失败: %v", err)
@@ -204,7 +553,7 @@ func InitData(db *gorm.DB) error {
 		RoleID:   1, // 超级管理员角色
 	}
 
-	// 4. 执行数据初始化
+	// 8. 执行数据初始化
 	return db.Transaction(func(tx *gorm.DB) error {
 		log.Println("创建角色...")
 		if err := tx.Create(&roles).Error; err != nil {
@@ -221,6 +570,29 @@ func InitData(db *gorm.DB) error {
 			return fmt.Errorf("分配权限失败: %v", err)
 		}
 
+		log.Println("创建岗位...")
+		if err := tx.Create(&positions).Error; err != nil {
+			return fmt.Errorf("创建岗位失败: %v", err)
+		}
+
+		log.Println("创建数据字典...")
+		if err := seedUserStatusDict(tx); err != nil {
+			return err
+		}
+		if err := tx.Create(&dicts).Error; err != nil {
+			return fmt.Errorf("创建数据字典失败: %v", err)
+		}
+
+		log.Println("创建消息模板...")
+		if err := tx.Create(&messageTemplates).Error; err != nil {
+			return fmt.Errorf("创建消息模板失败: %v", err)
+		}
+
+		log.Println("创建定时任务...")
+		if err := tx.Create(&tasks).Error; err != nil {
+			return fmt.Errorf("创建定时任务失败: %v", err)
+		}
+
 		log.Println("创建管理员用户...")
 		if err := tx.Create(&adminUser).Error; err != nil {
 			return fmt.Errorf("创建管理员用户失败: %v", err)
@@ -230,3 +602,37 @@ func InitData(db *gorm.DB) error {
 		return nil
 	})
 }
+
+// seedUserStatusDict 创建用户状态字典，并补充缺少的字典项，已有的字典项不修改
+// 修改用户状态时按该字典校验取值，字典不存在时无法修改任何用户的状态，因此在每次迁移时执行
+func seedUserStatusDict(db *gorm.DB) error {
+	items := []entity.DictItem{
+		{Label: "禁用", Value: strconv.Itoa(entity.UserStatusDisabled), Sort: 1, Status: 1},
+		{Label: "正常", Value: strconv.Itoa(entity.UserStatusNormal), Sort: 2, Status: 1},
+		{Label: "冻结", Value: strconv.Itoa(entity.UserStatusFrozen), Sort: 3, Status: 1},
+		{Label: "待审核", Value: strconv.Itoa(entity.UserStatusPending), Sort: 4, Status: 1},
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 编码有唯一索引，已软删除的字典同样占用编码
+		dict := entity.Dict{Name: "用户状态", Code: entity.UserStatusDictCode, Status: 1, Description: "用户账号状态"}
+		if err := tx.Unscoped().Where("code = ?", dict.Code).FirstOrCreate(&dict).Error; err != nil {
+			return fmt.Errorf("创建用户状态字典失败: %v", err)
+		}
+
+		for _, item := range items {
+			var count int64
+			if err := tx.Model(&entity.DictItem{}).Where("dict_id = ? AND value = ?", dict.ID, item.Value).Count(&count).Error; err != nil {
+				return fmt.Errorf("查询用户状态字典项失败: %v", err)
+			}
+			if count > 0 {
+				continue
+			}
+			item.DictID = dict.ID
+			if err := tx.Create(&item).Error; err != nil {
+				return fmt.Errorf("创建用户状态字典项失败: %v", err)
+			}
+		}
+		return nil
+	})
+}
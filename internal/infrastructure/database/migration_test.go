@@ -0,0 +1,45 @@
+package database
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gva/internal/domain/entity"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 迁移时创建用户状态字典，重复迁移只补充缺少的字典项
+func TestAutoMigrateSeedsUserStatusDict(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, AutoMigrate(db))
+
+	labels := func() map[string]string {
+		var dict entity.Dict
+		require.NoError(t, db.Preload("Items").Where("code = ?", entity.UserStatusDictCode).First(&dict).Error)
+		labels := make(map[string]string, len(dict.Items))
+		for _, item := range dict.Items {
+			labels[item.Value] = item.Label
+		}
+		return labels
+	}
+	assert.Equal(t, map[string]string{
+		strconv.Itoa(entity.UserStatusDisabled): "禁用",
+		strconv.Itoa(entity.UserStatusNormal):   "正常",
+		strconv.Itoa(entity.UserStatusFrozen):   "冻结",
+		strconv.Itoa(entity.UserStatusPending):  "待审核",
+	}, labels())
+
+	// 修改过的字典项保留，删除的字典项重新补充
+	require.NoError(t, db.Model(&entity.DictItem{}).Where("value = ?", "1").Update("label", "启用").Error)
+	require.NoError(t, db.Unscoped().Where("value = ?", "3").Delete(&entity.DictItem{}).Error)
+	require.NoError(t, AutoMigrate(db))
+	assert.Equal(t, map[string]string{"0": "禁用", "1": "启用", "2": "冻结", "3": "待审核"}, labels())
+}
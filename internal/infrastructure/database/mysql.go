@@ -17,7 +17,10 @@ func NewMySQLDB(cfg *config.MySQLConfig) (*gorm.DB, error) {
 		cfg.Database,
 	)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		// 用户允许不设置角色（role_id = 0），迁移时不创建外键约束
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
 	if err != nil {
 		return nil, err
 	}
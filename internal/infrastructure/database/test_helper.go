@@ -1,6 +1,8 @@
 package database
 
 import (
+	"fmt"
+	"gva/internal/domain/entity"
 	"gva/internal/pkg/config"
 	"log"
 
@@ -34,14 +36,96 @@ func InitTestDB() *gorm.DB {
 
 // AutoMigrate 自动迁移数据库
 func AutoMigrate(db *gorm.DB) error {
+	// 通知与接收者的关联表使用自定义模型，需要在迁移前注册
+	if err := db.SetupJoinTable(&entity.Notification{}, "Receivers", &entity.UserNotification{}); err != nil {
+		return err
+	}
+
 	// 在这里添加需要迁移的模型
-	return nil
+	err := db.AutoMigrate(
+		&entity.Role{},
+		&entity.Permission{},
+		&entity.Position{},
+		&entity.User{},
+		&entity.Department{},
+		&entity.Dict{},
+		&entity.DictItem{},
+		&entity.Notification{},
+		&entity.UserNotification{},
+		&entity.MessageTemplate{},
+		&entity.MessageTemplateContent{},
+		&entity.MessageDelivery{},
+		&entity.OperationLog{},
+		&entity.LoginLog{},
+		&entity.AuditEvent{},
+		&entity.AuditChainHead{},
+		&entity.AuditCheckpoint{},
+		&entity.Task{},
+		&entity.TaskLog{},
+	)
+	if err != nil {
+		return err
+	}
+
+	// 定时任务改为引用注册的任务类型，不再执行 shell 命令，移除旧的 command 列
+	if db.Migrator().HasColumn(&entity.Task{}, "command") {
+		if err := db.Migrator().DropColumn(&entity.Task{}, "command"); err != nil {
+			return err
+		}
+	}
+
+	if err := seedUserStatusDict(db); err != nil {
+		return err
+	}
+
+	return initAuditChain(db)
+}
+
+// initAuditChain 创建审计事件哈希链的链头，并将启用哈希链之前的审计事件按ID顺序接入链中
+// 链头不存在但已有事件接入哈希链时拒绝启动：重新计算哈希会掩盖对事件的修改和删除
+func initAuditChain(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&entity.AuditChainHead{}).Where("name = ?", entity.AuditChainEvents).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var chained int64
+	if err := db.Model(&entity.AuditEvent{}).Where("hash <> ''").Count(&chained).Error; err != nil {
+		return err
+	}
+	if chained > 0 {
+		return fmt.Errorf("审计链头不存在，但已有 %d 条审计事件接入哈希链，链头可能被删除，请使用 audit-verify 校验哈希链", chained)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		head := entity.AuditChainHead{Name: entity.AuditChainEvents}
+		var events []entity.AuditEvent
+		err := tx.Order("id").FindInBatches(&events, 1000, func(_ *gorm.DB, _ int) error {
+			for i := range events {
+				event := &events[i]
+				event.PrevHash = head.LastHash
+				event.Hash = event.ComputeHash()
+				if err := tx.Model(event).Updates(map[string]interface{}{"prev_hash": event.PrevHash, "hash": event.Hash}).Error; err != nil {
+					return err
+				}
+				head.LastID, head.LastHash = event.ID, event.Hash
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&head).Error
+	})
 }
 
 // CleanTestDB 清理测试数据库
 func CleanTestDB(db *gorm.DB) {
 	// 清理所有表数据
-	tables := []string{"users", "roles", "permissions", "role_permissions", "operation_logs"}
+	tables := []string{"users", "roles", "permissions", "role_permissions", "operation_logs", "departments", "positions", "user_positions", "dicts", "dict_items", "notifications", "user_notifications", "message_templates", "message_template_contents", "message_deliveries", "tasks", "task_logs", "login_logs", "audit_events", "audit_chain_heads", "audit_checkpoints"}
 	for _, table := range tables {
 		db.Exec("TRUNCATE TABLE " + table)
 	}
@@ -0,0 +1,123 @@
+package message
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/message"
+	"gva/internal/pkg/config"
+)
+
+const (
+	smtpDialTimeout = 10 * time.Second // 连接邮件服务器的超时时间
+	smtpTimeout     = 30 * time.Second // 发送一封邮件的总超时时间，ctx 的截止时间更早时以 ctx 为准
+)
+
+// SMTPSender 通过SMTP发送邮件
+type SMTPSender struct {
+	cfg config.SMTPConfig
+}
+
+func NewSMTPSender(cfg config.SMTPConfig) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
+}
+
+func (s *SMTPSender) Channel() string {
+	return entity.MessageChannelEmail
+}
+
+func (s *SMTPSender) Recipient(user *entity.User) string {
+	return user.Email
+}
+
+// Send 发送邮件，连接和整个发送过程受 smtpTimeout 和 ctx 的截止时间限制，
+// 避免邮件服务器无响应时阻塞投递协程
+func (s *SMTPSender) Send(ctx context.Context, msg *message.Message) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	data := buildMail(s.cfg.From, msg.Recipient, msg.Subject, msg.Body)
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("连接邮件服务器失败: %v", err)
+	}
+	// net/smtp 不支持 ctx，通过连接的截止时间限制后续的读写，ctx 提前取消时关闭连接
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("连接邮件服务器失败: %v", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// 465 端口使用隐式TLS
+	if s.cfg.Port == 465 {
+		conn = tls.Client(conn, &tls.Config{ServerName: s.cfg.Host})
+	}
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接邮件服务器失败: %v", err)
+	}
+	defer client.Close()
+
+	// 其他端口在服务器支持时升级为 STARTTLS，与 smtp.SendMail 一致
+	if s.cfg.Port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+				return fmt.Errorf("连接邮件服务器失败: %v", err)
+			}
+		}
+	}
+
+	if s.cfg.Username != "" {
+		auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("邮件服务器认证失败: %v", err)
+		}
+	}
+	if err := client.Mail(s.cfg.From); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := client.Rcpt(msg.Recipient); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	return client.Quit()
+}
+
+// buildMail 组装纯文本邮件，主题按 RFC 2047 编码以支持中文
+func buildMail(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,101 @@
+package message
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gva/internal/domain/message"
+	"gva/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSMTPServer 启动一个只接收一个连接的SMTP服务器，handle 处理该连接
+func newTestSMTPServer(t *testing.T, handle func(conn net.Conn)) config.SMTPConfig {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return config.SMTPConfig{Host: host, Port: portNum, From: "noreply@example.com"}
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	received := make(chan string, 1)
+	cfg := newTestSMTPServer(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 end with .")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	})
+
+	sender := NewSMTPSender(cfg)
+	err := sender.Send(context.Background(), &message.Message{Recipient: "alice@example.com", Subject: "你好", Body: "hello"})
+	require.NoError(t, err)
+	select {
+	case data := <-received:
+		assert.Contains(t, data, "To: alice@example.com")
+		assert.Contains(t, data, "hello")
+	case <-time.After(time.Second):
+		t.Fatal("服务器未收到邮件")
+	}
+}
+
+// 邮件服务器无响应时，发送在 ctx 的截止时间返回
+func TestSMTPSenderSendTimeout(t *testing.T) {
+	cfg := newTestSMTPServer(t, func(conn net.Conn) {
+		// 不发送问候，直到客户端断开
+		conn.Read(make([]byte, 1))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := NewSMTPSender(cfg).Send(ctx, &message.Message{Recipient: "alice@example.com", Subject: "hi", Body: "hello"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
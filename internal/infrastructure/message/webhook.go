@@ -0,0 +1,88 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/message"
+	"gva/internal/pkg/config"
+)
+
+// WebhookSender 将消息以JSON格式POST到配置的地址
+type WebhookSender struct {
+	cfg    config.WebhookConfig
+	client *http.Client
+}
+
+func NewWebhookSender(cfg config.WebhookConfig) *WebhookSender {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &WebhookSender{
+		cfg:    cfg,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *WebhookSender) Channel() string {
+	return entity.MessageChannelWebhook
+}
+
+func (s *WebhookSender) Recipient(user *entity.User) string {
+	return s.cfg.URL
+}
+
+// webhookPayload Webhook 请求体
+type webhookPayload struct {
+	Template string `json:"template"`
+	UserID   uint   `json:"user_id"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+	Time     int64  `json:"time"`
+}
+
+func (s *WebhookSender) Send(ctx context.Context, msg *message.Message) error {
+	payload, err := json.Marshal(webhookPayload{
+		Template: msg.TemplateCode,
+		UserID:   msg.UserID,
+		Subject:  msg.Subject,
+		Body:     msg.Body,
+		Time:     time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("序列化Webhook请求失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Recipient, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("创建Webhook请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.cfg.Secret))
+		mac.Write(payload)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Webhook请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook返回异常状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package push
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait 写入消息的超时时间
+	writeWait = 10 * time.Second
+	// pongWait 等待客户端响应心跳的时间，超时视为连接断开
+	pongWait = 60 * time.Second
+	// pingPeriod 发送心跳的间隔，必须小于 pongWait
+	pingPeriod = pongWait * 9 / 10
+	// maxMessageSize 客户端消息的最大长度，客户端只需要发送心跳
+	maxMessageSize = 512
+	// sendBufferSize 每个连接的待发送消息缓冲区大小
+	sendBufferSize = 64
+)
+
+// outbound 待发送的消息，closeAfter 为 true 时发送后关闭连接（如强制下线）
+type outbound struct {
+	data       []byte
+	closeAfter bool
+}
+
+// client 一个WebSocket连接
+type client struct {
+	conn   *websocket.Conn
+	userID uint
+	send   chan outbound
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newClient(conn *websocket.Conn, userID uint) *client {
+	return &client{
+		conn:   conn,
+		userID: userID,
+		send:   make(chan outbound, sendBufferSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// enqueue 将消息放入发送队列，队列已满说明客户端过慢，直接断开由客户端重连补发
+func (c *client) enqueue(data []byte, closeAfter bool) {
+	select {
+	case <-c.done:
+	case c.send <- outbound{data: data, closeAfter: closeAfter}:
+	default:
+		c.close()
+	}
+}
+
+// enqueueWait 等待发送队列有空位后放入消息，用于补发离线期间的消息，连接关闭或 ctx 结束时返回 false
+func (c *client) enqueueWait(ctx context.Context, data []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	case <-ctx.Done():
+		return false
+	case c.send <- outbound{data: data}:
+		return true
+	}
+}
+
+func (c *client) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// readPump 读取客户端消息以处理心跳响应和连接关闭
+func (c *client) readPump() {
+	defer c.close()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		msgType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		// 兼容无法发送控制帧的客户端，使用文本消息 ping 做应用层心跳
+		if msgType == websocket.TextMessage && string(data) == "ping" {
+			c.enqueue([]byte(`{"type":"pong"}`), false)
+		}
+	}
+}
+
+// writePump 发送队列中的消息并定时发送心跳
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg.data); err != nil {
+				return
+			}
+			if msg.closeAfter {
+				c.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "force logout"),
+					time.Now().Add(writeWait))
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
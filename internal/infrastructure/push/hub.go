@@ -0,0 +1,243 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gva/internal/domain/push"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/websocket"
+)
+
+// pushChannel 多实例之间转发推送消息的Redis频道
+const pushChannel = "push:events"
+
+// envelope 在Redis频道中传递的消息，UserID 为 0 表示广播
+type envelope struct {
+	UserID  uint          `json:"user_id"`
+	Message *push.Message `json:"message"`
+}
+
+// Hub 管理本实例上的WebSocket连接，并通过Redis发布订阅在多个实例之间分发消息
+type Hub struct {
+	rdb   *redis.Client
+	store messageStore
+
+	mu      sync.RWMutex
+	clients map[uint]map[*client]struct{}
+}
+
+// NewHub 创建推送中心，rdb 为空时只在当前实例内推送
+func NewHub(rdb *redis.Client) *Hub {
+	h := &Hub{
+		rdb:     rdb,
+		clients: make(map[uint]map[*client]struct{}),
+	}
+	if rdb != nil {
+		h.store = &redisStore{rdb: rdb}
+	} else {
+		h.store = newMemoryStore()
+	}
+	return h
+}
+
+// Run 订阅Redis频道，将其他实例发布的消息投递给本实例的连接，直到 ctx 结束
+func (h *Hub) Run(ctx context.Context) {
+	if h.rdb == nil {
+		<-ctx.Done()
+		h.closeAll()
+		return
+	}
+
+	for {
+		h.subscribe(ctx)
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case <-time.After(time.Second):
+			// 订阅断开后稍后重试
+		}
+	}
+}
+
+func (h *Hub) subscribe(ctx context.Context) {
+	pubsub := h.rdb.Subscribe(ctx, pushChannel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("订阅推送频道失败: %v", err)
+		}
+		return
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var env envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil || env.Message == nil {
+				log.Printf("解析推送消息失败: %v", err)
+				continue
+			}
+			h.deliver(env.UserID, env.Message)
+		}
+	}
+}
+
+// Publish 推送消息给指定用户
+func (h *Hub) Publish(ctx context.Context, userID uint, eventType string, data interface{}) error {
+	return h.publish(ctx, userID, eventType, data)
+}
+
+// Broadcast 推送消息给所有在线用户
+func (h *Hub) Broadcast(ctx context.Context, eventType string, data interface{}) error {
+	return h.publish(ctx, 0, eventType, data)
+}
+
+func (h *Hub) publish(ctx context.Context, userID uint, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("序列化推送数据失败: %v", err)
+	}
+
+	id, err := h.store.NextID(ctx)
+	if err != nil {
+		return fmt.Errorf("生成推送消息ID失败: %v", err)
+	}
+
+	msg := &push.Message{
+		ID:   id,
+		Type: eventType,
+		Data: payload,
+		Time: time.Now(),
+	}
+	if err := h.store.Save(ctx, userID, msg); err != nil {
+		return fmt.Errorf("保存推送消息失败: %v", err)
+	}
+
+	if h.rdb == nil {
+		h.deliver(userID, msg)
+		return nil
+	}
+
+	env, err := json.Marshal(envelope{UserID: userID, Message: msg})
+	if err != nil {
+		return err
+	}
+	if err := h.rdb.Publish(ctx, pushChannel, env).Err(); err != nil {
+		return fmt.Errorf("发布推送消息失败: %v", err)
+	}
+	return nil
+}
+
+// deliver 将消息投递给本实例上的连接，userID 为 0 时投递给所有连接
+func (h *Hub) deliver(userID uint, msg *push.Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	var targets []*client
+	if userID == 0 {
+		for _, clients := range h.clients {
+			for c := range clients {
+				targets = append(targets, c)
+			}
+		}
+	} else {
+		for c := range h.clients[userID] {
+			targets = append(targets, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range targets {
+		c.enqueue(data, msg.Type == push.EventForceLogout)
+	}
+}
+
+// Serve 接管已升级的WebSocket连接，lastID 大于 0 时先补发错过的消息，阻塞直到连接关闭
+func (h *Hub) Serve(ctx context.Context, conn *websocket.Conn, userID uint, lastID int64) {
+	c := newClient(conn, userID)
+	h.register(c)
+	defer h.unregister(c)
+
+	// 先启动发送再补发，补发的消息可能超过发送队列的容量，需要边发送边补发
+	go c.writePump()
+
+	// 先注册再补发，避免补发期间产生的新消息丢失（可能重复，由客户端按ID去重）
+	if lastID > 0 {
+		messages, err := h.store.Since(ctx, userID, lastID)
+		if err != nil {
+			log.Printf("查询待补发的推送消息失败: %v", err)
+		}
+		for _, msg := range messages {
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			if !c.enqueueWait(ctx, data) {
+				break
+			}
+		}
+	}
+
+	c.readPump()
+}
+
+// Online 返回本实例上指定用户的连接数
+func (h *Hub) Online(userID uint) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID])
+}
+
+func (h *Hub) register(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[c.userID] == nil {
+		h.clients[c.userID] = make(map[*client]struct{})
+	}
+	h.clients[c.userID][c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	if clients, ok := h.clients[c.userID]; ok {
+		delete(clients, c)
+		if len(clients) == 0 {
+			delete(h.clients, c.userID)
+		}
+	}
+	h.mu.Unlock()
+	c.close()
+}
+
+// closeAll 关闭本实例上的所有连接
+func (h *Hub) closeAll() {
+	h.mu.RLock()
+	var all []*client
+	for _, clients := range h.clients {
+		for c := range clients {
+			all = append(all, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range all {
+		c.close()
+	}
+}
@@ -0,0 +1,68 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gva/internal/domain/push"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 补发的消息超过发送队列容量时，应全部送达且不断开连接
+func TestServeReplayExceedsSendBuffer(t *testing.T) {
+	ctx := context.Background()
+	hub := NewHub(nil)
+
+	const userID = 7
+	require.NoError(t, hub.Publish(ctx, userID, push.EventNotification, "seen"))
+	for i := 0; i < backlogSize; i++ {
+		require.NoError(t, hub.Publish(ctx, userID, push.EventNotification, i))
+		require.NoError(t, hub.Broadcast(ctx, push.EventNotification, i))
+	}
+	replay := 2 * backlogSize
+	require.Greater(t, replay, sendBufferSize)
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		hub.Serve(r.Context(), conn, userID, 1)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	readMessage := func() *push.Message {
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		var msg push.Message
+		require.NoError(t, json.Unmarshal(data, &msg))
+		return &msg
+	}
+
+	lastID := int64(1)
+	for i := 0; i < replay; i++ {
+		msg := readMessage()
+		assert.Greater(t, msg.ID, lastID, "补发的消息按ID升序")
+		lastID = msg.ID
+	}
+
+	// 补发结束后连接仍然可用
+	require.NoError(t, hub.Publish(ctx, userID, push.EventUnreadCount, map[string]int{"unread": 1}))
+	msg := readMessage()
+	assert.Equal(t, push.EventUnreadCount, msg.Type)
+	assert.Equal(t, 1, hub.Online(userID))
+}
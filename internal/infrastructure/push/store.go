@@ -0,0 +1,132 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gva/internal/domain/push"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// backlogSize 每个用户（以及广播）保留的最近消息数，用于重连补发
+	backlogSize = 100
+	// backlogTTL 积压消息的保留时间
+	backlogTTL = 24 * time.Hour
+)
+
+// messageStore 负责分配消息ID并保存最近的消息，供客户端重连时补发
+type messageStore interface {
+	NextID(ctx context.Context) (int64, error)
+	Save(ctx context.Context, userID uint, msg *push.Message) error
+	// Since 返回ID大于 lastID 的消息（包括该用户的消息和广播消息），按ID升序
+	Since(ctx context.Context, userID uint, lastID int64) ([]*push.Message, error)
+}
+
+// redisStore 基于Redis的消息存储，多个实例共享消息ID和积压消息
+type redisStore struct {
+	rdb *redis.Client
+}
+
+func backlogKey(userID uint) string {
+	if userID == 0 {
+		return "push:backlog:all"
+	}
+	return fmt.Sprintf("push:backlog:user:%d", userID)
+}
+
+func (s *redisStore) NextID(ctx context.Context) (int64, error) {
+	return s.rdb.Incr(ctx, "push:seq").Result()
+}
+
+func (s *redisStore) Save(ctx context.Context, userID uint, msg *push.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	key := backlogKey(userID)
+	pipe := s.rdb.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(msg.ID), Member: data})
+	pipe.ZRemRangeByRank(ctx, key, 0, -backlogSize-1)
+	pipe.Expire(ctx, key, backlogTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *redisStore) Since(ctx context.Context, userID uint, lastID int64) ([]*push.Message, error) {
+	var messages []*push.Message
+	for _, key := range []string{backlogKey(userID), backlogKey(0)} {
+		members, err := s.rdb.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+			Min: "(" + strconv.FormatInt(lastID, 10),
+			Max: "+inf",
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			var msg push.Message
+			if err := json.Unmarshal([]byte(member), &msg); err != nil {
+				continue
+			}
+			messages = append(messages, &msg)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+// memoryStore 进程内的消息存储，用于未配置Redis的单实例部署
+type memoryStore struct {
+	mu      sync.Mutex
+	seq     int64
+	backlog map[uint][]*push.Message
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{backlog: make(map[uint][]*push.Message)}
+}
+
+func (s *memoryStore) NextID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return s.seq, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, userID uint, msg *push.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := append(s.backlog[userID], msg)
+	if len(messages) > backlogSize {
+		messages = messages[len(messages)-backlogSize:]
+	}
+	s.backlog[userID] = messages
+	return nil
+}
+
+func (s *memoryStore) Since(ctx context.Context, userID uint, lastID int64) ([]*push.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expireBefore := time.Now().Add(-backlogTTL)
+	var messages []*push.Message
+	for _, id := range []uint{userID, 0} {
+		for _, msg := range s.backlog[id] {
+			if msg.ID > lastID && msg.Time.After(expireBefore) {
+				messages = append(messages, msg)
+			}
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
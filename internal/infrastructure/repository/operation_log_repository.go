@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"gva/internal/domain/entity"
+	"gva/internal/domain/repository"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -19,20 +21,73 @@ func (r *operationLogRepository) Create(ctx context.Context, log *entity.Operati
 	return r.db.WithContext(ctx).Create(log).Error
 }
 
-func (r *operationLogRepository) List(ctx context.Context, page, size int) ([]*entity.OperationLog, int64, error) {
+func (r *operationLogRepository) CreateBatch(ctx context.Context, logs []*entity.OperationLog) error {
+	return r.db.WithContext(ctx).Omit("User").CreateInBatches(logs, len(logs)).Error
+}
+
+// operationLogSortColumns 允许排序的字段
+var operationLogSortColumns = map[string]string{
+	"created_at": "created_at",
+	"latency":    "latency",
+	"status":     "status",
+}
+
+func (r *operationLogRepository) List(ctx context.Context, page, size int, filter repository.OperationLogFilter) ([]*entity.OperationLog, int64, error) {
 	var logs []*entity.OperationLog
 	var total int64
 
-	db := r.db.WithContext(ctx)
+	db := r.db.WithContext(ctx).Model(&entity.OperationLog{})
+
+	// 构建查询条件
+	if filter.UserID != nil {
+		db = db.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Username != "" {
+		db = db.Where("user_id IN (?)", r.db.Model(&entity.User{}).Select("id").Where("username LIKE ?", "%"+filter.Username+"%"))
+	}
+	if filter.Method != "" {
+		db = db.Where("method = ?", strings.ToUpper(filter.Method))
+	}
+	if filter.PathPrefix != "" {
+		db = db.Where("path LIKE ? ESCAPE '!'", escapeLike(filter.PathPrefix)+"%")
+	}
+	if filter.StatusMin != nil {
+		db = db.Where("status >= ?", *filter.StatusMin)
+	}
+	if filter.StatusMax != nil {
+		db = db.Where("status <= ?", *filter.StatusMax)
+	}
+	if filter.IP != "" {
+		db = db.Where("ip = ?", filter.IP)
+	}
+	if filter.MinLatency != nil {
+		db = db.Where("latency >= ?", *filter.MinLatency)
+	}
+	if filter.StartTime != nil {
+		db = db.Where("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		db = db.Where("created_at < ?", *filter.EndTime)
+	}
 
 	// 统计总数
-	if err := db.Model(&entity.OperationLog{}).Count(&total).Error; err != nil {
+	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
+	// 排序，相同值按ID排序保证分页稳定
+	direction := " ASC"
+	if filter.SortDesc {
+		direction = " DESC"
+	}
+	order := "id" + direction
+	if column, ok := operationLogSortColumns[filter.SortBy]; ok {
+		order = column + direction + ", " + order
+	}
+
 	// 获取分页数据
 	err := db.Preload("User"). // 预加载用户信息
-					Order("id DESC").
+					Order(order).
 					Offset((page - 1) * size).
 					Limit(size).
 					Find(&logs).Error
@@ -43,3 +98,8 @@ func (r *operationLogRepository) List(ctx context.Context, page, size int) ([]*e
 
 	return logs, total, nil
 }
+
+// escapeLike 转义 LIKE 模式中的通配符，使用 ! 作为转义符，避免依赖数据库对反斜杠的处理
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"gva/internal/domain/entity"
+	"gva/internal/domain/repository"
 
 	"gorm.io/gorm"
 )
@@ -44,19 +46,41 @@ func (r *userRepository) FindByUsername(ctx context.Context, username string) (*
 	return &user, nil
 }
 
-func (r *userRepository) List(ctx context.Context, page, size int, keyword string, status *int) ([]*entity.User, int64, error) {
+func (r *userRepository) List(ctx context.Context, page, size int, filter repository.UserFilter) ([]*entity.User, int64, error) {
 	var users []*entity.User
 	var total int64
 
 	db := r.db.WithContext(ctx)
 
 	// 构建查询条件
-	if keyword != "" {
+	if keyword := filter.Keyword; keyword != "" {
 		db = db.Where("username LIKE ? OR nickname LIKE ? OR email LIKE ? OR phone LIKE ?",
 			"%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
 	}
-	if status != nil {
-		db = db.Where("status = ?", *status)
+	if filter.Status != nil {
+		db = db.Where("status = ?", *filter.Status)
+	}
+	if filter.RoleID != nil {
+		db = db.Where("role_id = ?", *filter.RoleID)
+	}
+	if filter.DepartmentID != nil {
+		if filter.IncludeSubDepartments {
+			// 通过部门路径匹配所有下级部门
+			var department entity.Department
+			if err := r.db.WithContext(ctx).Select("path").First(&department, *filter.DepartmentID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return []*entity.User{}, 0, nil
+				}
+				return nil, 0, err
+			}
+			subDepartments := r.db.Model(&entity.Department{}).Select("id").Where("path LIKE ?", department.Path+"%")
+			db = db.Where("department_id IN (?)", subDepartments)
+		} else {
+			db = db.Where("department_id = ?", *filter.DepartmentID)
+		}
+	}
+	if filter.PositionID != nil {
+		db = db.Where("id IN (?)", r.db.Table("user_positions").Select("user_id").Where("position_id = ?", *filter.PositionID))
 	}
 
 	// 统计总数
@@ -66,6 +90,8 @@ func (r *userRepository) List(ctx context.Context, page, size int, keyword strin
 
 	// 获取分页数据
 	err := db.Preload("Role"). // 预加载角色信息
+					Preload("Department").
+					Preload("Positions").
 					Offset((page - 1) * size).
 					Limit(size).
 					Order("id DESC").
@@ -80,6 +106,6 @@ func (r *userRepository) List(ctx context.Context, page, size int, keyword strin
 
 func (r *userRepository) FindAll(ctx context.Context) ([]*entity.User, error) {
 	var users []*entity.User
-	err := r.db.WithContext(ctx).Find(&users).Error
+	err := r.db.WithContext(ctx).Preload("Department").Preload("Positions").Find(&users).Error
 	return users, err
 }
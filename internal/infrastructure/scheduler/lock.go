@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// leaderKey 调度主节点的租约，只有持有租约的实例才会按计划执行任务
+	leaderKey = "task:scheduler:leader"
+	// fenceKey 单调递增的 fencing token，每次获得租约时递增
+	fenceKey = "task:scheduler:fence"
+
+	// leaseTTL 租约有效期，持有者宕机后最多经过该时间由其他实例接管
+	leaseTTL = 15 * time.Second
+	// renewInterval 续约（以及未持有租约时尝试获取）的间隔
+	renewInterval = 5 * time.Second
+	// occurrenceTTL 单次触发锁的保留时间，期间同一次触发不会被重复执行
+	occurrenceTTL = 10 * time.Minute
+	// runningLockMargin 执行锁的有效期在任务超时时间之上增加的余量，任务未配置超时时间时即为执行锁的有效期
+	// 执行期间按有效期的三分之一续期，持有锁的实例宕机后最多经过该时间由其他实例执行
+	runningLockMargin = 30 * time.Second
+	// runningPollInterval queue 策略等待其他实例上的执行结束时，重新尝试获取执行锁的间隔
+	runningPollInterval = time.Second
+)
+
+// renewScript 仅当租约仍属于自己时续期
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript 仅当租约仍属于自己时释放
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// lease 当前实例持有的主节点租约
+type lease struct {
+	holder string    // 租约的值：实例ID:token
+	token  int64     // fencing token
+	until  time.Time // 本地认为租约有效的截止时间
+}
+
+// leaderElector 基于Redis租约的主节点选举，rdb 为空时当前实例始终是主节点
+type leaderElector struct {
+	rdb        *redis.Client
+	instanceID string
+
+	mu      sync.RWMutex
+	current *lease
+}
+
+func newLeaderElector(rdb *redis.Client, instanceID string) *leaderElector {
+	return &leaderElector{rdb: rdb, instanceID: instanceID}
+}
+
+// Lease 返回当前有效的租约，未持有租约时返回 nil
+// 单实例部署时返回 token 为 0 的租约
+func (e *leaderElector) Lease() *lease {
+	if e.rdb == nil {
+		return &lease{holder: e.instanceID}
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	// 本地时钟判断租约已过期时，即使Redis中尚未过期也不再视为主节点
+	if e.current == nil || !time.Now().Before(e.current.until) {
+		return nil
+	}
+	l := *e.current
+	return &l
+}
+
+// Run 持续获取或续约租约，直到 ctx 结束后释放租约
+func (e *leaderElector) Run(ctx context.Context) {
+	if e.rdb == nil {
+		return
+	}
+
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	for {
+		e.tick(ctx)
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *leaderElector) tick(ctx context.Context) {
+	e.mu.RLock()
+	current := e.current
+	e.mu.RUnlock()
+
+	start := time.Now()
+	if current != nil {
+		renewed, err := renewScript.Run(ctx, e.rdb, []string{leaderKey}, current.holder, leaseTTL.Milliseconds()).Int()
+		if err == nil && renewed == 1 {
+			e.setLease(&lease{holder: current.holder, token: current.token, until: start.Add(leaseTTL)})
+			return
+		}
+		log.Printf("定时任务主节点租约已失效 - Instance: %s, Token: %d, Error: %v", e.instanceID, current.token, err)
+		e.setLease(nil)
+	}
+
+	// 先递增 token 再尝试获取租约，保证每次获得租约的 token 都比之前的大
+	token, err := e.rdb.Incr(ctx, fenceKey).Result()
+	if err != nil {
+		log.Printf("获取定时任务 fencing token 失败: %v", err)
+		return
+	}
+	holder := e.instanceID + ":" + strconv.FormatInt(token, 10)
+	ok, err := e.rdb.SetNX(ctx, leaderKey, holder, leaseTTL).Result()
+	if err != nil {
+		log.Printf("获取定时任务主节点租约失败: %v", err)
+		return
+	}
+	if ok {
+		e.setLease(&lease{holder: holder, token: token, until: start.Add(leaseTTL)})
+		log.Printf("成为定时任务主节点 - Instance: %s, Token: %d", e.instanceID, token)
+	}
+}
+
+func (e *leaderElector) setLease(l *lease) {
+	e.mu.Lock()
+	e.current = l
+	e.mu.Unlock()
+}
+
+// release 主动释放租约，使其他实例无需等待租约过期即可接管
+func (e *leaderElector) release() {
+	e.mu.Lock()
+	current := e.current
+	e.current = nil
+	e.mu.Unlock()
+	if current == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := releaseScript.Run(ctx, e.rdb, []string{leaderKey}, current.holder).Err(); err != nil {
+		log.Printf("释放定时任务主节点租约失败: %v", err)
+	}
+}
+
+// claimOccurrence 抢占任务的某一次触发，同一次触发只有一个实例能抢占成功
+// 用于防止主节点切换期间新旧主节点重复执行同一次触发
+func (e *leaderElector) claimOccurrence(ctx context.Context, taskID uint, scheduledAt time.Time, holder string) (bool, error) {
+	if e.rdb == nil {
+		return true, nil
+	}
+	key := fmt.Sprintf("task:occurrence:%d:%d", taskID, scheduledAt.Unix())
+	return e.rdb.SetNX(ctx, key, holder, occurrenceTTL).Result()
+}
+
+// lockRunning 获取任务的执行锁，skip 和 queue 策略的任务在所有实例上同一时间只能有一次执行
+// 成功时返回释放函数，持有期间定期续期；锁已被其他执行持有时返回 false
+func (e *leaderElector) lockRunning(ctx context.Context, taskID uint, ttl time.Duration) (func(), bool, error) {
+	if e.rdb == nil {
+		return func() {}, true, nil
+	}
+
+	key := fmt.Sprintf("task:running:%d", taskID)
+	b := make([]byte, 4)
+	rand.Read(b)
+	holder := e.instanceID + ":" + hex.EncodeToString(b)
+	ok, err := e.rdb.SetNX(ctx, key, holder, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	renewCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			renewed, err := renewScript.Run(renewCtx, e.rdb, []string{key}, holder, ttl.Milliseconds()).Int()
+			if renewCtx.Err() != nil {
+				return
+			}
+			if err != nil || renewed == 0 {
+				log.Printf("定时任务执行锁续期失败 - TaskID: %d, Error: %v", taskID, err)
+			}
+		}
+	}()
+
+	release := func() {
+		stop()
+		<-done
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := releaseScript.Run(ctx, e.rdb, []string{key}, holder).Err(); err != nil {
+			log.Printf("释放定时任务执行锁失败 - TaskID: %d, Error: %v", taskID, err)
+		}
+	}
+	return release, true, nil
+}
+
+// newInstanceID 生成实例标识：主机名-进程ID-随机串
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	b := make([]byte, 3)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(b))
+}
@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"gva/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// changeChannel 任务变更通知的Redis频道，任一实例修改任务后通知所有实例重新加载该任务
+	changeChannel = "task:scheduler:changes"
+	// reconcileInterval 与数据库对账的间隔，兜底订阅断开期间丢失的变更通知
+	reconcileInterval = time.Minute
+)
+
+// notify 通知其他实例重新加载任务，rdb 为空时按单实例部署处理，不通知
+func (s *Scheduler) notify(taskID uint) {
+	if s.rdb == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := s.rdb.Publish(ctx, changeChannel, strconv.FormatUint(uint64(taskID), 10)).Err(); err != nil {
+		log.Printf("发布定时任务变更通知失败 - TaskID: %d, Error: %v", taskID, err)
+	}
+}
+
+// watch 接收任务变更通知并重新加载任务，同时定期与数据库对账，直到 ctx 结束
+// 任务只由主节点执行，但任意实例都可能处理修改任务的请求，所有实例的调度表都需要保持一致
+func (s *Scheduler) watch(ctx context.Context) {
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		var retry <-chan time.Time
+		if s.rdb != nil {
+			s.subscribe(ctx, ticker.C)
+			// 订阅断开后稍后重试
+			retry = time.After(time.Second)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reconcileLogged(ctx)
+		case <-retry:
+		}
+	}
+}
+
+func (s *Scheduler) subscribe(ctx context.Context, tick <-chan time.Time) {
+	pubsub := s.rdb.Subscribe(ctx, changeChannel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("订阅定时任务变更通知失败: %v", err)
+		}
+		return
+	}
+	// 订阅建立前的变更不会收到通知，（重新）订阅后先对账一次
+	s.reconcileLogged(ctx)
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			s.reconcileLogged(ctx)
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			taskID, err := strconv.ParseUint(msg.Payload, 10, 64)
+			if err != nil {
+				log.Printf("解析定时任务变更通知失败: %v", err)
+				continue
+			}
+			if err := s.reload(ctx, uint(taskID)); err != nil {
+				log.Printf("重新加载定时任务失败 - TaskID: %d, Error: %v", taskID, err)
+			}
+		}
+	}
+}
+
+// reload 按数据库中的最新配置重新调度当前实例上的任务，不通知其他实例
+func (s *Scheduler) reload(ctx context.Context, taskID uint) error {
+	var task entity.Task
+	err := s.db.WithContext(ctx).First(&task, taskID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.unschedule(taskID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询定时任务失败: %v", err)
+	}
+	if task.Status != 1 {
+		s.unschedule(taskID)
+		return nil
+	}
+	_, err = s.schedule(&task)
+	return err
+}
+
+// reconcile 将当前实例的调度表与数据库中启用的任务对账：添加缺少的任务，
+// 移除已删除或已暂停的任务，cron表达式变化的任务重新调度
+func (s *Scheduler) reconcile(ctx context.Context) error {
+	var tasks []entity.Task
+	if err := s.db.WithContext(ctx).Select("id", "cron").Where("status = ?", 1).Find(&tasks).Error; err != nil {
+		return fmt.Errorf("查询定时任务失败: %v", err)
+	}
+
+	enabled := make(map[uint]bool, len(tasks))
+	for i := range tasks {
+		task := &tasks[i]
+		enabled[task.ID] = true
+		s.mu.Lock()
+		entry, ok := s.entries[task.ID]
+		s.mu.Unlock()
+		if ok && entry.spec == task.Cron {
+			continue
+		}
+		if _, err := s.schedule(task); err != nil {
+			log.Printf("加载定时任务失败 - TaskID: %d, Error: %v", task.ID, err)
+		}
+	}
+
+	s.mu.Lock()
+	var stale []uint
+	for taskID := range s.entries {
+		if !enabled[taskID] {
+			stale = append(stale, taskID)
+		}
+	}
+	s.mu.Unlock()
+	for _, taskID := range stale {
+		s.unschedule(taskID)
+	}
+	return nil
+}
+
+func (s *Scheduler) reconcileLogged(ctx context.Context) {
+	if err := s.reconcile(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("定时任务对账失败: %v", err)
+	}
+}
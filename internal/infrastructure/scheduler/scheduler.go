@@ -0,0 +1,593 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/job"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxResultSize 任务日志中保存的最大执行结果长度
+	maxResultSize = 64 * 1024
+	// defaultRetryInterval 任务未配置重试间隔时，第一次重试前的等待时间
+	defaultRetryInterval = 10 * time.Second
+	// maxRetryDelay 重试等待时间的上限
+	maxRetryDelay = 10 * time.Minute
+)
+
+// Alerter 任务最终执行失败（重试次数已用尽）时发送告警
+type Alerter interface {
+	TaskFailed(ctx context.Context, task *entity.Task, taskLog *entity.TaskLog) error
+}
+
+// parser 解析包含秒的cron表达式，如 "0 */5 * * * *"，也支持 @every 1m、@daily 等描述符
+var parser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseCron 解析cron表达式
+func ParseCron(spec string) (cron.Schedule, error) {
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("无效的cron表达式: %v", err)
+	}
+	return schedule, nil
+}
+
+// Scheduler 定时任务调度器，按 cron 表达式执行启用的任务并记录执行日志
+// 任务通过 Handler 引用注册表中的任务类型，由注册表校验参数并执行
+// 配置了Redis时，所有实例都运行调度器，但只有持有主节点租约的实例会执行按计划触发的任务，
+// 每一次触发还需要抢占触发锁，并通过 fencing token 拒绝租约过期的旧主节点，保证同一次触发只执行一次
+// 任务变更通过Redis通知所有实例重新加载，并定期与数据库对账，保证接替的主节点使用最新的调度
+// skip 和 queue 策略的任务执行前还需要获取Redis执行锁，手动触发和按计划触发在所有实例上同一时间只有一次执行
+type Scheduler struct {
+	db         *gorm.DB
+	rdb        *redis.Client
+	registry   *job.Registry
+	alerter    Alerter
+	cron       *cron.Cron
+	instanceID string
+	elector    *leaderElector
+
+	// 主节点选举和任务变更同步的上下文，停止调度器时取消并释放租约
+	bgCancel context.CancelFunc
+	bg       sync.WaitGroup
+
+	// 任务执行使用的上下文，停止调度器超时后取消，以终止仍在执行的任务
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	entries map[uint]cronEntry
+	running map[uint]chan struct{} // 每个任务的执行槽，用于 skip 和 queue 重叠执行策略
+
+	manual sync.WaitGroup // 正在执行的手动触发任务
+}
+
+// cronEntry 任务在当前实例上的调度，spec 用于对账时判断cron表达式是否变化
+type cronEntry struct {
+	id   cron.EntryID
+	spec string
+}
+
+// New 创建调度器，rdb 为空时按单实例部署处理，不进行主节点选举；alerter 为空时不发送失败告警
+func New(db *gorm.DB, rdb *redis.Client, registry *job.Registry, alerter Alerter) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	instanceID := newInstanceID()
+	return &Scheduler{
+		db:         db,
+		rdb:        rdb,
+		registry:   registry,
+		alerter:    alerter,
+		cron:       cron.New(cron.WithParser(parser), cron.WithChain(cron.Recover(cron.DefaultLogger))),
+		instanceID: instanceID,
+		elector:    newLeaderElector(rdb, instanceID),
+		ctx:        ctx,
+		cancel:     cancel,
+		entries:    make(map[uint]cronEntry),
+		running:    make(map[uint]chan struct{}),
+	}
+}
+
+// InstanceID 返回当前实例的标识
+func (s *Scheduler) InstanceID() string {
+	return s.instanceID
+}
+
+// Start 加载所有启用的任务并开始调度
+func (s *Scheduler) Start(ctx context.Context) error {
+	var tasks []entity.Task
+	if err := s.db.WithContext(ctx).Where("status = ?", 1).Find(&tasks).Error; err != nil {
+		return fmt.Errorf("加载定时任务失败: %v", err)
+	}
+
+	for i := range tasks {
+		next, err := s.schedule(&tasks[i])
+		if err == nil {
+			err = s.updateNextRunTime(tasks[i].ID, &next)
+		}
+		if err != nil {
+			log.Printf("加载定时任务失败 - TaskID: %d, Error: %v", tasks[i].ID, err)
+		}
+	}
+
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	s.bgCancel = bgCancel
+	s.bg.Add(2)
+	go func() {
+		defer s.bg.Done()
+		s.elector.Run(bgCtx)
+	}()
+	go func() {
+		defer s.bg.Done()
+		s.watch(bgCtx)
+	}()
+
+	s.cron.Start()
+	log.Printf("定时任务调度器已启动，共加载 %d 个任务 - Instance: %s", len(s.entries), s.instanceID)
+	return nil
+}
+
+// Stop 停止调度新的任务，并等待正在执行的任务（包括手动触发的任务）结束
+// ctx 结束时仍未执行完的任务会被取消
+func (s *Scheduler) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		<-s.cron.Stop().Done()
+		s.manual.Wait()
+		// 任务全部结束后再释放主节点租约，由其他实例接管
+		if s.bgCancel != nil {
+			s.bgCancel()
+			s.bg.Wait()
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// NextRunTimes 计算cron表达式接下来的 n 次执行时间
+func (s *Scheduler) NextRunTimes(spec string, n int) ([]time.Time, error) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Time, 0, n)
+	next := time.Now()
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		times = append(times, next)
+	}
+	return times, nil
+}
+
+// RunNow 立即在后台执行一次任务（暂停的任务也可以手动执行），返回本次执行第一次尝试的日志
+// 任务正在任一实例上执行且重叠执行策略为 skip 时返回 job.ErrBusy；策略为 queue 时在后台等待上一次执行结束
+func (s *Scheduler) RunNow(taskID uint) (*entity.TaskLog, error) {
+	var task entity.Task
+	if err := s.db.First(&task, taskID).Error; err != nil {
+		return nil, err
+	}
+
+	release, acquired := s.acquire(&task, false)
+	if !acquired && task.OverlapPolicy != entity.TaskOverlapQueue {
+		return nil, job.ErrBusy
+	}
+
+	taskLog, err := s.start(&task, entity.TaskTriggerManual, nil)
+	if err != nil {
+		if acquired {
+			release()
+		}
+		return nil, err
+	}
+
+	s.manual.Add(1)
+	go func() {
+		defer s.manual.Done()
+		if !acquired {
+			if release, acquired = s.acquire(&task, true); !acquired {
+				s.skip(taskLog, "调度器已停止，取消排队")
+				return
+			}
+			// 开始时间从真正开始执行时计算，不包括排队时间
+			taskLog.StartTime = time.Now()
+		}
+		defer release()
+		s.execute(&task, taskLog)
+	}()
+	return taskLog, nil
+}
+
+// Schedule 添加或更新任务的调度，未启用的任务会被移除，并通知其他实例重新加载该任务
+func (s *Scheduler) Schedule(task *entity.Task) error {
+	defer s.notify(task.ID)
+	if task.Status != 1 {
+		s.unschedule(task.ID)
+		return s.updateNextRunTime(task.ID, nil)
+	}
+
+	next, err := s.schedule(task)
+	if err != nil {
+		return err
+	}
+	return s.updateNextRunTime(task.ID, &next)
+}
+
+// Remove 移除任务的调度，并通知其他实例，不影响正在执行的任务
+func (s *Scheduler) Remove(taskID uint) {
+	s.unschedule(taskID)
+	s.notify(taskID)
+}
+
+// schedule 在当前实例上添加或更新启用任务的调度，返回下次执行时间，cron表达式无效时移除原有的调度
+func (s *Scheduler) schedule(task *entity.Task) (time.Time, error) {
+	schedule, err := ParseCron(task.Cron)
+	if err != nil {
+		s.unschedule(task.ID)
+		return time.Time{}, err
+	}
+
+	taskID := task.ID
+	s.mu.Lock()
+	if entry, ok := s.entries[taskID]; ok {
+		s.cron.Remove(entry.id)
+	}
+	s.entries[taskID] = cronEntry{
+		id:   s.cron.Schedule(schedule, cron.FuncJob(func() { s.run(taskID) })),
+		spec: task.Cron,
+	}
+	s.mu.Unlock()
+	return schedule.Next(time.Now()), nil
+}
+
+// unschedule 移除当前实例上任务的调度
+func (s *Scheduler) unschedule(taskID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[taskID]; ok {
+		s.cron.Remove(entry.id)
+		delete(s.entries, taskID)
+	}
+}
+
+// run 按计划执行一次任务，执行前重新读取任务以使用最新的参数
+func (s *Scheduler) run(taskID uint) {
+	var task entity.Task
+	if err := s.db.First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.unschedule(taskID)
+			return
+		}
+		log.Printf("查询定时任务失败 - TaskID: %d, Error: %v", taskID, err)
+		return
+	}
+	if task.Status != 1 {
+		s.unschedule(taskID)
+		return
+	}
+
+	// 只有主节点执行按计划触发的任务
+	l := s.elector.Lease()
+	if l == nil {
+		return
+	}
+
+	// 抢占本次触发，主节点切换期间新旧主节点可能同时触发
+	scheduledAt := time.Now().Truncate(time.Second)
+	claimCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	claimed, err := s.elector.claimOccurrence(claimCtx, task.ID, scheduledAt, l.holder)
+	cancel()
+	if err != nil {
+		log.Printf("抢占定时任务触发锁失败 - TaskID: %d, Error: %v", task.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	// fencing：数据库中记录了更大 token 时，说明已有新的主节点执行过该任务，当前租约已过期
+	if l.token > 0 {
+		result := s.db.Model(&entity.Task{}).
+			Where("id = ? AND fencing_token <= ?", task.ID, l.token).
+			Update("fencing_token", l.token)
+		if result.Error != nil {
+			log.Printf("更新任务 fencing token 失败 - TaskID: %d, Error: %v", task.ID, result.Error)
+			return
+		}
+		if result.RowsAffected == 0 {
+			log.Printf("主节点租约已过期，跳过执行 - TaskID: %d, Token: %d", task.ID, l.token)
+			return
+		}
+	}
+
+	release, acquired := s.acquire(&task, true)
+	if !acquired {
+		taskLog, err := s.start(&task, entity.TaskTriggerSchedule, l)
+		if err != nil {
+			log.Printf("创建任务日志失败 - TaskID: %d, Error: %v", task.ID, err)
+			return
+		}
+		s.skip(taskLog, "上一次执行尚未结束，已跳过")
+		return
+	}
+	defer release()
+
+	taskLog, err := s.start(&task, entity.TaskTriggerSchedule, l)
+	if err != nil {
+		log.Printf("创建任务日志失败 - TaskID: %d, Error: %v", task.ID, err)
+		return
+	}
+	s.execute(&task, taskLog)
+}
+
+// acquire 按任务的重叠执行策略获取执行槽和所有实例共享的执行锁，成功时返回释放函数
+// allow 策略始终成功；skip 策略在任务执行中（包括在其他实例上执行中）时失败；
+// queue 策略在 wait 为 true 时等待上一次执行结束，调度器停止时失败
+func (s *Scheduler) acquire(task *entity.Task, wait bool) (func(), bool) {
+	if task.OverlapPolicy == entity.TaskOverlapAllow {
+		return func() {}, true
+	}
+
+	s.mu.Lock()
+	slot, ok := s.running[task.ID]
+	if !ok {
+		slot = make(chan struct{}, 1)
+		s.running[task.ID] = slot
+	}
+	s.mu.Unlock()
+
+	acquired := false
+	select {
+	case slot <- struct{}{}:
+		acquired = true
+	default:
+	}
+	if !acquired {
+		if !wait || task.OverlapPolicy != entity.TaskOverlapQueue {
+			return nil, false
+		}
+		select {
+		case slot <- struct{}{}:
+		case <-s.ctx.Done():
+			return nil, false
+		}
+	}
+
+	// 当前实例上没有执行后，再获取执行锁，排除其他实例上的执行
+	ttl := time.Duration(task.Timeout)*time.Second + runningLockMargin
+	for {
+		unlock, locked, err := s.elector.lockRunning(s.ctx, task.ID, ttl)
+		if err != nil {
+			log.Printf("获取定时任务执行锁失败 - TaskID: %d, Error: %v", task.ID, err)
+		}
+		if locked {
+			return func() {
+				unlock()
+				<-slot
+			}, true
+		}
+		if !wait || task.OverlapPolicy != entity.TaskOverlapQueue {
+			<-slot
+			return nil, false
+		}
+
+		timer := time.NewTimer(runningPollInterval)
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			timer.Stop()
+			<-slot
+			return nil, false
+		}
+	}
+}
+
+// start 创建执行中的任务日志（第一次尝试），l 为执行时持有的主节点租约
+func (s *Scheduler) start(task *entity.Task, trigger string, l *lease) (*entity.TaskLog, error) {
+	taskLog := &entity.TaskLog{
+		TaskID:     task.ID,
+		Status:     entity.TaskLogRunning,
+		Trigger:    trigger,
+		StartTime:  time.Now(),
+		Attempt:    1,
+		InstanceID: s.instanceID,
+	}
+	if l != nil {
+		taskLog.LockHolder = l.holder
+		taskLog.FencingToken = l.token
+	}
+	if err := s.db.Create(taskLog).Error; err != nil {
+		return nil, fmt.Errorf("创建任务日志失败: %v", err)
+	}
+	return taskLog, nil
+}
+
+// skip 将任务日志记录为未执行
+func (s *Scheduler) skip(taskLog *entity.TaskLog, reason string) {
+	taskLog.Status = entity.TaskLogSkipped
+	taskLog.EndTime = time.Now()
+	taskLog.Result = reason
+	if err := s.db.Save(taskLog).Error; err != nil {
+		log.Printf("更新任务日志失败 - TaskID: %d, Error: %v", taskLog.TaskID, err)
+	}
+}
+
+// execute 执行任务，失败时按指数退避重试，每次尝试都记录一条任务日志
+// 重试次数用尽后仍失败时发送告警，调度器停止或主节点租约丢失导致的中止不再重试也不告警
+func (s *Scheduler) execute(task *entity.Task, taskLog *entity.TaskLog) {
+	for {
+		if s.attempt(task, taskLog) {
+			return
+		}
+		if s.ctx.Err() != nil {
+			return
+		}
+		if taskLog.Attempt > task.MaxRetries {
+			s.alert(task, taskLog)
+			return
+		}
+
+		timer := time.NewTimer(retryDelay(task, taskLog.Attempt))
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		// 等待期间租约可能已被新的主节点接管，继续重试会与新主节点重复执行
+		if !s.leaseValid(taskLog) {
+			log.Printf("主节点租约已失效，停止重试 - TaskID: %d, Token: %d", task.ID, taskLog.FencingToken)
+			return
+		}
+
+		next, err := s.retry(taskLog)
+		if err != nil {
+			log.Printf("创建任务重试日志失败 - TaskID: %d, Error: %v", task.ID, err)
+			return
+		}
+		taskLog = next
+	}
+}
+
+// attempt 执行一次任务，记录执行结果并更新任务的执行时间，返回是否执行成功
+func (s *Scheduler) attempt(task *entity.Task, taskLog *entity.TaskLog) bool {
+	ctx, cancel := s.ctx, context.CancelFunc(func() {})
+	if task.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(s.ctx, time.Duration(task.Timeout)*time.Second)
+	}
+	output, err := s.registry.Run(ctx, task.Handler, task.Params)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("执行超时（%d秒）: %v", task.Timeout, err)
+	}
+	cancel()
+
+	taskLog.EndTime = time.Now()
+	taskLog.Duration = taskLog.EndTime.Sub(taskLog.StartTime).Milliseconds()
+	taskLog.Status = entity.TaskLogSuccess
+	taskLog.Result = output
+	if err != nil {
+		taskLog.Status = entity.TaskLogFailed
+		taskLog.Result = strings.TrimLeft(fmt.Sprintf("%s\n执行失败: %v", output, err), "\n")
+	}
+	if len(taskLog.Result) > maxResultSize {
+		taskLog.Result = strings.ToValidUTF8(taskLog.Result[:maxResultSize], "") + "\n...(结果过长，已截断)"
+	}
+	if err := s.db.Save(taskLog).Error; err != nil {
+		log.Printf("更新任务日志失败 - TaskID: %d, Error: %v", task.ID, err)
+	}
+
+	updates := map[string]interface{}{"last_run_time": taskLog.StartTime}
+	if task.Status == 1 {
+		if schedule, err := ParseCron(task.Cron); err == nil {
+			updates["next_run_time"] = schedule.Next(time.Now())
+		}
+	}
+	if err := s.db.Model(&entity.Task{}).Where("id = ?", task.ID).Updates(updates).Error; err != nil {
+		log.Printf("更新任务执行时间失败 - TaskID: %d, Error: %v", task.ID, err)
+	}
+	return taskLog.Status == entity.TaskLogSuccess
+}
+
+// leaseValid 检查按计划触发的执行是否仍持有触发时的主节点租约，且没有更新的主节点执行过该任务
+// 手动触发不依赖租约，始终有效
+func (s *Scheduler) leaseValid(taskLog *entity.TaskLog) bool {
+	if taskLog.LockHolder == "" {
+		return true
+	}
+	l := s.elector.Lease()
+	if l == nil || l.holder != taskLog.LockHolder {
+		return false
+	}
+	if l.token == 0 {
+		return true
+	}
+
+	var count int64
+	err := s.db.Model(&entity.Task{}).Where("id = ? AND fencing_token > ?", taskLog.TaskID, l.token).Count(&count).Error
+	if err != nil {
+		log.Printf("查询任务 fencing token 失败 - TaskID: %d, Error: %v", taskLog.TaskID, err)
+		return false
+	}
+	return count == 0
+}
+
+// retry 创建下一次尝试的任务日志，沿用第一次尝试的触发方式和主节点租约
+func (s *Scheduler) retry(prev *entity.TaskLog) (*entity.TaskLog, error) {
+	retryOf := prev.RetryOf
+	if retryOf == 0 {
+		retryOf = prev.ID
+	}
+	taskLog := &entity.TaskLog{
+		TaskID:       prev.TaskID,
+		Status:       entity.TaskLogRunning,
+		Trigger:      prev.Trigger,
+		StartTime:    time.Now(),
+		Attempt:      prev.Attempt + 1,
+		RetryOf:      retryOf,
+		InstanceID:   s.instanceID,
+		LockHolder:   prev.LockHolder,
+		FencingToken: prev.FencingToken,
+	}
+	if err := s.db.Create(taskLog).Error; err != nil {
+		return nil, fmt.Errorf("创建任务日志失败: %v", err)
+	}
+	return taskLog, nil
+}
+
+// alert 发送任务最终执行失败的告警
+func (s *Scheduler) alert(task *entity.Task, taskLog *entity.TaskLog) {
+	if s.alerter == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.alerter.TaskFailed(ctx, task, taskLog); err != nil {
+		log.Printf("发送任务失败告警失败 - TaskID: %d, Error: %v", task.ID, err)
+	}
+}
+
+// retryDelay 计算第 attempt 次尝试失败后的等待时间，从重试间隔开始每次翻倍
+func retryDelay(task *entity.Task, attempt int) time.Duration {
+	delay := defaultRetryInterval
+	if task.RetryInterval > 0 {
+		delay = time.Duration(task.RetryInterval) * time.Second
+	}
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+func (s *Scheduler) updateNextRunTime(taskID uint, next *time.Time) error {
+	err := s.db.Model(&entity.Task{}).Where("id = ?", taskID).Update("next_run_time", next).Error
+	if err != nil {
+		return fmt.Errorf("更新任务下次执行时间失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/job"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.Task{}, &entity.TaskLog{}))
+	return db
+}
+
+func newTestRegistry(t *testing.T) *job.Registry {
+	registry := job.NewRegistry()
+	require.NoError(t, registry.Register(job.Definition{
+		Name: "echo",
+		Run:  func(ctx context.Context, _ json.RawMessage) (string, error) { return "ok", nil },
+	}))
+	return registry
+}
+
+func stopScheduler(t *testing.T, s *Scheduler) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, s.Stop(ctx))
+}
+
+func (s *Scheduler) scheduledSpec(taskID uint) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[taskID]
+	return entry.spec, ok
+}
+
+// 在非主节点上创建的任务需要由主节点调度执行
+func TestScheduleOnFollowerRunsOnLeader(t *testing.T) {
+	db := newTestDB(t)
+	mr := miniredis.RunT(t)
+	registry := newTestRegistry(t)
+
+	leader := New(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}), registry, nil)
+	require.NoError(t, leader.Start(context.Background()))
+	defer stopScheduler(t, leader)
+	require.Eventually(t, func() bool { return leader.elector.Lease() != nil }, 5*time.Second, 10*time.Millisecond)
+
+	follower := New(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}), registry, nil)
+	require.NoError(t, follower.Start(context.Background()))
+	defer stopScheduler(t, follower)
+	require.Nil(t, follower.elector.Lease())
+
+	// 与 TaskService.Create 相同：先保存任务，再由处理请求的实例调度
+	task := &entity.Task{Name: "echo", Cron: "* * * * * *", Handler: "echo", Status: 1, OverlapPolicy: entity.TaskOverlapSkip}
+	require.NoError(t, db.Create(task).Error)
+	require.NoError(t, follower.Schedule(task))
+
+	assert.Eventually(t, func() bool {
+		_, ok := leader.scheduledSpec(task.ID)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond, "主节点应收到变更通知并调度任务")
+	assert.Eventually(t, func() bool {
+		var count int64
+		db.Model(&entity.TaskLog{}).Where("task_id = ? AND instance_id = ?", task.ID, leader.InstanceID()).Count(&count)
+		return count > 0
+	}, 5*time.Second, 50*time.Millisecond, "主节点应执行任务")
+
+	var followerRuns int64
+	db.Model(&entity.TaskLog{}).Where("instance_id = ?", follower.InstanceID()).Count(&followerRuns)
+	assert.Zero(t, followerRuns, "非主节点不应执行任务")
+
+	// 在非主节点上暂停任务，主节点同样移除调度
+	require.NoError(t, db.Model(task).Update("status", 0).Error)
+	task.Status = 0
+	require.NoError(t, follower.Schedule(task))
+	assert.Eventually(t, func() bool {
+		_, ok := leader.scheduledSpec(task.ID)
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond, "主节点应移除已暂停的任务")
+}
+
+// 丢失变更通知时，对账按数据库中的任务修正调度表
+func TestReconcile(t *testing.T) {
+	db := newTestDB(t)
+	s := New(db, nil, newTestRegistry(t), nil)
+
+	task := &entity.Task{Name: "echo", Cron: "0 0 * * * *", Handler: "echo", Status: 1}
+	require.NoError(t, db.Create(task).Error)
+	removed := &entity.Task{Name: "removed", Cron: "0 0 * * * *", Handler: "echo", Status: 1}
+	require.NoError(t, db.Create(removed).Error)
+	_, err := s.schedule(removed)
+	require.NoError(t, err)
+	require.NoError(t, db.Delete(removed).Error)
+
+	require.NoError(t, s.reconcile(context.Background()))
+	spec, ok := s.scheduledSpec(task.ID)
+	assert.True(t, ok, "添加缺少的任务")
+	assert.Equal(t, "0 0 * * * *", spec)
+	_, ok = s.scheduledSpec(removed.ID)
+	assert.False(t, ok, "移除已删除的任务")
+
+	require.NoError(t, db.Model(task).Update("cron", "0 30 * * * *").Error)
+	require.NoError(t, s.reconcile(context.Background()))
+	spec, _ = s.scheduledSpec(task.ID)
+	assert.Equal(t, "0 30 * * * *", spec, "cron表达式变化的任务重新调度")
+
+	require.NoError(t, db.Model(task).Update("status", 0).Error)
+	require.NoError(t, s.reconcile(context.Background()))
+	_, ok = s.scheduledSpec(task.ID)
+	assert.False(t, ok, "移除已暂停的任务")
+}
+
+// blockingJob 每次执行时通知 started，直到 release 关闭或 ctx 结束后返回
+type blockingJob struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingJob(t *testing.T, registry *job.Registry) *blockingJob {
+	b := &blockingJob{started: make(chan struct{}, 10), release: make(chan struct{})}
+	require.NoError(t, registry.Register(job.Definition{
+		Name: "block",
+		Run: func(ctx context.Context, _ json.RawMessage) (string, error) {
+			b.started <- struct{}{}
+			select {
+			case <-b.release:
+				return "done", nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		},
+	}))
+	return b
+}
+
+func (b *blockingJob) waitStarted(t *testing.T) {
+	select {
+	case <-b.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("任务未开始执行")
+	}
+}
+
+// countingAlerter 记录告警次数
+type countingAlerter struct{ count atomic.Int32 }
+
+func (a *countingAlerter) TaskFailed(ctx context.Context, task *entity.Task, taskLog *entity.TaskLog) error {
+	a.count.Add(1)
+	return nil
+}
+
+func taskLogs(t *testing.T, db *gorm.DB, taskID uint) []entity.TaskLog {
+	var logs []entity.TaskLog
+	require.NoError(t, db.Where("task_id = ?", taskID).Order("id").Find(&logs).Error)
+	return logs
+}
+
+func TestOverlapPolicy(t *testing.T) {
+	tests := []struct {
+		policy     string
+		concurrent bool // 第二次触发是否与第一次同时执行
+		busy       bool // 第二次触发是否返回 job.ErrBusy
+	}{
+		{policy: entity.TaskOverlapSkip, busy: true},
+		{policy: entity.TaskOverlapQueue},
+		{policy: entity.TaskOverlapAllow, concurrent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			db := newTestDB(t)
+			registry := newTestRegistry(t)
+			blocking := newBlockingJob(t, registry)
+			s := New(db, nil, registry, nil)
+			defer stopScheduler(t, s)
+
+			task := &entity.Task{Name: "block", Cron: "0 0 * * * *", Handler: "block", Status: 1, OverlapPolicy: tt.policy}
+			require.NoError(t, db.Create(task).Error)
+
+			_, err := s.RunNow(task.ID)
+			require.NoError(t, err)
+			blocking.waitStarted(t)
+
+			_, err = s.RunNow(task.ID)
+			if tt.busy {
+				assert.ErrorIs(t, err, job.ErrBusy)
+				close(blocking.release)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.concurrent {
+				blocking.waitStarted(t)
+			} else {
+				select {
+				case <-blocking.started:
+					t.Fatal("上一次执行结束前不应开始执行")
+				case <-time.After(200 * time.Millisecond):
+				}
+			}
+			close(blocking.release)
+			if !tt.concurrent {
+				blocking.waitStarted(t)
+			}
+
+			assert.Eventually(t, func() bool {
+				logs := taskLogs(t, db, task.ID)
+				return len(logs) == 2 && logs[0].Status == entity.TaskLogSuccess && logs[1].Status == entity.TaskLogSuccess
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+// 执行超时时取消任务并记录为失败
+func TestTimeout(t *testing.T) {
+	db := newTestDB(t)
+	registry := newTestRegistry(t)
+	blocking := newBlockingJob(t, registry)
+	s := New(db, nil, registry, nil)
+	defer stopScheduler(t, s)
+
+	task := &entity.Task{Name: "block", Cron: "0 0 * * * *", Handler: "block", Status: 1, Timeout: 1}
+	require.NoError(t, db.Create(task).Error)
+	_, err := s.RunNow(task.ID)
+	require.NoError(t, err)
+	blocking.waitStarted(t)
+
+	assert.Eventually(t, func() bool {
+		logs := taskLogs(t, db, task.ID)
+		return len(logs) == 1 && logs[0].Status == entity.TaskLogFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, taskLogs(t, db, task.ID)[0].Result, "执行超时（1秒）")
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		interval int
+		attempt  int
+		want     time.Duration
+	}{
+		{interval: 0, attempt: 1, want: defaultRetryInterval},
+		{interval: 0, attempt: 3, want: 4 * defaultRetryInterval},
+		{interval: 5, attempt: 1, want: 5 * time.Second},
+		{interval: 5, attempt: 2, want: 10 * time.Second},
+		{interval: 5, attempt: 20, want: maxRetryDelay},
+		{interval: 3600, attempt: 1, want: maxRetryDelay},
+	}
+	for _, tt := range tests {
+		got := retryDelay(&entity.Task{RetryInterval: tt.interval}, tt.attempt)
+		assert.Equal(t, tt.want, got, "interval=%d attempt=%d", tt.interval, tt.attempt)
+	}
+}
+
+// 失败后按退避间隔重试，重试次数用尽后告警
+func TestRetryBackoff(t *testing.T) {
+	db := newTestDB(t)
+	registry := job.NewRegistry()
+	require.NoError(t, registry.Register(job.Definition{
+		Name: "fail",
+		Run:  func(ctx context.Context, _ json.RawMessage) (string, error) { return "", errors.New("boom") },
+	}))
+	alerter := &countingAlerter{}
+	s := New(db, nil, registry, alerter)
+	defer stopScheduler(t, s)
+
+	task := &entity.Task{Name: "fail", Cron: "0 0 * * * *", Handler: "fail", Status: 1, MaxRetries: 1, RetryInterval: 1}
+	require.NoError(t, db.Create(task).Error)
+	first, err := s.RunNow(task.ID)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return alerter.count.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	logs := taskLogs(t, db, task.ID)
+	require.Len(t, logs, 2)
+	assert.Equal(t, entity.TaskLogFailed, logs[1].Status)
+	assert.Equal(t, 2, logs[1].Attempt)
+	assert.Equal(t, first.ID, logs[1].RetryOf)
+	assert.GreaterOrEqual(t, logs[1].StartTime.Sub(logs[0].EndTime), time.Second, "等待重试间隔后重试")
+}
+
+// 等待重试期间丢失主节点租约时停止重试，由新的主节点负责
+func TestRetryStopsWhenLeaseLost(t *testing.T) {
+	failing := func(t *testing.T) *job.Registry {
+		registry := job.NewRegistry()
+		require.NoError(t, registry.Register(job.Definition{
+			Name: "fail",
+			Run:  func(ctx context.Context, _ json.RawMessage) (string, error) { return "", errors.New("boom") },
+		}))
+		return registry
+	}
+
+	t.Run("租约已被其他实例持有", func(t *testing.T) {
+		db := newTestDB(t)
+		alerter := &countingAlerter{}
+		s := New(db, nil, failing(t), alerter)
+		task := &entity.Task{Name: "fail", Cron: "0 0 * * * *", Handler: "fail", Status: 1, MaxRetries: 3, RetryInterval: 1}
+		require.NoError(t, db.Create(task).Error)
+
+		taskLog, err := s.start(task, entity.TaskTriggerSchedule, &lease{holder: "old-leader:1", token: 1})
+		require.NoError(t, err)
+		s.execute(task, taskLog)
+
+		assert.Len(t, taskLogs(t, db, task.ID), 1)
+		assert.Zero(t, alerter.count.Load())
+	})
+
+	t.Run("新的主节点已执行过该任务", func(t *testing.T) {
+		db := newTestDB(t)
+		mr := miniredis.RunT(t)
+		alerter := &countingAlerter{}
+		s := New(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}), failing(t), alerter)
+		s.elector.tick(context.Background())
+		l := s.elector.Lease()
+		require.NotNil(t, l)
+
+		task := &entity.Task{Name: "fail", Cron: "0 0 * * * *", Handler: "fail", Status: 1, MaxRetries: 3, RetryInterval: 1}
+		require.NoError(t, db.Create(task).Error)
+		require.NoError(t, db.Model(task).Update("fencing_token", l.token+1).Error)
+
+		taskLog, err := s.start(task, entity.TaskTriggerSchedule, l)
+		require.NoError(t, err)
+		s.execute(task, taskLog)
+
+		assert.Len(t, taskLogs(t, db, task.ID), 1)
+		assert.Zero(t, alerter.count.Load())
+	})
+}
+
+// 重叠执行策略对所有实例生效：任务在一个实例上执行时，另一个实例上的手动触发按策略跳过或排队
+func TestOverlapPolicyAcrossInstances(t *testing.T) {
+	setup := func(t *testing.T, policy string) (*gorm.DB, *blockingJob, *Scheduler, *Scheduler, *entity.Task) {
+		db := newTestDB(t)
+		mr := miniredis.RunT(t)
+		registry := newTestRegistry(t)
+		blocking := newBlockingJob(t, registry)
+		a := New(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}), registry, nil)
+		b := New(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}), registry, nil)
+		t.Cleanup(func() {
+			stopScheduler(t, a)
+			stopScheduler(t, b)
+		})
+
+		task := &entity.Task{Name: "block", Cron: "0 0 * * * *", Handler: "block", Status: 1, OverlapPolicy: policy}
+		require.NoError(t, db.Create(task).Error)
+		return db, blocking, a, b, task
+	}
+
+	t.Run(entity.TaskOverlapSkip, func(t *testing.T) {
+		db, blocking, a, b, task := setup(t, entity.TaskOverlapSkip)
+		_, err := a.RunNow(task.ID)
+		require.NoError(t, err)
+		blocking.waitStarted(t)
+
+		_, err = b.RunNow(task.ID)
+		assert.ErrorIs(t, err, job.ErrBusy, "其他实例上正在执行")
+
+		close(blocking.release)
+		require.Eventually(t, func() bool {
+			logs := taskLogs(t, db, task.ID)
+			return len(logs) == 1 && logs[0].Status == entity.TaskLogSuccess
+		}, 5*time.Second, 10*time.Millisecond)
+		_, err = b.RunNow(task.ID)
+		assert.NoError(t, err, "执行结束后释放执行锁")
+	})
+
+	t.Run(entity.TaskOverlapQueue, func(t *testing.T) {
+		db, blocking, a, b, task := setup(t, entity.TaskOverlapQueue)
+		_, err := a.RunNow(task.ID)
+		require.NoError(t, err)
+		blocking.waitStarted(t)
+
+		_, err = b.RunNow(task.ID)
+		require.NoError(t, err)
+		select {
+		case <-blocking.started:
+			t.Fatal("其他实例上的执行结束前不应开始执行")
+		case <-time.After(2 * runningPollInterval):
+		}
+
+		close(blocking.release)
+		blocking.waitStarted(t)
+		assert.Eventually(t, func() bool {
+			logs := taskLogs(t, db, task.ID)
+			return len(logs) == 2 && logs[1].Status == entity.TaskLogSuccess && logs[1].InstanceID == b.InstanceID()
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"gva/internal/domain/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// auditListRequest 审计事件的分页和筛选参数
+// 时间参数使用 RFC3339 格式，如 start_time=2024-01-01T00:00:00+08:00
+type auditListRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+
+	EntityType string    `form:"entity_type" binding:"max=32"` // 实体类型，如 user、role
+	EntityID   uint      `form:"entity_id"`                    // 实体ID
+	ActorID    uint      `form:"actor_id"`                     // 操作人
+	Action     string    `form:"action" binding:"max=32"`      // 操作，如 update、update_status、assign_permissions
+	StartTime  time.Time `form:"start_time"`                   // 开始时间（包含）
+	EndTime    time.Time `form:"end_time"`                     // 结束时间（不包含）
+}
+
+// ListAuditEvents 获取审计事件列表
+func (h *AuditHandler) ListAuditEvents(c *gin.Context) {
+	var req auditListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+	h.list(c, req)
+}
+
+// ListEntityAuditEvents 获取指定实体的变更历史，如 /audit-events/role/5
+func (h *AuditHandler) ListEntityAuditEvents(c *gin.Context) {
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的实体ID",
+		})
+		return
+	}
+
+	var req auditListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+	req.EntityType = c.Param("type")
+	req.EntityID = uint(entityID)
+	h.list(c, req)
+}
+
+func (h *AuditHandler) list(c *gin.Context, req auditListRequest) {
+	if !req.StartTime.IsZero() && !req.EndTime.IsZero() && !req.StartTime.Before(req.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "时间范围错误",
+		})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	filter := service.AuditEventFilter{
+		EntityType: req.EntityType,
+		EntityID:   req.EntityID,
+		ActorID:    req.ActorID,
+		Action:     req.Action,
+	}
+	if !req.StartTime.IsZero() {
+		filter.StartTime = &req.StartTime
+	}
+	if !req.EndTime.IsZero() {
+		filter.EndTime = &req.EndTime
+	}
+
+	events, total, err := h.auditService.List(c.Request.Context(), req.Page, req.PageSize, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"events": events,
+			"total":  total,
+			"page":   req.Page,
+			"size":   req.PageSize,
+		},
+	})
+}
+
+// VerifyAuditChain 校验审计事件哈希链，报告第一处断开的链接
+func (h *AuditHandler) VerifyAuditChain(c *gin.Context) {
+	result, err := h.auditService.Verify(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": result,
+	})
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"gva/internal/domain/entity"
+	"gva/internal/domain/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DepartmentHandler struct {
+	departmentService *service.DepartmentService
+}
+
+func NewDepartmentHandler(departmentService *service.DepartmentService) *DepartmentHandler {
+	return &DepartmentHandler{departmentService: departmentService}
+}
+
+// departmentRequest 创建/更新部门的请求参数
+type departmentRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=64"`
+	Code        string `json:"code" binding:"required,min=2,max=64"`
+	ParentID    *uint  `json:"parent_id"`
+	Leader      string `json:"leader" binding:"max=64"`
+	Phone       string `json:"phone" binding:"omitempty,max=32"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	Sort        int    `json:"sort"`
+	Status      *int   `json:"status" binding:"omitempty,oneof=0 1"`
+	Description string `json:"description" binding:"max=256"`
+}
+
+func (r *departmentRequest) toEntity() *entity.Department {
+	status := 1 // 默认启用
+	if r.Status != nil {
+		status = *r.Status
+	}
+	return &entity.Department{
+		Name:        r.Name,
+		Code:        r.Code,
+		ParentID:    r.ParentID,
+		Leader:      r.Leader,
+		Phone:       r.Phone,
+		Email:       r.Email,
+		Sort:        r.Sort,
+		Status:      status,
+		Description: r.Description,
+	}
+}
+
+// GetTree 获取部门树
+func (h *DepartmentHandler) GetTree(c *gin.Context) {
+	departments, err := h.departmentService.GetTree(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"departments": departments,
+		},
+	})
+}
+
+// GetDepartment 获取单个部门信息
+func (h *DepartmentHandler) GetDepartment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的部门ID",
+		})
+		return
+	}
+
+	department, err := h.departmentService.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		respondDepartmentError(c, err, "获取部门信息失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"department": department,
+		},
+	})
+}
+
+// CreateDepartment 创建部门
+func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
+	var req departmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	department := req.toEntity()
+	if err := h.departmentService.Create(c.Request.Context(), department); err != nil {
+		respondDepartmentError(c, err, "创建部门失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "创建成功",
+		"data": gin.H{
+			"department": department,
+		},
+	})
+}
+
+// UpdateDepartment 更新部门信息，修改上级部门时会同步移动其下级部门
+func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的部门ID",
+		})
+		return
+	}
+
+	var req departmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	if err := h.departmentService.Update(c.Request.Context(), uint(id), req.toEntity()); err != nil {
+		respondDepartmentError(c, err, "更新部门失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "更新成功",
+	})
+}
+
+// MoveDepartment 移动部门到新的上级部门下
+func (h *DepartmentHandler) MoveDepartment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的部门ID",
+		})
+		return
+	}
+
+	var req struct {
+		ParentID *uint `json:"parent_id"` // 为空时移动为顶级部门
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	if err := h.departmentService.Move(c.Request.Context(), uint(id), req.ParentID); err != nil {
+		respondDepartmentError(c, err, "移动部门失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "移动成功",
+	})
+}
+
+// DeleteDepartment 删除部门
+func (h *DepartmentHandler) DeleteDepartment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的部门ID",
+		})
+		return
+	}
+
+	if err := h.departmentService.Delete(c.Request.Context(), uint(id)); err != nil {
+		respondDepartmentError(c, err, "删除部门失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "删除成功",
+	})
+}
+
+// respondDepartmentError 将部门服务的错误转换为对应的HTTP状态码
+func respondDepartmentError(c *gin.Context, err error, action string) {
+	var statusCode int
+	switch {
+	case errors.Is(err, service.ErrDepartmentNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, service.ErrParentDepartmentNotFound),
+		errors.Is(err, service.ErrDepartmentCycle):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, service.ErrDepartmentCodeConflict),
+		errors.Is(err, service.ErrDepartmentHasChildren),
+		errors.Is(err, service.ErrDepartmentHasUsers),
+		errors.Is(err, service.ErrDepartmentHasPositions):
+		statusCode = http.StatusConflict
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": fmt.Sprintf("%s: %v", action, err),
+		})
+		return
+	}
+
+	c.JSON(statusCode, gin.H{
+		"code":  statusCode,
+		"error": err.Error(),
+	})
+}
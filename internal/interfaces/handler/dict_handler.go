@@ -0,0 +1,398 @@
+package handler
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gva/internal/domain/entity"
+	"gva/internal/domain/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DictHandler struct {
+	dictService *service.DictService
+}
+
+func NewDictHandler(dictService *service.DictService) *DictHandler {
+	return &DictHandler{dictService: dictService}
+}
+
+// dictRequest 创建/更新字典的请求参数
+type dictRequest struct {
+	Name        string            `json:"name" binding:"required,min=2,max=64"`
+	Code        string            `json:"code" binding:"required,min=2,max=64"`
+	Description string            `json:"description" binding:"max=256"`
+	Status      *int              `json:"status" binding:"omitempty,oneof=0 1"`
+	Items       []dictItemRequest `json:"items" binding:"dive"` // 仅创建时有效
+}
+
+// dictItemRequest 创建/更新字典项的请求参数
+type dictItemRequest struct {
+	Label       string `json:"label" binding:"required,max=128"`
+	Value       string `json:"value" binding:"required,max=128"`
+	Sort        int    `json:"sort"`
+	Status      *int   `json:"status" binding:"omitempty,oneof=0 1"`
+	Description string `json:"description" binding:"max=256"`
+}
+
+func (r *dictRequest) toEntity() *entity.Dict {
+	status := 1 // 默认启用
+	if r.Status != nil {
+		status = *r.Status
+	}
+	dict := &entity.Dict{
+		Name:        r.Name,
+		Code:        r.Code,
+		Description: r.Description,
+		Status:      status,
+	}
+	for i := range r.Items {
+		dict.Items = append(dict.Items, *r.Items[i].toEntity())
+	}
+	return dict
+}
+
+func (r *dictItemRequest) toEntity() *entity.DictItem {
+	status := 1 // 默认启用
+	if r.Status != nil {
+		status = *r.Status
+	}
+	return &entity.DictItem{
+		Label:       r.Label,
+		Value:       r.Value,
+		Sort:        r.Sort,
+		Status:      status,
+		Description: r.Description,
+	}
+}
+
+// ListDicts 获取字典列表（包含字典项）
+func (h *DictHandler) ListDicts(c *gin.Context) {
+	dicts, err := h.dictService.List(c.Request.Context(), c.Query("keyword"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"dicts": dicts,
+		},
+	})
+}
+
+// CreateDict 创建字典
+func (h *DictHandler) CreateDict(c *gin.Context) {
+	var req dictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	dict := req.toEntity()
+	if err := h.dictService.Create(c.Request.Context(), dict); err != nil {
+		respondDictError(c, err, "创建字典失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "创建成功",
+		"data": gin.H{
+			"dict": dict,
+		},
+	})
+}
+
+// UpdateDict 更新字典基本信息
+func (h *DictHandler) UpdateDict(c *gin.Context) {
+	id, ok := parseDictID(c, "id", "无效的字典ID")
+	if !ok {
+		return
+	}
+
+	var req dictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	if err := h.dictService.Update(c.Request.Context(), id, req.toEntity()); err != nil {
+		respondDictError(c, err, "更新字典失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "更新成功",
+	})
+}
+
+// DeleteDict 删除字典
+func (h *DictHandler) DeleteDict(c *gin.Context) {
+	id, ok := parseDictID(c, "id", "无效的字典ID")
+	if !ok {
+		return
+	}
+
+	if err := h.dictService.Delete(c.Request.Context(), id); err != nil {
+		respondDictError(c, err, "删除字典失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "删除成功",
+	})
+}
+
+// CreateDictItem 添加字典项
+func (h *DictHandler) CreateDictItem(c *gin.Context) {
+	dictID, ok := parseDictID(c, "id", "无效的字典ID")
+	if !ok {
+		return
+	}
+
+	var req dictItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	item := req.toEntity()
+	if err := h.dictService.AddItem(c.Request.Context(), dictID, item); err != nil {
+		respondDictError(c, err, "添加字典项失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "创建成功",
+		"data": gin.H{
+			"item": item,
+		},
+	})
+}
+
+// UpdateDictItem 更新字典项
+func (h *DictHandler) UpdateDictItem(c *gin.Context) {
+	dictID, ok := parseDictID(c, "id", "无效的字典ID")
+	if !ok {
+		return
+	}
+	itemID, ok := parseDictID(c, "item_id", "无效的字典项ID")
+	if !ok {
+		return
+	}
+
+	var req dictItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	if err := h.dictService.UpdateItem(c.Request.Context(), dictID, itemID, req.toEntity()); err != nil {
+		respondDictError(c, err, "更新字典项失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "更新成功",
+	})
+}
+
+// UpdateDictItemStatus 启用或禁用字典项
+func (h *DictHandler) UpdateDictItemStatus(c *gin.Context) {
+	dictID, ok := parseDictID(c, "id", "无效的字典ID")
+	if !ok {
+		return
+	}
+	itemID, ok := parseDictID(c, "item_id", "无效的字典项ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status *int `json:"status" binding:"required,oneof=0 1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "状态参数无效",
+		})
+		return
+	}
+
+	if err := h.dictService.UpdateItemStatus(c.Request.Context(), dictID, itemID, *req.Status); err != nil {
+		respondDictError(c, err, "更新字典项状态失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "状态更新成功",
+	})
+}
+
+// DeleteDictItem 删除字典项
+func (h *DictHandler) DeleteDictItem(c *gin.Context) {
+	dictID, ok := parseDictID(c, "id", "无效的字典ID")
+	if !ok {
+		return
+	}
+	itemID, ok := parseDictID(c, "item_id", "无效的字典项ID")
+	if !ok {
+		return
+	}
+
+	if err := h.dictService.DeleteItem(c.Request.Context(), dictID, itemID); err != nil {
+		respondDictError(c, err, "删除字典项失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "删除成功",
+	})
+}
+
+// GetDictItems 获取字典的已启用字典项（所有登录用户可用，支持 ETag 协商缓存）
+func (h *DictHandler) GetDictItems(c *gin.Context) {
+	options, err := h.dictService.GetOptions(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		respondDictError(c, err, "获取字典项失败")
+		return
+	}
+
+	respondWithETag(c, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"dict": options,
+		},
+	})
+}
+
+// BatchGetDictItems 批量获取多个字典的字典项，如 ?codes=user_status,permission_type
+func (h *DictHandler) BatchGetDictItems(c *gin.Context) {
+	var codes []string
+	for _, code := range strings.Split(c.Query("codes"), ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请指定字典编码",
+		})
+		return
+	}
+
+	options, err := h.dictService.BatchGetOptions(c.Request.Context(), codes)
+	if err != nil {
+		respondDictError(c, err, "获取字典项失败")
+		return
+	}
+
+	respondWithETag(c, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"dicts": options,
+		},
+	})
+}
+
+// respondWithETag 根据响应内容生成 ETag，与 If-None-Match 匹配时返回 304
+func respondWithETag(c *gin.Context, body gin.H) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": fmt.Sprintf("序列化响应失败: %v", err),
+		})
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(data))
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache") // 允许缓存，但每次使用前需要重新验证
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// etagMatches 判断 If-None-Match 请求头是否包含指定的 ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDictID 解析路径中的字典/字典项ID，解析失败时直接返回400
+func parseDictID(c *gin.Context, param, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": message,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondDictError 将字典服务的错误转换为对应的HTTP状态码
+func respondDictError(c *gin.Context, err error, action string) {
+	var statusCode int
+	switch {
+	case errors.Is(err, service.ErrDictNotFound),
+		errors.Is(err, service.ErrDictItemNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, service.ErrDictConflict),
+		errors.Is(err, service.ErrDictItemValueConflict):
+		statusCode = http.StatusConflict
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": fmt.Sprintf("%s: %v", action, err),
+		})
+		return
+	}
+
+	c.JSON(statusCode, gin.H{
+		"code":  statusCode,
+		"error": err.Error(),
+	})
+}
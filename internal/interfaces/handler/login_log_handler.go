@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"gva/internal/domain/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginLogHandler struct {
+	loginLogService *service.LoginLogService
+}
+
+func NewLoginLogHandler(loginLogService *service.LoginLogService) *LoginLogHandler {
+	return &LoginLogHandler{loginLogService: loginLogService}
+}
+
+// ListLoginLogs 获取登录日志列表
+// 时间参数使用 RFC3339 格式，如 start_time=2024-01-01T00:00:00+08:00
+func (h *LoginLogHandler) ListLoginLogs(c *gin.Context) {
+	var req struct {
+		Page     int `form:"page" binding:"omitempty,min=1"`
+		PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+
+		UserID    uint      `form:"user_id"`                                                                                         // 用户ID
+		Username  string    `form:"username" binding:"max=64"`                                                                       // 尝试登录的用户名
+		Result    string    `form:"result" binding:"omitempty,oneof=success wrong_password not_found frozen disabled pending error"` // 登录结果
+		IP        string    `form:"ip" binding:"omitempty,ip"`                                                                       // 客户端IP
+		StartTime time.Time `form:"start_time"`                                                                                      // 开始时间（包含）
+		EndTime   time.Time `form:"end_time"`                                                                                        // 结束时间（不包含）
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+	if !req.StartTime.IsZero() && !req.EndTime.IsZero() && !req.StartTime.Before(req.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "时间范围错误",
+		})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	filter := service.LoginLogFilter{
+		UserID:   req.UserID,
+		Username: req.Username,
+		Result:   req.Result,
+		IP:       req.IP,
+	}
+	if !req.StartTime.IsZero() {
+		filter.StartTime = &req.StartTime
+	}
+	if !req.EndTime.IsZero() {
+		filter.EndTime = &req.EndTime
+	}
+
+	logs, total, err := h.loginLogService.List(c.Request.Context(), req.Page, req.PageSize, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"logs":  logs,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"gva/internal/domain/entity"
+	"gva/internal/domain/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageHandler struct {
+	messageService *service.MessageService
+}
+
+func NewMessageHandler(messageService *service.MessageService) *MessageHandler {
+	return &MessageHandler{messageService: messageService}
+}
+
+// messageTemplateRequest 创建/更新消息模板的请求参数
+type messageTemplateRequest struct {
+	Code             string                          `json:"code" binding:"required,min=2,max=64"`
+	Name             string                          `json:"name" binding:"required,min=2,max=64"`
+	Channels         []string                        `json:"channels" binding:"omitempty,dive,oneof=inapp email webhook"` // 默认投递渠道
+	NotificationType string                          `json:"notification_type" binding:"omitempty,dict=notification_type"`
+	Status           *int                            `json:"status" binding:"omitempty,oneof=0 1"`
+	Description      string                          `json:"description" binding:"max=256"`
+	Contents         []messageTemplateContentRequest `json:"contents" binding:"required,min=1,dive"`
+}
+
+// messageTemplateContentRequest 消息模板某一语言的内容
+type messageTemplateContentRequest struct {
+	Locale  string `json:"locale" binding:"required,max=16"`
+	Subject string `json:"subject" binding:"required,max=256"`
+	Body    string `json:"body" binding:"required"`
+}
+
+func (r *messageTemplateRequest) toEntity() *entity.MessageTemplate {
+	status := 1 // 默认启用
+	if r.Status != nil {
+		status = *r.Status
+	}
+	notificationType := r.NotificationType
+	if notificationType == "" {
+		notificationType = "system"
+	}
+	tpl := &entity.MessageTemplate{
+		Code:             r.Code,
+		Name:             r.Name,
+		Channels:         strings.Join(r.Channels, ","),
+		NotificationType: notificationType,
+		Status:           status,
+		Description:      r.Description,
+	}
+	for _, content := range r.Contents {
+		tpl.Contents = append(tpl.Contents, entity.MessageTemplateContent{
+			Locale:  content.Locale,
+			Subject: content.Subject,
+			Body:    content.Body,
+		})
+	}
+	return tpl
+}
+
+// ListTemplates 获取消息模板列表
+func (h *MessageHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.messageService.ListTemplates(c.Request.Context(), c.Query("keyword"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"templates": templates,
+		},
+	})
+}
+
+// GetTemplate 获取单个消息模板
+func (h *MessageHandler) GetTemplate(c *gin.Context) {
+	id, ok := parseMessageID(c, "无效的模板ID")
+	if !ok {
+		return
+	}
+
+	tpl, err := h.messageService.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		respondMessageError(c, err, "获取消息模板失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"template": tpl,
+		},
+	})
+}
+
+// CreateTemplate 创建消息模板
+func (h *MessageHandler) CreateTemplate(c *gin.Context) {
+	var req messageTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	tpl := req.toEntity()
+	if err := h.messageService.CreateTemplate(c.Request.Context(), tpl); err != nil {
+		respondMessageError(c, err, "创建消息模板失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "创建成功",
+		"data": gin.H{
+			"template": tpl,
+		},
+	})
+}
+
+// UpdateTemplate 更新消息模板
+func (h *MessageHandler) UpdateTemplate(c *gin.Context) {
+	id, ok := parseMessageID(c, "无效的模板ID")
+	if !ok {
+		return
+	}
+
+	var req messageTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	if err := h.messageService.UpdateTemplate(c.Request.Context(), id, req.toEntity()); err != nil {
+		respondMessageError(c, err, "更新消息模板失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "更新成功",
+	})
+}
+
+// DeleteTemplate 删除消息模板
+func (h *MessageHandler) DeleteTemplate(c *gin.Context) {
+	id, ok := parseMessageID(c, "无效的模板ID")
+	if !ok {
+		return
+	}
+
+	if err := h.messageService.DeleteTemplate(c.Request.Context(), id); err != nil {
+		respondMessageError(c, err, "删除消息模板失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "删除成功",
+	})
+}
+
+// SendMessage 按模板向指定用户发送消息
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	var req struct {
+		TemplateCode string                 `json:"template_code" binding:"required"`
+		UserIDs      []uint                 `json:"user_ids" binding:"required,min=1,dive,min=1"`
+		Channels     []string               `json:"channels" binding:"omitempty,dive,oneof=inapp email webhook"` // 为空时使用模板的默认渠道
+		Locale       string                 `json:"locale" binding:"max=16"`
+		Vars         map[string]interface{} `json:"vars"` // 模板变量
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	locale := req.Locale
+	if locale == "" {
+		locale = c.GetHeader("Accept-Language")
+		if i := strings.IndexAny(locale, ",;"); i >= 0 {
+			locale = locale[:i]
+		}
+	}
+
+	deliveries, err := h.messageService.Dispatch(c.Request.Context(), service.DispatchRequest{
+		TemplateCode: req.TemplateCode,
+		UserIDs:      req.UserIDs,
+		Channels:     req.Channels,
+		Locale:       strings.TrimSpace(locale),
+		Vars:         req.Vars,
+	})
+	if err != nil {
+		respondMessageError(c, err, "发送消息失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "已提交发送",
+		"data": gin.H{
+			"deliveries": deliveries,
+		},
+	})
+}
+
+// ListDeliveries 分页查询消息投递记录
+func (h *MessageHandler) ListDeliveries(c *gin.Context) {
+	var req struct {
+		Page         int    `form:"page" binding:"omitempty,min=1"`
+		PageSize     int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+		UserID       uint   `form:"user_id"`
+		TemplateCode string `form:"template_code"`
+		Channel      string `form:"channel"`
+		Status       *int   `form:"status" binding:"omitempty,oneof=0 1 2"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	deliveries, total, err := h.messageService.ListDeliveries(c.Request.Context(), req.Page, req.PageSize, service.DeliveryFilter{
+		UserID:       req.UserID,
+		TemplateCode: req.TemplateCode,
+		Channel:      req.Channel,
+		Status:       req.Status,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"deliveries": deliveries,
+			"total":      total,
+			"page":       req.Page,
+			"size":       req.PageSize,
+		},
+	})
+}
+
+// RetryDelivery 立即重新投递一条未成功的消息
+func (h *MessageHandler) RetryDelivery(c *gin.Context) {
+	id, ok := parseMessageID(c, "无效的投递记录ID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.messageService.Retry(c.Request.Context(), id)
+	if err != nil && delivery == nil {
+		respondMessageError(c, err, "重新投递失败")
+		return
+	}
+
+	// 投递本身失败时仍返回最新的投递记录，失败原因见 last_error
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "已重新投递",
+		"data": gin.H{
+			"delivery": delivery,
+		},
+	})
+}
+
+// parseMessageID 解析路径中的ID，解析失败时直接返回400
+func parseMessageID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": message,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondMessageError 将消息服务的错误转换为对应的HTTP状态码
+func respondMessageError(c *gin.Context, err error, action string) {
+	var statusCode int
+	switch {
+	case errors.Is(err, service.ErrMessageTemplateNotFound),
+		errors.Is(err, service.ErrMessageDeliveryNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, service.ErrMessageTemplateConflict):
+		statusCode = http.StatusConflict
+	case errors.Is(err, service.ErrMessageTemplateDisabled),
+		errors.Is(err, service.ErrMessageTemplateInvalid),
+		errors.Is(err, service.ErrMessageChannel),
+		errors.Is(err, service.ErrNotificationNoReceivers):
+		statusCode = http.StatusBadRequest
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": fmt.Sprintf("%s: %v", action, err),
+		})
+		return
+	}
+
+	c.JSON(statusCode, gin.H{
+		"code":  statusCode,
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"errors"
+	"gva/internal/domain/entity"
+	"gva/internal/domain/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// SendNotification 编写并发送通知，可发送给指定用户、角色、部门或全体用户，支持定时发送
+func (h *NotificationHandler) SendNotification(c *gin.Context) {
+	var req struct {
+		Title         string     `json:"title" binding:"required,max=128"`
+		Content       string     `json:"content" binding:"required"`
+		Type          string     `json:"type" binding:"required,dict=notification_type"`
+		TargetType    string     `json:"target_type" binding:"omitempty,oneof=user all role department"` // 接收对象类型，默认为指定用户
+		UserIDs       []uint     `json:"user_ids" binding:"omitempty,dive,min=1"`
+		RoleCodes     []string   `json:"role_codes" binding:"omitempty,dive,required,max=64"`
+		DepartmentIDs []uint     `json:"department_ids" binding:"omitempty,dive,min=1"`
+		ScheduledAt   *time.Time `json:"scheduled_at"` // 定时发送时间，为空时立即发送
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	notification := &entity.Notification{
+		Title:       req.Title,
+		Content:     req.Content,
+		Type:        req.Type,
+		ScheduledAt: req.ScheduledAt,
+	}
+	target := service.NotificationTarget{
+		Type:          req.TargetType,
+		UserIDs:       req.UserIDs,
+		RoleCodes:     req.RoleCodes,
+		DepartmentIDs: req.DepartmentIDs,
+	}
+	if err := h.notificationService.Send(c.Request.Context(), userID.(uint), notification, target); err != nil {
+		if errors.Is(err, service.ErrNotificationNoReceivers) || errors.Is(err, service.ErrNotificationTarget) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "发送成功"
+	if notification.Status == entity.NotificationStatusScheduled {
+		message = "已创建定时通知"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      message,
+		"notification": notification,
+	})
+}
+
+// ListNotifications 获取已发送的通知列表
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	page, pageSize, ok := bindPage(c)
+	if !ok {
+		return
+	}
+
+	notifications, total, err := h.notificationService.List(c.Request.Context(), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"total":         total,
+		"page":          page,
+		"size":          pageSize,
+	})
+}
+
+// Inbox 获取当前用户的收件箱
+func (h *NotificationHandler) Inbox(c *gin.Context) {
+	var req struct {
+		Page       int  `form:"page" binding:"omitempty,min=1"`
+		PageSize   int  `form:"page_size" binding:"omitempty,min=1,max=100"`
+		ReadStatus *int `form:"read_status" binding:"omitempty,oneof=0 1"` // 阅读状态
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	items, total, err := h.notificationService.Inbox(c.Request.Context(), userID.(uint), req.Page, req.PageSize, req.ReadStatus)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": items,
+		"total":         total,
+		"page":          req.Page,
+		"size":          req.PageSize,
+	})
+}
+
+// UnreadCount 获取当前用户的未读通知数量
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	count, err := h.notificationService.UnreadCount(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// MarkRead 将一条通知标记为已读
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的通知ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), userID.(uint), uint(notificationID)); err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已标记为已读"})
+}
+
+// MarkAllRead 将当前用户的所有通知标记为已读
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	count, err := h.notificationService.MarkAllRead(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已全部标记为已读",
+		"count":   count,
+	})
+}
+
+// DeleteNotification 从当前用户的收件箱中删除通知
+func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的通知ID"})
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证"})
+		return
+	}
+
+	if err := h.notificationService.Delete(c.Request.Context(), userID.(uint), uint(notificationID)); err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// bindPage 绑定分页参数并设置默认值
+func bindPage(c *gin.Context) (int, int, bool) {
+	var req struct {
+		Page     int `form:"page" binding:"omitempty,min=1"`
+		PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return 0, 0, false
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+	return req.Page, req.PageSize, true
+}
+
+func respondNotificationError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrNotificationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
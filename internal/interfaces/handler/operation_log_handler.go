@@ -1,31 +1,58 @@
 package handler
 
 import (
+	"errors"
+	"gva/internal/domain/repository"
 	"gva/internal/domain/service"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type OperationLogHandler struct {
-	logService *service.OperationLogService
+	logService  *service.OperationLogService
+	logArchiver *service.OperationLogArchiver
 }
 
-func NewOperationLogHandler(logService *service.OperationLogService) *OperationLogHandler {
-	return &OperationLogHandler{logService: logService}
+func NewOperationLogHandler(logService *service.OperationLogService, logArchiver *service.OperationLogArchiver) *OperationLogHandler {
+	return &OperationLogHandler{logService: logService, logArchiver: logArchiver}
 }
 
-// ListLogs 获取操作日志列表
+// ListLogs 获取操作日志列表（支持筛选和排序）
+// 时间参数使用 RFC3339 格式，如 start_time=2024-01-01T00:00:00+08:00
 func (h *OperationLogHandler) ListLogs(c *gin.Context) {
 	var req struct {
 		Page     int `form:"page" binding:"omitempty,min=1"`
 		PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+
+		UserID     *uint     `form:"user_id"`                                                     // 用户ID
+		Username   string    `form:"username" binding:"max=64"`                                   // 用户名（模糊匹配）
+		Method     string    `form:"method" binding:"omitempty,oneof=GET POST PUT PATCH DELETE"`  // 请求方法
+		Path       string    `form:"path" binding:"max=255"`                                      // 请求路径前缀
+		StatusMin  *int      `form:"status_min" binding:"omitempty,min=100,max=599"`              // 最小状态码
+		StatusMax  *int      `form:"status_max" binding:"omitempty,min=100,max=599"`              // 最大状态码
+		IP         string    `form:"ip" binding:"omitempty,ip"`                                   // 客户端IP
+		MinLatency *int64    `form:"min_latency" binding:"omitempty,min=0"`                       // 最小耗时（毫秒）
+		StartTime  time.Time `form:"start_time"`                                                  // 开始时间（包含）
+		EndTime    time.Time `form:"end_time"`                                                    // 结束时间（不包含）
+		SortBy     string    `form:"sort_by" binding:"omitempty,oneof=created_at latency status"` // 排序字段，默认按时间倒序
+		Order      string    `form:"order" binding:"omitempty,oneof=asc desc"`                    // 排序方向，默认 desc
 	}
 
 	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
 		return
 	}
+	if req.StatusMin != nil && req.StatusMax != nil && *req.StatusMin > *req.StatusMax {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "状态码范围错误"})
+		return
+	}
+	if !req.StartTime.IsZero() && !req.EndTime.IsZero() && !req.StartTime.Before(req.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "时间范围错误"})
+		return
+	}
 
 	// 设置默认值
 	if req.Page <= 0 {
@@ -35,7 +62,26 @@ func (h *OperationLogHandler) ListLogs(c *gin.Context) {
 		req.PageSize = 10
 	}
 
-	logs, total, err := h.logService.List(c.Request.Context(), req.Page, req.PageSize)
+	filter := repository.OperationLogFilter{
+		UserID:     req.UserID,
+		Username:   req.Username,
+		Method:     req.Method,
+		PathPrefix: req.Path,
+		StatusMin:  req.StatusMin,
+		StatusMax:  req.StatusMax,
+		IP:         req.IP,
+		MinLatency: req.MinLatency,
+		SortBy:     req.SortBy,
+		SortDesc:   req.Order != "asc",
+	}
+	if !req.StartTime.IsZero() {
+		filter.StartTime = &req.StartTime
+	}
+	if !req.EndTime.IsZero() {
+		filter.EndTime = &req.EndTime
+	}
+
+	logs, total, err := h.logService.List(c.Request.Context(), req.Page, req.PageSize, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -48,3 +94,61 @@ func (h *OperationLogHandler) ListLogs(c *gin.Context) {
 		"size":  req.PageSize,
 	})
 }
+
+// GetLogStats 获取操作日志写入队列的统计信息，包括丢弃和写入失败的条数
+func (h *OperationLogHandler) GetLogStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"stats": h.logService.Stats(),
+	})
+}
+
+// ArchiveLogs 立即将超过保留天数的日志归档后删除，days 为空时使用配置的保留天数
+func (h *OperationLogHandler) ArchiveLogs(c *gin.Context) {
+	var req struct {
+		Days int `json:"days" binding:"omitempty,min=1,max=3650"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	result, err := h.logArchiver.Archive(c.Request.Context(), req.Days)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrArchiveRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "归档完成",
+		"result":  result,
+	})
+}
+
+// ListArchives 获取归档文件列表
+func (h *OperationLogHandler) ListArchives(c *gin.Context) {
+	files, err := h.logArchiver.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"archives": files,
+	})
+}
+
+// DownloadArchive 下载归档文件（gzip 压缩的 JSON Lines，每行一条日志）
+func (h *OperationLogHandler) DownloadArchive(c *gin.Context) {
+	name := c.Param("name")
+	path, err := h.logArchiver.Path(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.FileAttachment(path, name)
+}
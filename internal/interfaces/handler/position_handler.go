@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"gva/internal/domain/entity"
+	"gva/internal/domain/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PositionHandler struct {
+	positionService *service.PositionService
+}
+
+func NewPositionHandler(positionService *service.PositionService) *PositionHandler {
+	return &PositionHandler{positionService: positionService}
+}
+
+// positionRequest 创建/更新岗位的请求参数
+type positionRequest struct {
+	Code         string `json:"code" binding:"required,min=2,max=64"`
+	Name         string `json:"name" binding:"required,min=2,max=64"`
+	DepartmentID *uint  `json:"department_id"`
+	Sort         int    `json:"sort"`
+	Status       *int   `json:"status" binding:"omitempty,oneof=0 1"`
+	Description  string `json:"description" binding:"max=256"`
+}
+
+func (r *positionRequest) toEntity() *entity.Position {
+	status := 1 // 默认启用
+	if r.Status != nil {
+		status = *r.Status
+	}
+	departmentID := r.DepartmentID
+	if departmentID != nil && *departmentID == 0 {
+		departmentID = nil
+	}
+	return &entity.Position{
+		Code:         r.Code,
+		Name:         r.Name,
+		DepartmentID: departmentID,
+		Sort:         r.Sort,
+		Status:       status,
+		Description:  r.Description,
+	}
+}
+
+// ListPositions 获取岗位列表
+func (h *PositionHandler) ListPositions(c *gin.Context) {
+	var req struct {
+		Keyword      string `form:"keyword"`
+		Status       *int   `form:"status"`
+		DepartmentID *uint  `form:"department_id"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	positions, err := h.positionService.List(c.Request.Context(), req.Keyword, req.Status, req.DepartmentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"positions": positions,
+		},
+	})
+}
+
+// GetPosition 获取单个岗位信息
+func (h *PositionHandler) GetPosition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的岗位ID",
+		})
+		return
+	}
+
+	position, err := h.positionService.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		respondPositionError(c, err, "获取岗位信息失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"position": position,
+		},
+	})
+}
+
+// CreatePosition 创建岗位
+func (h *PositionHandler) CreatePosition(c *gin.Context) {
+	var req positionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	position := req.toEntity()
+	if err := h.positionService.Create(c.Request.Context(), position); err != nil {
+		respondPositionError(c, err, "创建岗位失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "创建成功",
+		"data": gin.H{
+			"position": position,
+		},
+	})
+}
+
+// UpdatePosition 更新岗位信息
+func (h *PositionHandler) UpdatePosition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的岗位ID",
+		})
+		return
+	}
+
+	var req positionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	if err := h.positionService.Update(c.Request.Context(), uint(id), req.toEntity()); err != nil {
+		respondPositionError(c, err, "更新岗位失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "更新成功",
+	})
+}
+
+// DeletePosition 删除岗位
+func (h *PositionHandler) DeletePosition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的岗位ID",
+		})
+		return
+	}
+
+	if err := h.positionService.Delete(c.Request.Context(), uint(id)); err != nil {
+		respondPositionError(c, err, "删除岗位失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "删除成功",
+	})
+}
+
+// respondPositionError 将岗位服务的错误转换为对应的HTTP状态码
+func respondPositionError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, service.ErrPositionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":  http.StatusNotFound,
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrPositionDepartment):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrPositionCodeConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"code":  http.StatusConflict,
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": fmt.Sprintf("%s: %v", action, err),
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"gva/internal/domain/entity"
+	"gva/internal/domain/service"
+	infraPush "gva/internal/infrastructure/push"
+	"gva/internal/pkg/jwt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// tokenProtocol 浏览器通过 new WebSocket(url, ["bearer", token]) 在 Sec-WebSocket-Protocol 头中传递JWT，
+// 服务端选择该子协议完成握手
+const tokenProtocol = "bearer"
+
+type PushHandler struct {
+	hub         *infraPush.Hub
+	userService *service.UserService
+	upgrader    websocket.Upgrader
+}
+
+func NewPushHandler(hub *infraPush.Hub, userService *service.UserService) *PushHandler {
+	return &PushHandler{
+		hub:         hub,
+		userService: userService,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// 与 CORS 配置保持一致，允许所有来源
+			CheckOrigin:  func(r *http.Request) bool { return true },
+			Subprotocols: []string{tokenProtocol},
+		},
+	}
+}
+
+// Connect 建立实时推送的WebSocket连接
+// 浏览器无法为WebSocket设置 Authorization 头，应通过 Sec-WebSocket-Protocol 头传递JWT（见 tokenProtocol）；
+// 兼容旧客户端仍支持 ?token=，访问日志会脱敏该参数；
+// 重连时通过 ?last_id= 传递最后收到的消息ID以补发错过的消息
+func (h *PushHandler) Connect(c *gin.Context) {
+	token := c.Query("token")
+	if protocols := websocket.Subprotocols(c.Request); len(protocols) == 2 && protocols[0] == tokenProtocol {
+		token = protocols[1]
+	}
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		token = strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供认证信息"})
+		return
+	}
+
+	claims, err := jwt.ParseToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
+		return
+	}
+
+	// 被禁用、冻结或删除的用户不允许建立连接
+	user, err := h.userService.GetUserByID(c.Request.Context(), claims.UserID)
+	if err != nil || user.Status != entity.UserStatusNormal {
+		c.JSON(http.StatusForbidden, gin.H{"error": "账号不可用"})
+		return
+	}
+
+	var lastID int64
+	if v := c.Query("last_id"); v != "" {
+		if lastID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的消息ID"})
+			return
+		}
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已经写入了错误响应
+		log.Printf("WebSocket升级失败: %v", err)
+		return
+	}
+
+	h.hub.Serve(c.Request.Context(), conn, user.ID, lastID)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"gva/internal/domain/service"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPolicySize 导入的策略文档大小上限
+const maxPolicySize = 2 << 20 // 2MB
+
+type RBACHandler struct {
+	rbacService *service.RBACService
+}
+
+func NewRBACHandler(rbacService *service.RBACService) *RBACHandler {
+	return &RBACHandler{rbacService: rbacService}
+}
+
+// ExportPolicy 导出 RBAC 策略（YAML）
+func (h *RBACHandler) ExportPolicy(c *gin.Context) {
+	data, err := h.rbacService.Export(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": fmt.Sprintf("导出策略失败: %v", err),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("rbac_policy_%s.yaml", time.Now().Format("20060102_150405"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/x-yaml; charset=utf-8", data)
+}
+
+// ImportPolicy 导入 RBAC 策略，支持上传文件（file 字段）或直接提交 YAML 请求体
+// 查询参数 dry_run=true 时只返回差异，不做修改
+func (h *RBACHandler) ImportPolicy(c *gin.Context) {
+	var req struct {
+		DryRun bool `form:"dry_run"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	var reader io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":  http.StatusInternalServerError,
+				"error": err.Error(),
+			})
+			return
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxPolicySize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "读取策略文档失败",
+		})
+		return
+	}
+	if len(data) > maxPolicySize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "策略文档不能超过2MB",
+		})
+		return
+	}
+
+	result, err := h.rbacService.Import(c.Request.Context(), data, req.DryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPolicy) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":  http.StatusBadRequest,
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": fmt.Sprintf("导入策略失败: %v", err),
+		})
+		return
+	}
+
+	message := "导入成功"
+	if req.DryRun {
+		message = "预览成功，未做任何修改"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": message,
+		"data":    result,
+	})
+}
@@ -1,12 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"gva/internal/domain/entity"
 	"gva/internal/domain/service"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -191,6 +191,13 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 
 	// 调用服务更新角色
 	if err := h.roleService.UpdateRole(c.Request.Context(), uint(roleID), role); err != nil {
+		if errors.Is(err, service.ErrSystemRoleCodeProtected) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":  http.StatusForbidden,
+				"error": err.Error(),
+			})
+			return
+		}
 		if err.Error() == "角色不存在" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"code":  http.StatusNotFound,
@@ -198,7 +205,7 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 			})
 			return
 		}
-		if strings.Contains(err.Error(), "已存在") {
+		if errors.Is(err, service.ErrRoleExists) {
 			c.JSON(http.StatusConflict, gin.H{
 				"code":  http.StatusConflict,
 				"error": err.Error(),
@@ -229,16 +236,103 @@ func (h *RoleHandler) DeleteRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.roleService.DeleteRole(c.Request.Context(), uint(roleID)); err != nil {
+	// 角色下仍有用户时，需要指定用户转移到的角色
+	var req struct {
+		TransferRoleID uint `form:"transfer_role_id"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的转移角色ID",
+		})
+		return
+	}
+
+	if err := h.roleService.DeleteRole(c.Request.Context(), uint(roleID), req.TransferRoleID); err != nil {
+		var statusCode int
+		switch {
+		case errors.Is(err, service.ErrRoleNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, service.ErrSystemRoleProtected):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, service.ErrRoleInUse):
+			statusCode = http.StatusConflict
+		case errors.Is(err, service.ErrInvalidTransferRole):
+			statusCode = http.StatusBadRequest
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":  http.StatusInternalServerError,
+				"error": fmt.Sprintf("删除角色失败: %v", err),
+			})
+			return
+		}
+
+		c.JSON(statusCode, gin.H{
+			"code":  statusCode,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "删除成功",
+	})
+}
+
+// CloneRole 复制角色及其权限
+func (h *RoleHandler) CloneRole(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的角色ID",
+		})
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name" binding:"required,min=2,max=50"`
+		Code        string `json:"code" binding:"required,min=2,max=50"`
+		Description string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	role, err := h.roleService.CloneRole(c.Request.Context(), uint(roleID), req.Name, req.Code, req.Description)
+	if err != nil {
+		if errors.Is(err, service.ErrRoleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":  http.StatusNotFound,
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, service.ErrRoleExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"code":  http.StatusConflict,
+				"error": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":  http.StatusInternalServerError,
-			"error": fmt.Sprintf("删除角色失败: %v", err),
+			"error": fmt.Sprintf("复制角色失败: %v", err),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    http.StatusOK,
-		"message": "删除成功",
+		"message": "复制成功",
+		"data": gin.H{
+			"role": role,
+		},
 	})
 }
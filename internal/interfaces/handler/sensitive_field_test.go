@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/service"
+	"gva/internal/infrastructure/database"
+	"gva/internal/infrastructure/repository"
+	"gva/internal/interfaces/validator"
+	"gva/internal/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	testPhone       = "13812345678"
+	testEmail       = "alice@example.com"
+	testMaskedPhone = "138****5678"
+	testMaskedEmail = "a***@example.com"
+)
+
+// setupSensitiveFieldRouter 注册返回用户信息的接口，viewer 的角色拥有 permissions 中的权限
+func setupSensitiveFieldRouter(t *testing.T, permissions ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(db))
+
+	role := entity.Role{Name: "查看者", Code: "viewer", Status: 1}
+	for _, code := range permissions {
+		role.Permissions = append(role.Permissions, entity.Permission{Name: code, Code: code, Type: "button", Status: 1})
+	}
+	require.NoError(t, db.Create(&role).Error)
+	viewer := entity.User{Username: "viewer", Password: "x", Status: 1, RoleID: role.ID}
+	require.NoError(t, db.Create(&viewer).Error)
+
+	alice := entity.User{Username: "alice", Password: "x", Status: 1, Phone: testPhone, Email: testEmail}
+	require.NoError(t, db.Create(&alice).Error)
+	require.NoError(t, db.Create(&entity.Notification{Title: "通知", Content: "内容", Type: "system", SenderID: alice.ID}).Error)
+	require.NoError(t, db.Create(&entity.OperationLog{UserID: alice.ID, Method: "GET", Path: "/api/v1/users", Status: 200}).Error)
+
+	dictService := service.NewDictService(db, nil)
+	require.NoError(t, validator.RegisterDictValidation(dictService))
+	userService := service.NewUserService(repository.NewUserRepository(db), db, nil, nil)
+	logService := service.NewOperationLogService(repository.NewOperationLogRepository(db), config.OperationLogConfig{})
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", viewer.ID)
+		c.Set("permissionService", service.NewPermissionService(db))
+		c.Next()
+	})
+	userHandler := NewUserHandler(userService, dictService)
+	r.GET("/users", userHandler.ListUsers)
+	r.GET("/users/:id/profile", userHandler.GetUserProfile)
+	r.GET("/notifications", NewNotificationHandler(service.NewNotificationService(db, nil)).ListNotifications)
+	r.GET("/logs", NewOperationLogHandler(logService, nil).ListLogs)
+	return r
+}
+
+func getBody(t *testing.T, r *gin.Engine, path string) string {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	return w.Body.String()
+}
+
+// 没有字段级权限时，所有返回用户信息的接口都只返回脱敏后的手机号和邮箱
+func TestSensitiveFieldsMaskedWithoutPermission(t *testing.T) {
+	r := setupSensitiveFieldRouter(t)
+
+	for _, path := range []string{"/users", "/users/2/profile", "/notifications", "/logs"} {
+		body := getBody(t, r, path)
+		assert.Contains(t, body, testMaskedPhone, path)
+		assert.Contains(t, body, testMaskedEmail, path)
+		assert.NotContains(t, body, testPhone, path)
+		assert.NotContains(t, body, testEmail, path)
+	}
+}
+
+// 拥有字段级权限时只显示对应字段的明文
+func TestSensitiveFieldsRevealedWithPermission(t *testing.T) {
+	r := setupSensitiveFieldRouter(t, userFieldPermissionPrefix+entity.UserFieldPhone)
+
+	for _, path := range []string{"/users", "/users/2/profile"} {
+		body := getBody(t, r, path)
+		assert.Contains(t, body, testPhone, path)
+		assert.Contains(t, body, testMaskedEmail, path)
+		assert.NotContains(t, body, testEmail, path)
+	}
+}
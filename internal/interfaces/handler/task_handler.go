@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gva/internal/domain/entity"
+	"gva/internal/domain/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaskHandler struct {
+	taskService *service.TaskService
+}
+
+func NewTaskHandler(taskService *service.TaskService) *TaskHandler {
+	return &TaskHandler{taskService: taskService}
+}
+
+// taskRequest 创建/更新定时任务的请求参数
+type taskRequest struct {
+	Name        string          `json:"name" binding:"required,min=2,max=64"`
+	Cron        string          `json:"cron" binding:"required,max=64"`
+	Handler     string          `json:"handler" binding:"required,max=64"`    // 任务类型，可选值通过 GET /tasks/handlers 获取
+	Params      json.RawMessage `json:"params"`                               // 任务参数，按任务类型的参数定义校验
+	Status      *int            `json:"status" binding:"omitempty,oneof=0 1"` // 仅创建时有效
+	Description string          `json:"description" binding:"max=256"`
+
+	OwnerID       uint   `json:"owner_id"`                                                  // 负责人，创建时默认为当前用户，更新时为空表示不修改
+	Timeout       int    `json:"timeout" binding:"min=0,max=86400"`                         // 单次执行超时时间（秒），0 表示不限制
+	MaxRetries    int    `json:"max_retries" binding:"min=0,max=10"`                        // 最大重试次数
+	RetryInterval int    `json:"retry_interval" binding:"min=0,max=3600"`                   // 第一次重试前的等待时间（秒）
+	OverlapPolicy string `json:"overlap_policy" binding:"omitempty,oneof=skip queue allow"` // 重叠执行策略，默认 skip
+}
+
+func (r *taskRequest) toEntity() *entity.Task {
+	status := 1 // 默认启用
+	if r.Status != nil {
+		status = *r.Status
+	}
+	return &entity.Task{
+		Name:        r.Name,
+		Cron:        r.Cron,
+		Handler:     r.Handler,
+		Params:      r.Params,
+		Status:      status,
+		Description: r.Description,
+
+		OwnerID:       r.OwnerID,
+		Timeout:       r.Timeout,
+		MaxRetries:    r.MaxRetries,
+		RetryInterval: r.RetryInterval,
+		OverlapPolicy: r.OverlapPolicy,
+	}
+}
+
+// ListTasks 分页获取定时任务列表
+func (h *TaskHandler) ListTasks(c *gin.Context) {
+	var req struct {
+		Page     int    `form:"page" binding:"omitempty,min=1"`
+		PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+		Keyword  string `form:"keyword"`
+		Status   *int   `form:"status" binding:"omitempty,oneof=0 1"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	tasks, total, err := h.taskService.List(c.Request.Context(), req.Page, req.PageSize, req.Keyword, req.Status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"tasks": tasks,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+// GetTask 获取单个定时任务
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.taskService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondTaskError(c, err, "获取定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"task": task,
+		},
+	})
+}
+
+// CreateTask 创建定时任务
+func (h *TaskHandler) CreateTask(c *gin.Context) {
+	var req taskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	task := req.toEntity()
+	if task.OwnerID == 0 {
+		if userID, exists := c.Get("userID"); exists {
+			task.OwnerID = userID.(uint)
+		}
+	}
+	if err := h.taskService.Create(c.Request.Context(), task); err != nil {
+		respondTaskError(c, err, "创建定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "创建成功",
+		"data": gin.H{
+			"task": task,
+		},
+	})
+}
+
+// UpdateTask 更新定时任务
+func (h *TaskHandler) UpdateTask(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	var req taskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+
+	if err := h.taskService.Update(c.Request.Context(), id, req.toEntity()); err != nil {
+		respondTaskError(c, err, "更新定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "更新成功",
+	})
+}
+
+// DeleteTask 删除定时任务
+func (h *TaskHandler) DeleteTask(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.Delete(c.Request.Context(), id); err != nil {
+		respondTaskError(c, err, "删除定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "删除成功",
+	})
+}
+
+// PauseTask 暂停定时任务
+func (h *TaskHandler) PauseTask(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.Pause(c.Request.Context(), id); err != nil {
+		respondTaskError(c, err, "暂停定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "已暂停",
+	})
+}
+
+// ResumeTask 恢复定时任务
+func (h *TaskHandler) ResumeTask(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.Resume(c.Request.Context(), id); err != nil {
+		respondTaskError(c, err, "恢复定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "已恢复",
+	})
+}
+
+// RunTask 立即执行一次定时任务，执行结果通过任务日志查询
+func (h *TaskHandler) RunTask(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	taskLog, err := h.taskService.RunNow(c.Request.Context(), id)
+	if err != nil {
+		respondTaskError(c, err, "执行定时任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "已开始执行",
+		"data": gin.H{
+			"log": taskLog,
+		},
+	})
+}
+
+// ListTaskLogs 分页获取定时任务的执行日志
+func (h *TaskHandler) ListTaskLogs(c *gin.Context) {
+	id, ok := parseTaskID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Page     int  `form:"page" binding:"omitempty,min=1"`
+		PageSize int  `form:"page_size" binding:"omitempty,min=1,max=100"`
+		Status   *int `form:"status" binding:"omitempty,oneof=0 1 2 3"` // 执行状态
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	logs, total, err := h.taskService.Logs(c.Request.Context(), id, req.Page, req.PageSize, req.Status)
+	if err != nil {
+		respondTaskError(c, err, "获取任务日志失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"logs":  logs,
+			"total": total,
+			"page":  req.Page,
+			"size":  req.PageSize,
+		},
+	})
+}
+
+// ListTaskHandlers 获取可用的任务类型及其参数定义
+func (h *TaskHandler) ListTaskHandlers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"handlers": h.taskService.Handlers(),
+		},
+	})
+}
+
+// PreviewCron 校验cron表达式并预览接下来的执行时间，如 ?cron=0 */5 * * * *&count=5
+func (h *TaskHandler) PreviewCron(c *gin.Context) {
+	var req struct {
+		Cron  string `form:"cron" binding:"required"`
+		Count int    `form:"count" binding:"omitempty,min=1,max=20"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "请求参数错误",
+		})
+		return
+	}
+	if req.Count <= 0 {
+		req.Count = 5
+	}
+
+	times, err := h.taskService.Preview(req.Cron, req.Count)
+	if err != nil {
+		respondTaskError(c, err, "预览执行时间失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": http.StatusOK,
+		"data": gin.H{
+			"next_run_times": times,
+		},
+	})
+}
+
+// parseTaskID 解析路径中的任务ID，解析失败时直接返回400
+func parseTaskID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "无效的任务ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondTaskError 将定时任务服务的错误转换为对应的HTTP状态码
+func respondTaskError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":  http.StatusNotFound,
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrTaskCron), errors.Is(err, service.ErrTaskHandler):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrTaskRunning):
+		c.JSON(http.StatusConflict, gin.H{
+			"code":  http.StatusConflict,
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": fmt.Sprintf("%s: %v", action, err),
+		})
+	}
+}
@@ -3,6 +3,8 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"gva/internal/domain/entity"
+	"gva/internal/domain/repository"
 	"gva/internal/domain/service"
 	"gva/internal/pkg/upload"
 	"log"
@@ -17,12 +19,22 @@ import (
 	"github.com/spf13/viper"
 )
 
+// userFieldPermissionPrefix 用户敏感字段的字段级权限前缀，如 system:user:field:phone
+const userFieldPermissionPrefix = "system:user:field:"
+
+// recentLoginLimit 用户信息中返回的最近登录记录条数
+const recentLoginLimit = 10
+
 type UserHandler struct {
 	userService *service.UserService
+	dictService *service.DictService
 }
 
-func NewUserHandler(userService *service.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *service.UserService, dictService *service.DictService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		dictService: dictService,
+	}
 }
 
 func (h *UserHandler) Register(c *gin.Context) {
@@ -56,9 +68,10 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	fmt.Print("11user.Password", req.Password)
-
-	user, token, err := h.userService.Login(c.Request.Context(), req.Username, req.Password)
+	user, token, err := h.userService.Login(c.Request.Context(), req.Username, req.Password, service.LoginClient{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
 	if err != nil {
 		var statusCode int
 		var message string
@@ -153,6 +166,8 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		req.Email,
 		req.Phone,
 		req.RoleID,
+		nil, // 个人资料不修改所属部门
+		nil, // 个人资料不修改岗位
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -235,6 +250,28 @@ func (h *UserHandler) GetUserInfo(c *gin.Context) {
 		return
 	}
 
+	// 获取部门链（从顶级部门到所属部门）
+	departmentChain, err := h.userService.GetDepartmentChain(c.Request.Context(), user.Department)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取用户信息失败: %v", err)})
+		return
+	}
+	departments := make([]gin.H, 0, len(departmentChain))
+	for _, d := range departmentChain {
+		departments = append(departments, gin.H{
+			"id":   d.ID,
+			"name": d.Name,
+			"code": d.Code,
+		})
+	}
+
+	// 最近的登录记录，便于用户发现异常登录
+	recentLogins, err := h.userService.RecentLogins(c.Request.Context(), user.ID, recentLoginLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取用户信息失败: %v", err)})
+		return
+	}
+
 	// 返回用户信息
 	c.JSON(http.StatusOK, gin.H{
 		"code": http.StatusOK,
@@ -253,7 +290,11 @@ func (h *UserHandler) GetUserInfo(c *gin.Context) {
 					"code":        user.Role.Code,
 					"permissions": user.Role.Permissions,
 				},
+				"department_id":    user.DepartmentID,
+				"department_chain": departments,
+				"positions":        user.Positions,
 			},
+			"recent_logins": recentLogins,
 		},
 	})
 }
@@ -263,9 +304,13 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	var req struct {
 		Page     int    `form:"page" binding:"omitempty,min=1"`
 		PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
-		Keyword  string `form:"keyword"` // 搜索关键词
-		Status   *int   `form:"status"`  // 用户状态
-		RoleID   *uint  `form:"role_id"` // 角色ID
+		Keyword  string `form:"keyword"`                                     // 搜索关键词
+		Status   *int   `form:"status" binding:"omitempty,dict=user_status"` // 用户状态
+		RoleID   *uint  `form:"role_id"`                                     // 角色ID
+
+		DepartmentID    *uint `form:"department_id"`    // 部门ID
+		IncludeChildren bool  `form:"include_children"` // 是否包含下级部门的用户
+		PositionID      *uint `form:"position_id"`      // 岗位ID
 	}
 
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -286,14 +331,22 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		c.Request.Context(),
 		req.Page,
 		req.PageSize,
-		req.Keyword,
-		req.Status,
+		repository.UserFilter{
+			Keyword:               req.Keyword,
+			Status:                req.Status,
+			RoleID:                req.RoleID,
+			DepartmentID:          req.DepartmentID,
+			IncludeSubDepartments: req.IncludeChildren,
+			PositionID:            req.PositionID,
+		},
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	revealSensitiveFields(c, users...)
+
 	c.JSON(http.StatusOK, gin.H{
 		"users": users,
 		"total": total,
@@ -314,7 +367,7 @@ func (h *UserHandler) UpdateUserStatus(c *gin.Context) {
 
 	// 再获取请求体
 	var req struct {
-		Status int `json:"status" binding:"required,oneof=0 1 2"`
+		Status *int `json:"status" binding:"required,dict=user_status"` // 取值必须是字典 user_status 中已启用的字典项
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -323,9 +376,9 @@ func (h *UserHandler) UpdateUserStatus(c *gin.Context) {
 		return
 	}
 
-	log.Printf("准备更新用户状态 - UserID: %d, Status: %d", userID, req.Status)
+	log.Printf("准备更新用户状态 - UserID: %d, Status: %d", userID, *req.Status)
 
-	if err := h.userService.UpdateUserStatus(c.Request.Context(), uint(userID), req.Status); err != nil {
+	if err := h.userService.UpdateUserStatus(c.Request.Context(), uint(userID), *req.Status); err != nil {
 		log.Printf("更新用户状态失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -361,6 +414,8 @@ func (h *UserHandler) ExportUsers(c *gin.Context) {
 		return
 	}
 
+	revealSensitiveFields(c, users...)
+
 	// 使用配置的导出目录
 	exportDir := viper.GetString("export.dir")
 	if exportDir == "" {
@@ -386,20 +441,45 @@ func (h *UserHandler) ExportUsers(c *gin.Context) {
 	defer file.Close()
 
 	// 写入CSV头
-	file.Write([]byte("ID,用户名,昵称,邮箱,手机号,状态,创建时间\n"))
+	file.Write([]byte("ID,用户名,昵称,邮箱,手机号,状态,部门编码,部门,岗位编码,岗位,创建时间\n"))
+
+	// 用户状态名称取自数据字典
+	statusLabels := make(map[string]string)
+	if statusDict, err := h.dictService.GetEnabledDict(c.Request.Context(), entity.UserStatusDictCode); err != nil {
+		log.Printf("获取用户状态字典失败: %v", err)
+	} else {
+		for _, item := range statusDict.Items {
+			statusLabels[item.Value] = item.Label
+		}
+	}
 
 	// 写入数据
 	for _, user := range users {
-		status := "正常"
-		if user.Status == 0 {
-			status = "禁用"
-		} else if user.Status == 2 {
-			status = "待审核"
+		status, ok := statusLabels[strconv.Itoa(user.Status)]
+		if !ok {
+			status = strconv.Itoa(user.Status)
+		}
+
+		var departmentCode, departmentName string
+		if user.Department != nil {
+			departmentCode = user.Department.Code
+			departmentName = user.Department.Name
 		}
 
-		line := fmt.Sprintf("%d,%s,%s,%s,%s,%s,%s\n",
-			user.ID, user.Username, user.Nickname, user.Email, user.Phone,
-			status, user.CreatedAt.Format("2006-01-02 15:04:05"))
+		// 多个岗位以 | 分隔，与导入格式保持一致
+		positionCodes := make([]string, 0, len(user.Positions))
+		positionNames := make([]string, 0, len(user.Positions))
+		for _, p := range user.Positions {
+			positionCodes = append(positionCodes, p.Code)
+			positionNames = append(positionNames, p.Name)
+		}
+
+		masked := user.Masked()
+		line := fmt.Sprintf("%d,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s\n",
+			user.ID, user.Username, user.Nickname, masked.Email, masked.Phone,
+			status, departmentCode, departmentName,
+			strings.Join(positionCodes, "|"), strings.Join(positionNames, "|"),
+			user.CreatedAt.Format("2006-01-02 15:04:05"))
 		file.Write([]byte(line))
 	}
 
@@ -487,6 +567,9 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		Email    string `json:"email" binding:"omitempty,email"`
 		Phone    string `json:"phone" binding:"omitempty,numeric,len=11"`
 		RoleID   uint   `json:"role_id"`
+
+		DepartmentID *uint  `json:"department_id"` // 不传则不修改，传0清除所属部门
+		PositionIDs  []uint `json:"position_ids"`  // 不传则不修改，传空数组清除岗位
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -523,6 +606,8 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		req.Email,
 		req.Phone,
 		req.RoleID,
+		req.DepartmentID,
+		req.PositionIDs,
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -548,6 +633,8 @@ func (h *UserHandler) GetUserProfile(c *gin.Context) {
 		return
 	}
 
+	revealSensitiveFields(c, user)
+
 	// 使用结构体来确保顺序
 	type UserProfile struct {
 		Username string `json:"username"`
@@ -555,14 +642,31 @@ func (h *UserHandler) GetUserProfile(c *gin.Context) {
 		Email    string `json:"email"`
 		Phone    string `json:"phone"`
 		RoleID   uint   `json:"role_id"`
+
+		DepartmentID *uint  `json:"department_id"`
+		PositionIDs  []uint `json:"position_ids"`
+	}
+
+	positions, err := h.userService.GetUserPositions(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	positionIDs := make([]uint, 0, len(positions))
+	for _, p := range positions {
+		positionIDs = append(positionIDs, p.ID)
 	}
 
+	masked := user.Masked()
 	profile := UserProfile{
 		Username: user.Username,
 		Nickname: user.Nickname,
-		Email:    user.Email,
-		Phone:    user.Phone,
+		Email:    masked.Email,
+		Phone:    masked.Phone,
 		RoleID:   user.RoleID,
+
+		DepartmentID: user.DepartmentID,
+		PositionIDs:  positionIDs,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -595,6 +699,9 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		Email    string `json:"email" binding:"omitempty,email"`
 		Phone    string `json:"phone" binding:"omitempty,numeric,len=11"`
 		RoleID   uint   `json:"role_id"`
+
+		DepartmentID *uint  `json:"department_id"` // 所属部门
+		PositionIDs  []uint `json:"position_ids"`  // 岗位
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -626,6 +733,8 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		req.Email,
 		req.Phone,
 		req.RoleID,
+		req.DepartmentID,
+		req.PositionIDs,
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -634,3 +743,36 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "创建成功"})
 }
+
+// revealSensitiveFields 对当前用户拥有字段级权限的敏感字段显示明文，其余敏感字段在序列化时脱敏
+func revealSensitiveFields(c *gin.Context, users ...*entity.User) {
+	var fields []string
+	for _, field := range []string{entity.UserFieldPhone, entity.UserFieldEmail} {
+		if hasFieldPermission(c, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	for _, user := range users {
+		user.Reveal(fields...)
+	}
+}
+
+// hasFieldPermission 检查当前用户是否拥有查看指定字段明文的权限
+func hasFieldPermission(c *gin.Context, field string) bool {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return false
+	}
+
+	permissionService, exists := c.Get("permissionService")
+	if !exists {
+		return false
+	}
+
+	return permissionService.(*service.PermissionService).HasPermission(
+		c.Request.Context(),
+		userID.(uint),
+		userFieldPermissionPrefix+field,
+	)
+}
@@ -50,9 +50,9 @@ func setupTestRouter() (*gin.Engine, error) {
 	// 初始化路由
 	r := gin.Default()
 	userRepo := repository.NewUserRepository(db)
-	userCache := cache.NewUserCache(rdb)
-	userService := service.NewUserService(userRepo, db, userCache)
-	userHandler := NewUserHandler(userService)
+	userCache := cache.NewRedisUserCache(rdb)
+	userService := service.NewUserService(userRepo, db, userCache, nil)
+	userHandler := NewUserHandler(userService, service.NewDictService(db, nil))
 
 	// 注册路由
 	r.POST("/api/v1/register", userHandler.Register)
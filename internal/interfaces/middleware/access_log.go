@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"fmt"
+	"gva/internal/pkg/redact"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog 访问日志中间件，格式与 gin 默认的访问日志相同
+// 查询参数中的敏感字段会被脱敏，如浏览器建立WebSocket连接时通过 ?token= 传递的JWT
+func AccessLog(skipPaths ...string) gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: accessLogFormatter,
+		SkipPaths: skipPaths,
+	})
+}
+
+func accessLogFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	path := param.Path
+	if p, query, ok := strings.Cut(path, "?"); ok {
+		path = p + "?" + redact.Form(query)
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		path,
+		param.ErrorMessage,
+	)
+}
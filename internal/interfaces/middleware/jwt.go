@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"gva/internal/domain/audit"
 	"gva/internal/pkg/jwt"
 	"log"
 	"net/http"
@@ -40,6 +41,11 @@ func JWTAuth() gin.HandlerFunc {
 
 		// 将用户ID存入上下文（确保是 uint 类型）
 		c.Set("userID", uint(claims.UserID))
+		// 同时作为操作人存入请求的上下文，服务记录审计事件时使用
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+			UserID: uint(claims.UserID),
+			IP:     c.ClientIP(),
+		}))
 		c.Next()
 	}
 }
@@ -4,23 +4,55 @@ import (
 	"bytes"
 	"gva/internal/domain/entity"
 	"gva/internal/domain/service"
+	"gva/internal/pkg/redact"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxCaptureSize 脱敏前最多缓存的响应内容长度，超过时不记录响应内容
+// 需要完整的内容才能解析JSON并脱敏，脱敏后再按配置的最大记录长度截断
+const maxCaptureSize = 1 << 20
+
+// hiddenBody 按路由配置隐藏的内容
+const hiddenBody = "[内容已按配置隐藏]"
+
 type responseWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body     *bytes.Buffer
+	capture  bool // 是否记录响应内容
+	size     int  // 响应内容的总长度
+	overflow bool // 响应内容超过 maxCaptureSize
 }
 
-func (w responseWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.record(b)
 	return w.ResponseWriter.Write(b)
 }
 
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseWriter) record(b []byte) {
+	w.size += len(b)
+	if !w.capture || w.overflow {
+		return
+	}
+	if w.body.Len()+len(b) > maxCaptureSize {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
+
 // OperationLog 操作日志中间件
+// 请求和响应内容中的敏感字段会被脱敏，multipart 上传和二进制内容只记录类型和长度，
+// 按路由配置隐藏的内容不会被记录，其余内容超过最大记录长度时截断
 func OperationLog(logService *service.OperationLogService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 跳过 OPTIONS 请求
@@ -32,20 +64,43 @@ func OperationLog(logService *service.OperationLogService) gin.HandlerFunc {
 		// 开始时间
 		startTime := time.Now()
 
-		// 获取请求信息
-		var requestBody []byte
-		if c.Request.Body != nil {
-			requestBody, _ = io.ReadAll(c.Request.Body)
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		hideRequest, hideResponse := redact.Route(c.Request.Method, path)
+
+		// 获取请求信息，multipart 上传的文件不读入内存
+		var request string
+		contentType := c.Request.Header.Get("Content-Type")
+		switch {
+		case hideRequest:
+			request = hiddenBody
+		case strings.HasPrefix(contentType, "multipart/"):
+			request = redact.Omitted(contentType, int(c.Request.ContentLength))
+		case c.Request.Body != nil:
+			requestBody, _ := io.ReadAll(c.Request.Body)
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+			request = redact.Body(contentType, requestBody)
 		}
 
 		// 包装 ResponseWriter 以获取响应内容
-		w := &responseWriter{body: &bytes.Buffer{}, ResponseWriter: c.Writer}
+		w := &responseWriter{body: &bytes.Buffer{}, capture: !hideResponse, ResponseWriter: c.Writer}
 		c.Writer = w
 
 		// 处理请求
 		c.Next()
 
+		var response string
+		switch {
+		case hideResponse:
+			response = hiddenBody
+		case w.overflow:
+			response = redact.Omitted(w.Header().Get("Content-Type"), w.size)
+		default:
+			response = redact.Body(w.Header().Get("Content-Type"), w.body.Bytes())
+		}
+
 		// 获取用户ID，如果未登录则为0
 		var userID uint = 0
 		if id, exists := c.Get("userID"); exists {
@@ -61,11 +116,12 @@ func OperationLog(logService *service.OperationLogService) gin.HandlerFunc {
 			Status:    c.Writer.Status(),
 			Latency:   time.Since(startTime).Milliseconds(),
 			UserAgent: c.Request.UserAgent(),
-			Request:   string(requestBody),
-			Response:  w.body.String(),
+			Request:   request,
+			Response:  response,
+			CreatedAt: startTime, // 按请求开始时间记录，而不是后台写入的时间
 		}
 
-		// 异步保存日志
-		go logService.Create(c.Request.Context(), log)
+		// 放入写入队列，由后台批量写入
+		logService.Record(log)
 	}
 }
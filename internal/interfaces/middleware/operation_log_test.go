@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gva/internal/domain/entity"
+	"gva/internal/domain/repository"
+	"gva/internal/domain/service"
+	"gva/internal/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOperationLogRepo 在内存中记录写入的日志
+type memoryOperationLogRepo struct {
+	mu   sync.Mutex
+	logs []*entity.OperationLog
+}
+
+func (r *memoryOperationLogRepo) Create(ctx context.Context, log *entity.OperationLog) error {
+	return r.CreateBatch(ctx, []*entity.OperationLog{log})
+}
+
+func (r *memoryOperationLogRepo) CreateBatch(ctx context.Context, logs []*entity.OperationLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, logs...)
+	return nil
+}
+
+func (r *memoryOperationLogRepo) List(ctx context.Context, page, size int, filter repository.OperationLogFilter) ([]*entity.OperationLog, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.logs, int64(len(r.logs)), nil
+}
+
+// 操作日志的时间为请求开始的时间，不受后台写入延迟的影响
+func TestOperationLogCreatedAt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &memoryOperationLogRepo{}
+	logService := service.NewOperationLogService(repo, config.OperationLogConfig{Workers: 1, FlushInterval: 3600000})
+
+	r := gin.New()
+	r.Use(OperationLog(logService))
+	r.GET("/ping", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"code": 0}) })
+
+	before := time.Now()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	after := time.Now()
+
+	// 请求结束一段时间后才写入
+	time.Sleep(50 * time.Millisecond)
+	logService.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, logService.Close(ctx))
+
+	require.Len(t, repo.logs, 1)
+	createdAt := repo.logs[0].CreatedAt
+	assert.False(t, createdAt.Before(before), "created_at = %v", createdAt)
+	assert.False(t, createdAt.After(after), "created_at = %v", createdAt)
+}
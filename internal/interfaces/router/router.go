@@ -1,11 +1,15 @@
 package router
 
 import (
+	"gva/internal/domain/cache"
 	"gva/internal/domain/service"
-	"gva/internal/infrastructure/repository"
+	infraCache "gva/internal/infrastructure/cache"
+	infraPush "gva/internal/infrastructure/push"
 	"gva/internal/interfaces/handler"
 	"gva/internal/interfaces/middleware"
+	"gva/internal/interfaces/validator"
 
+	"log"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -14,13 +18,11 @@ import (
 	"gorm.io/gorm"
 )
 
-func InitRouter(db *gorm.DB, rdb *redis.Client, userService *service.UserService) *gin.Engine {
-	r := gin.Default()
+func InitRouter(db *gorm.DB, rdb *redis.Client, userService *service.UserService, notificationService *service.NotificationService, messageService *service.MessageService, taskService *service.TaskService, logService *service.OperationLogService, logArchiver *service.OperationLogArchiver, auditService *service.AuditService, pushHub *infraPush.Hub) *gin.Engine {
+	r := gin.New()
 
-	// 添加路由日志
-	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{
-		SkipPaths: []string{"/health"},
-	}))
+	// 添加路由日志，查询参数中的token等敏感字段会被脱敏
+	r.Use(middleware.AccessLog("/health"), gin.Recovery())
 
 	// 添加 CORS 中间件
 	r.Use(cors.New(cors.Config{
@@ -36,10 +38,9 @@ func InitRouter(db *gorm.DB, rdb *redis.Client, userService *service.UserService
 	r.Static("/uploads", "./uploads")
 
 	// 初始化处理器
-	userHandler := handler.NewUserHandler(userService)
-	logRepo := repository.NewOperationLogRepository(db)
-	logService := service.NewOperationLogService(logRepo)
-	logHandler := handler.NewOperationLogHandler(logService)
+	logHandler := handler.NewOperationLogHandler(logService, logArchiver)
+	loginLogHandler := handler.NewLoginLogHandler(service.NewLoginLogService(db))
+	auditHandler := handler.NewAuditHandler(auditService)
 
 	// 添加操作日志中间件
 	r.Use(middleware.OperationLog(logService))
@@ -51,6 +52,36 @@ func InitRouter(db *gorm.DB, rdb *redis.Client, userService *service.UserService
 	roleService := service.NewRoleService(db)
 	roleHandler := handler.NewRoleHandler(roleService)
 
+	rbacService := service.NewRBACService(db)
+	rbacHandler := handler.NewRBACHandler(rbacService)
+
+	departmentService := service.NewDepartmentService(db)
+	departmentHandler := handler.NewDepartmentHandler(departmentService)
+
+	positionService := service.NewPositionService(db)
+	positionHandler := handler.NewPositionHandler(positionService)
+
+	// 字典缓存：配置了Redis时多实例共享，否则使用进程内缓存
+	var dictCache cache.DictCache = infraCache.NewMemoryDictCache()
+	if rdb != nil {
+		dictCache = infraCache.NewRedisDictCache(rdb)
+	}
+	dictService := service.NewDictService(db, dictCache)
+	dictHandler := handler.NewDictHandler(dictService)
+
+	// 注册字典校验规则，如 binding:"dict=user_status"
+	if err := validator.RegisterDictValidation(dictService); err != nil {
+		log.Fatalf("注册字典校验规则失败: %v", err)
+	}
+
+	userHandler := handler.NewUserHandler(userService, dictService)
+
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	messageHandler := handler.NewMessageHandler(messageService)
+	taskHandler := handler.NewTaskHandler(taskService)
+
+	pushHandler := handler.NewPushHandler(pushHub, userService)
+
 	// 将权限服务添加到全局上下文
 	r.Use(func(c *gin.Context) {
 		c.Set("permissionService", permissionService)
@@ -62,6 +93,9 @@ func InitRouter(db *gorm.DB, rdb *redis.Client, userService *service.UserService
 	{
 		public.POST("/register", userHandler.Register)
 		public.POST("/login", userHandler.Login)
+
+		// 实时推送（WebSocket，在升级时校验JWT）
+		public.GET("/ws", pushHandler.Connect)
 	}
 
 	// 需要认证的路由
@@ -76,6 +110,13 @@ func InitRouter(db *gorm.DB, rdb *redis.Client, userService *service.UserService
 			userBase.PUT("/profile", userHandler.UpdateProfile)
 			userBase.POST("/reset-password", userHandler.ResetPassword)
 			userBase.POST("/avatar", userHandler.UploadAvatar)
+
+			// 个人收件箱
+			userBase.GET("/notifications", notificationHandler.Inbox)                     // 收件箱（?read_status=0 仅未读）
+			userBase.GET("/notifications/unread-count", notificationHandler.UnreadCount)  // 未读数量
+			userBase.PUT("/notifications/read-all", notificationHandler.MarkAllRead)      // 全部标记为已读
+			userBase.PUT("/notifications/:id/read", notificationHandler.MarkRead)         // 标记为已读
+			userBase.DELETE("/notifications/:id", notificationHandler.DeleteNotification) // 删除通知
 		}
 
 		// 用户管理相关（需要用户管理权限）
@@ -111,9 +152,97 @@ func InitRouter(db *gorm.DB, rdb *redis.Client, userService *service.UserService
 			roleManage.GET("/:id", roleHandler.GetRole)                        // 获取单个角色
 			roleManage.POST("", roleHandler.CreateRole)                        // 创建角色
 			roleManage.PUT("/:id", roleHandler.UpdateRole)                     // 更新角色
-			roleManage.DELETE("/:id", roleHandler.DeleteRole)                  // 删除角色
+			roleManage.DELETE("/:id", roleHandler.DeleteRole)                  // 删除角色（?transfer_role_id= 转移角色下的用户）
 			roleManage.GET("/:id/permissions", roleHandler.GetPermissions)     // 获取角色权限
 			roleManage.POST("/:id/permissions", roleHandler.AssignPermissions) // 分配权限
+			roleManage.POST("/:id/clone", roleHandler.CloneRole)               // 复制角色及其权限
+			roleManage.GET("/policy/export", rbacHandler.ExportPolicy)         // 导出RBAC策略（YAML）
+
+			// 导入RBAC策略会修改权限，额外要求权限管理权限（?dry_run=true 仅预览差异）
+			roleManage.POST("/policy/import", middleware.CheckPermission("system:permission"), rbacHandler.ImportPolicy)
+		}
+
+		// 部门管理
+		deptManage := authorized.Group("/departments")
+		deptManage.Use(middleware.CheckPermission("system:dept"))
+		{
+			deptManage.GET("/tree", departmentHandler.GetTree)            // 获取部门树
+			deptManage.GET("/:id", departmentHandler.GetDepartment)       // 获取单个部门
+			deptManage.POST("", departmentHandler.CreateDepartment)       // 创建部门
+			deptManage.PUT("/:id", departmentHandler.UpdateDepartment)    // 更新部门
+			deptManage.PUT("/:id/move", departmentHandler.MoveDepartment) // 移动部门
+			deptManage.DELETE("/:id", departmentHandler.DeleteDepartment) // 删除部门
+		}
+
+		// 岗位管理
+		positionManage := authorized.Group("/positions")
+		positionManage.Use(middleware.CheckPermission("system:position"))
+		{
+			positionManage.GET("", positionHandler.ListPositions)         // 获取岗位列表
+			positionManage.GET("/:id", positionHandler.GetPosition)       // 获取单个岗位
+			positionManage.POST("", positionHandler.CreatePosition)       // 创建岗位
+			positionManage.PUT("/:id", positionHandler.UpdatePosition)    // 更新岗位
+			positionManage.DELETE("/:id", positionHandler.DeletePosition) // 删除岗位
+		}
+
+		// 数据字典查询（所有登录用户可用）
+		dictQuery := authorized.Group("/dicts")
+		{
+			dictQuery.GET("/items", dictHandler.BatchGetDictItems)  // 批量获取字典项（?codes=a,b）
+			dictQuery.GET("/:code/items", dictHandler.GetDictItems) // 获取字典项
+		}
+
+		// 数据字典管理
+		dictManage := authorized.Group("/dicts")
+		dictManage.Use(middleware.CheckPermission("system:dict"))
+		{
+			dictManage.GET("", dictHandler.ListDicts)                                      // 获取字典列表（包含字典项）
+			dictManage.POST("", dictHandler.CreateDict)                                    // 创建字典
+			dictManage.PUT("/:id", dictHandler.UpdateDict)                                 // 更新字典
+			dictManage.DELETE("/:id", dictHandler.DeleteDict)                              // 删除字典及其字典项
+			dictManage.POST("/:id/items", dictHandler.CreateDictItem)                      // 添加字典项
+			dictManage.PUT("/:id/items/:item_id", dictHandler.UpdateDictItem)              // 更新字典项
+			dictManage.PUT("/:id/items/:item_id/status", dictHandler.UpdateDictItemStatus) // 启用/禁用字典项
+			dictManage.DELETE("/:id/items/:item_id", dictHandler.DeleteDictItem)           // 删除字典项
+		}
+
+		// 通知管理
+		notificationManage := authorized.Group("/notifications")
+		notificationManage.Use(middleware.CheckPermission("system:notification"))
+		{
+			notificationManage.GET("", notificationHandler.ListNotifications) // 获取已发送的通知
+			notificationManage.POST("", notificationHandler.SendNotification) // 发送通知给指定用户、角色、部门或全体用户
+		}
+
+		// 消息模板与多渠道投递
+		messageManage := authorized.Group("/messages")
+		messageManage.Use(middleware.CheckPermission("system:message"))
+		{
+			messageManage.GET("/templates", messageHandler.ListTemplates)             // 获取消息模板列表
+			messageManage.GET("/templates/:id", messageHandler.GetTemplate)           // 获取消息模板
+			messageManage.POST("/templates", messageHandler.CreateTemplate)           // 创建消息模板
+			messageManage.PUT("/templates/:id", messageHandler.UpdateTemplate)        // 更新消息模板
+			messageManage.DELETE("/templates/:id", messageHandler.DeleteTemplate)     // 删除消息模板
+			messageManage.POST("/send", messageHandler.SendMessage)                   // 按模板发送消息
+			messageManage.GET("/deliveries", messageHandler.ListDeliveries)           // 查询投递记录
+			messageManage.POST("/deliveries/:id/retry", messageHandler.RetryDelivery) // 重新投递
+		}
+
+		// 定时任务管理
+		taskManage := authorized.Group("/tasks")
+		taskManage.Use(middleware.CheckPermission("system:task"))
+		{
+			taskManage.GET("", taskHandler.ListTasks)                 // 获取定时任务列表
+			taskManage.GET("/preview", taskHandler.PreviewCron)       // 校验cron表达式并预览执行时间
+			taskManage.GET("/handlers", taskHandler.ListTaskHandlers) // 获取可用的任务类型
+			taskManage.GET("/:id", taskHandler.GetTask)               // 获取定时任务
+			taskManage.POST("", taskHandler.CreateTask)               // 创建定时任务
+			taskManage.PUT("/:id", taskHandler.UpdateTask)            // 更新定时任务
+			taskManage.DELETE("/:id", taskHandler.DeleteTask)         // 删除定时任务
+			taskManage.PUT("/:id/pause", taskHandler.PauseTask)       // 暂停定时任务
+			taskManage.PUT("/:id/resume", taskHandler.ResumeTask)     // 恢复定时任务
+			taskManage.POST("/:id/run", taskHandler.RunTask)          // 立即执行一次
+			taskManage.GET("/:id/logs", taskHandler.ListTaskLogs)     // 获取执行日志
 		}
 
 		// 日志管理（需要日志查看权限）
@@ -121,6 +250,14 @@ func InitRouter(db *gorm.DB, rdb *redis.Client, userService *service.UserService
 		logManage.Use(middleware.CheckPermission("system:log"))
 		{
 			logManage.GET("/logs", logHandler.ListLogs)
+			logManage.GET("/logs/stats", logHandler.GetLogStats)                         // 操作日志写入队列的统计信息
+			logManage.GET("/logs/archives", logHandler.ListArchives)                     // 获取归档文件列表
+			logManage.POST("/logs/archives", logHandler.ArchiveLogs)                     // 立即归档过期日志
+			logManage.GET("/logs/archives/:name", logHandler.DownloadArchive)            // 下载归档文件
+			logManage.GET("/login-logs", loginLogHandler.ListLoginLogs)                  // 获取登录日志
+			logManage.GET("/audit-events", auditHandler.ListAuditEvents)                 // 获取审计事件
+			logManage.GET("/audit-events/verify", auditHandler.VerifyAuditChain)         // 校验审计事件哈希链
+			logManage.GET("/audit-events/:type/:id", auditHandler.ListEntityAuditEvents) // 获取指定实体的变更历史
 		}
 	}
 
@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"gva/internal/domain/entity"
+	"log"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin/binding"
+	playground "github.com/go-playground/validator/v10"
+)
+
+// DictTag 字典校验规则的标签名，用法如 binding:"dict=user_status"
+const DictTag = "dict"
+
+// DictLookup 按编码查询已启用的字典（只包含已启用的字典项）
+type DictLookup interface {
+	GetEnabledDict(ctx context.Context, code string) (*entity.Dict, error)
+}
+
+// RegisterDictValidation 为 gin 的参数绑定注册字典校验规则，
+// 字段的值必须是指定字典中已启用的字典项的值
+func RegisterDictValidation(lookup DictLookup) error {
+	v, ok := binding.Validator.Engine().(*playground.Validate)
+	if !ok {
+		return errors.New("不支持的参数校验引擎")
+	}
+	return v.RegisterValidation(DictTag, dictValidation(lookup))
+}
+
+func dictValidation(lookup DictLookup) playground.Func {
+	return func(fl playground.FieldLevel) bool {
+		value, ok := fieldString(fl.Field())
+		if !ok {
+			return false
+		}
+
+		dict, err := lookup.GetEnabledDict(context.Background(), fl.Param())
+		if err != nil {
+			log.Printf("字典校验失败 - Dict: %s, Error: %v", fl.Param(), err)
+			return false
+		}
+
+		for _, item := range dict.Items {
+			if item.Value == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// fieldString 将字段的值转换为与字典项值比较的字符串
+func fieldString(field reflect.Value) (string, bool) {
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), true
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,65 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gva/internal/domain/entity"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+type fakeDictLookup map[string]*entity.Dict
+
+func (f fakeDictLookup) GetEnabledDict(ctx context.Context, code string) (*entity.Dict, error) {
+	if dict, ok := f[code]; ok {
+		return dict, nil
+	}
+	return nil, errors.New("字典不存在")
+}
+
+func TestDictValidation(t *testing.T) {
+	lookup := fakeDictLookup{
+		"user_status": {
+			Code: "user_status",
+			Items: []entity.DictItem{
+				{Label: "禁用", Value: "0"},
+				{Label: "正常", Value: "1"},
+			},
+		},
+	}
+	if err := RegisterDictValidation(lookup); err != nil {
+		t.Fatalf("注册校验规则失败: %v", err)
+	}
+
+	type statusRequest struct {
+		Status *int `binding:"required,dict=user_status"`
+	}
+	type typeRequest struct {
+		Type string `binding:"omitempty,dict=unknown"`
+	}
+
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name    string
+		req     interface{}
+		wantErr bool
+	}{
+		{"零值是有效的字典项", &statusRequest{Status: intPtr(0)}, false},
+		{"有效的字典项", &statusRequest{Status: intPtr(1)}, false},
+		{"不在字典中的值", &statusRequest{Status: intPtr(2)}, true},
+		{"缺少必填字段", &statusRequest{}, true},
+		{"空值跳过校验", &typeRequest{}, false},
+		{"字典不存在", &typeRequest{Type: "menu"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
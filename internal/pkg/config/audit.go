@@ -0,0 +1,6 @@
+package config
+
+// AuditConfig 审计日志配置
+type AuditConfig struct {
+	HMACKey string `mapstructure:"hmac_key"` // 审计检查点的 HMAC 签名密钥，为空时不生成检查点，也不校验检查点签名
+}
@@ -0,0 +1,15 @@
+package config
+
+// MaskConfig 敏感字段脱敏配置
+type MaskConfig struct {
+	Fields map[string]MaskRule `mapstructure:"fields"` // 按字段名配置的脱敏规则
+}
+
+// MaskRule 脱敏规则
+type MaskRule struct {
+	KeepPrefix int    `mapstructure:"keep_prefix"` // 保留开头字符数
+	KeepSuffix int    `mapstructure:"keep_suffix"` // 保留结尾字符数
+	MaskChar   string `mapstructure:"mask_char"`   // 掩码字符
+	MaskLen    int    `mapstructure:"mask_len"`    // 固定掩码长度，0 表示与被遮盖部分等长
+	Email      bool   `mapstructure:"email"`       // 是否只对邮箱 @ 之前的部分脱敏
+}
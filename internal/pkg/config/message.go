@@ -0,0 +1,24 @@
+package config
+
+// MessageConfig 消息投递配置
+type MessageConfig struct {
+	DefaultLocale string        `mapstructure:"default_locale"` // 默认语言，模板缺少请求的语言时使用
+	SMTP          SMTPConfig    `mapstructure:"smtp"`
+	Webhook       WebhookConfig `mapstructure:"webhook"`
+}
+
+// SMTPConfig 邮件发送配置，Host 为空时不启用邮件渠道
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"` // 465 端口使用 SSL 直连，其他端口在服务器支持时使用 STARTTLS
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"` // 发件人地址
+}
+
+// WebhookConfig Webhook 投递配置，URL 为空时不启用 Webhook 渠道
+type WebhookConfig struct {
+	URL     string `mapstructure:"url"`
+	Secret  string `mapstructure:"secret"`  // 签名密钥，配置后在 X-Signature 请求头中携带 HMAC-SHA256 签名
+	Timeout int    `mapstructure:"timeout"` // 请求超时时间（秒），默认10秒
+}
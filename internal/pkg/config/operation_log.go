@@ -0,0 +1,32 @@
+package config
+
+// OperationLogConfig 操作日志配置
+type OperationLogConfig struct {
+	RedactKeys   []string      `mapstructure:"redact_keys"`   // 需要脱敏的JSON或表单字段名（不区分大小写），会追加到默认字段之后
+	RedactRoutes []RedactRoute `mapstructure:"redact_routes"` // 按路由不记录请求或响应内容
+	MaxBodySize  int           `mapstructure:"max_body_size"` // 请求和响应内容的最大记录长度（字节），默认4096
+
+	QueueSize     int    `mapstructure:"queue_size"`     // 待写入日志的队列长度，默认10000
+	Workers       int    `mapstructure:"workers"`        // 批量写入的协程数，默认2
+	BatchSize     int    `mapstructure:"batch_size"`     // 每批写入的最大条数，默认100
+	FlushInterval int    `mapstructure:"flush_interval"` // 未攒满一批时的最长写入间隔（毫秒），默认1000
+	FullPolicy    string `mapstructure:"full_policy"`    // 队列已满时的策略：drop 直接丢弃（默认），block 等待队列空闲
+	BlockTimeout  int    `mapstructure:"block_timeout"`  // block 策略的最长等待时间（毫秒），超时后丢弃，0 表示一直等待
+
+	Retention OperationLogRetention `mapstructure:"retention"` // 保留策略
+}
+
+// OperationLogRetention 操作日志保留策略，超过保留天数的日志归档为 gzip 压缩的 JSON Lines 文件后删除
+type OperationLogRetention struct {
+	Days       int    `mapstructure:"days"`        // 保留天数，默认90
+	ArchiveDir string `mapstructure:"archive_dir"` // 归档文件目录，默认 storage/archives/operation_logs
+	BatchSize  int    `mapstructure:"batch_size"`  // 每批归档和删除的条数，默认1000
+}
+
+// RedactRoute 按路由隐藏请求或响应内容
+type RedactRoute struct {
+	Method   string `mapstructure:"method"`   // 请求方法，为空时匹配所有方法
+	Path     string `mapstructure:"path"`     // 路由路径，与注册路由时的写法一致，如 /api/v1/users/:id/reset-password
+	Request  bool   `mapstructure:"request"`  // 是否隐藏请求内容
+	Response bool   `mapstructure:"response"` // 是否隐藏响应内容
+}
@@ -0,0 +1,72 @@
+package mask
+
+import (
+	"strings"
+	"sync"
+
+	"gva/internal/pkg/config"
+)
+
+var (
+	mu sync.RWMutex
+	// 默认脱敏规则：138****1234、a***@example.com
+	rules = map[string]config.MaskRule{
+		"phone": {KeepPrefix: 3, KeepSuffix: 4, MaskChar: "*"},
+		"email": {KeepPrefix: 1, MaskChar: "*", MaskLen: 3, Email: true},
+	}
+)
+
+// Configure 使用配置覆盖默认脱敏规则
+func Configure(cfg config.MaskConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+	for field, rule := range cfg.Fields {
+		if rule.MaskChar == "" {
+			rule.MaskChar = "*"
+		}
+		rules[field] = rule
+	}
+}
+
+// Field 按字段名对应的规则脱敏，未配置规则的字段原样返回
+func Field(field, value string) string {
+	mu.RLock()
+	rule, ok := rules[field]
+	mu.RUnlock()
+	if !ok || value == "" {
+		return value
+	}
+	return Apply(rule, value)
+}
+
+// Apply 按指定规则脱敏
+func Apply(rule config.MaskRule, value string) string {
+	if rule.Email {
+		if at := strings.LastIndex(value, "@"); at >= 0 {
+			return mask(rule, value[:at]) + value[at:]
+		}
+	}
+	return mask(rule, value)
+}
+
+func mask(rule config.MaskRule, value string) string {
+	runes := []rune(value)
+	maskChar := rule.MaskChar
+	if maskChar == "" {
+		maskChar = "*"
+	}
+
+	// 长度不足以保留首尾时全部遮盖
+	if len(runes) <= rule.KeepPrefix+rule.KeepSuffix {
+		return strings.Repeat(maskChar, len(runes))
+	}
+
+	maskLen := rule.MaskLen
+	if maskLen <= 0 {
+		maskLen = len(runes) - rule.KeepPrefix - rule.KeepSuffix
+	}
+
+	return string(runes[:rule.KeepPrefix]) +
+		strings.Repeat(maskChar, maskLen) +
+		string(runes[len(runes)-rule.KeepSuffix:])
+}
@@ -0,0 +1,43 @@
+package mask
+
+import (
+	"testing"
+
+	"gva/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+		want  string
+	}{
+		{"phone", "13812341234", "138****1234"},
+		{"phone", "1234", "****"},
+		{"email", "alice@example.com", "a***@example.com"},
+		{"email", "a@example.com", "*@example.com"},
+		{"email", "", ""},
+		{"nickname", "张三", "张三"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Field(tt.field, tt.value), "%s=%s", tt.field, tt.value)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	Configure(config.MaskConfig{
+		Fields: map[string]config.MaskRule{
+			"nickname": {KeepPrefix: 1},
+		},
+	})
+	defer func() {
+		mu.Lock()
+		delete(rules, "nickname")
+		mu.Unlock()
+	}()
+
+	assert.Equal(t, "张**", Field("nickname", "张小三"))
+}
@@ -0,0 +1,182 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gva/internal/pkg/config"
+)
+
+// Placeholder 被脱敏字段的替换值
+const Placeholder = "******"
+
+// defaultMaxBodySize 默认的最大记录长度（字节）
+const defaultMaxBodySize = 4096
+
+var (
+	mu sync.RWMutex
+	// 默认脱敏的字段
+	keys = map[string]bool{
+		"password":      true,
+		"old_password":  true,
+		"new_password":  true,
+		"token":         true,
+		"authorization": true,
+	}
+	routes      []config.RedactRoute
+	maxBodySize = defaultMaxBodySize
+)
+
+// Configure 使用配置追加脱敏字段、按路由隐藏的内容和最大记录长度
+func Configure(cfg config.OperationLogConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, key := range cfg.RedactKeys {
+		keys[strings.ToLower(key)] = true
+	}
+	routes = append(routes, cfg.RedactRoutes...)
+	if cfg.MaxBodySize > 0 {
+		maxBodySize = cfg.MaxBodySize
+	}
+}
+
+// MaxBodySize 返回最大记录长度
+func MaxBodySize() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return maxBodySize
+}
+
+// Route 返回路由是否需要隐藏请求内容和响应内容，path 为注册路由时的路径
+func Route(method, path string) (hideRequest, hideResponse bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, route := range routes {
+		if route.Path != path || (route.Method != "" && !strings.EqualFold(route.Method, method)) {
+			continue
+		}
+		hideRequest = hideRequest || route.Request
+		hideResponse = hideResponse || route.Response
+	}
+	return hideRequest, hideResponse
+}
+
+// Body 按内容类型脱敏并截断请求或响应内容
+// JSON 和表单内容中的敏感字段被替换为 Placeholder，multipart 和二进制内容只记录类型和长度
+func Body(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return omitted(mediaType, len(body))
+	case mediaType == "application/x-www-form-urlencoded":
+		return Truncate(Form(string(body)))
+	case mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		// 未声明类型的内容按JSON尝试脱敏
+		if redacted, ok := JSON(body); ok {
+			return Truncate(redacted)
+		}
+		if mediaType == "" && !utf8.Valid(body) {
+			return omitted("application/octet-stream", len(body))
+		}
+		return Truncate(string(body))
+	case strings.HasPrefix(mediaType, "text/"):
+		return Truncate(string(body))
+	default:
+		return omitted(mediaType, len(body))
+	}
+}
+
+// Omitted 返回未记录内容时的说明
+func Omitted(contentType string, size int) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return omitted(mediaType, size)
+}
+
+func omitted(mediaType string, size int) string {
+	if mediaType == "" {
+		mediaType = "unknown"
+	}
+	if size < 0 {
+		return "[" + mediaType + " 内容未记录]"
+	}
+	return "[" + mediaType + " 内容未记录，共 " + strconv.Itoa(size) + " 字节]"
+}
+
+// JSON 脱敏JSON中的敏感字段（包括嵌套对象和数组中的字段），内容不是合法JSON时返回 false
+func JSON(body []byte) (string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", false
+	}
+
+	mu.RLock()
+	value = redactValue(value)
+	mu.RUnlock()
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+// redactValue 递归替换敏感字段的值，调用方需持有读锁
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if keys[strings.ToLower(key)] {
+				v[key] = Placeholder
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// Form 脱敏 URL 编码的表单或查询参数中的敏感字段，保持参数原有的顺序
+func Form(body string) string {
+	pairs := strings.Split(body, "&")
+
+	mu.RLock()
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); err == nil && keys[strings.ToLower(name)] {
+			pairs[i] = key + "=" + Placeholder
+		}
+	}
+	mu.RUnlock()
+	return strings.Join(pairs, "&")
+}
+
+// Truncate 将内容截断到最大记录长度，不会截断在多字节字符中间
+func Truncate(s string) string {
+	limit := MaxBodySize()
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "...(已截断，共 " + strconv.Itoa(len(s)) + " 字节)"
+}
@@ -0,0 +1,66 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+
+	"gva/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"application/json", `{"username":"admin","password":"123456"}`, `{"password":"******","username":"admin"}`},
+		{"application/json; charset=utf-8", `{"code":200,"data":{"token":"abc","user":{"id":1}}}`, `{"code":200,"data":{"token":"******","user":{"id":1}}}`},
+		{"application/json", `[{"Old_Password":"a","new_password":"b"}]`, `[{"Old_Password":"******","new_password":"******"}]`},
+		{"", `{"Authorization":"Bearer x"}`, `{"Authorization":"******"}`},
+		{"application/json", `not json`, `not json`},
+		{"application/x-www-form-urlencoded", "username=admin&password=123456", "username=admin&password=******"},
+		{"multipart/form-data; boundary=x", "--x\r\n...", "[multipart/form-data 内容未记录，共 8 字节]"},
+		{"text/csv", "id,name\n1,a", "id,name\n1,a"},
+		{"application/octet-stream", "\x00\x01", "[application/octet-stream 内容未记录，共 2 字节]"},
+		{"application/json", "", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Body(tt.contentType, []byte(tt.body)), "%s %s", tt.contentType, tt.body)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	Configure(config.OperationLogConfig{
+		RedactKeys: []string{"Secret"},
+		RedactRoutes: []config.RedactRoute{
+			{Method: "POST", Path: "/api/v1/users/:id/reset-password", Request: true},
+			{Path: "/api/v1/messages/deliveries", Response: true},
+		},
+		MaxBodySize: 20,
+	})
+	defer func() {
+		mu.Lock()
+		delete(keys, "secret")
+		routes = nil
+		maxBodySize = defaultMaxBodySize
+		mu.Unlock()
+	}()
+
+	assert.Equal(t, `{"secret":"******"}`, Body("application/json", []byte(`{"secret":"s"}`)))
+
+	hideRequest, hideResponse := Route("post", "/api/v1/users/:id/reset-password")
+	assert.True(t, hideRequest)
+	assert.False(t, hideResponse)
+	hideRequest, _ = Route("GET", "/api/v1/users/:id/reset-password")
+	assert.False(t, hideRequest)
+	_, hideResponse = Route("GET", "/api/v1/messages/deliveries")
+	assert.True(t, hideResponse)
+
+	// 截断不会拆开多字节字符
+	truncated := Truncate("中文内容很长很长很长")
+	assert.True(t, strings.HasPrefix(truncated, "中文内容很长"), truncated)
+	assert.Contains(t, truncated, "已截断")
+}
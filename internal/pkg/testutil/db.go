@@ -3,34 +3,24 @@ package testutil
 import (
 	infraconfig "gva/internal/infrastructure/config"
 	"gva/internal/infrastructure/database"
-	"gva/internal/pkg/config"
 
 	"gorm.io/gorm"
 )
 
 var testDB *gorm.DB
 
+// GetTestDB 按项目根目录下的 configs/config.yaml 连接测试数据库
 func GetTestDB() (*gorm.DB, error) {
 	if testDB != nil {
 		return testDB, nil
 	}
 
-	cfg, err := infraconfig.Load()
+	cfg, err := infraconfig.LoadConfig("configs/config.yaml")
 	if err != nil {
 		return nil, err
 	}
 
-	mysqlConfig := config.MySQLConfig{
-		Host:         cfg.MySQL.Host,
-		Port:         cfg.MySQL.Port,
-		Username:     cfg.MySQL.Username,
-		Password:     cfg.MySQL.Password,
-		Database:     cfg.MySQL.Database,
-		MaxIdleConns: cfg.MySQL.MaxIdleConns,
-		MaxOpenConns: cfg.MySQL.MaxOpenConns,
-	}
-
-	db, err := database.NewMySQLConnection(mysqlConfig)
+	db, err := database.NewMySQLDB(&cfg.MySQL)
 	if err != nil {
 		return nil, err
 	}
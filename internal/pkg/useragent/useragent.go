@@ -0,0 +1,199 @@
+package useragent
+
+import (
+	"strings"
+)
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop" // 桌面电脑
+	DeviceMobile  = "mobile"  // 手机
+	DeviceTablet  = "tablet"  // 平板
+	DeviceBot     = "bot"     // 爬虫、命令行工具等程序
+)
+
+// Info 从 User-Agent 解析出的客户端信息，无法识别的字段为空
+type Info struct {
+	Browser string `json:"browser"` // 浏览器及主版本号，如 Chrome 120
+	OS      string `json:"os"`      // 操作系统及版本号，如 Windows 10、iOS 17.1
+	Device  string `json:"device"`  // 设备类型
+}
+
+// browserRule 浏览器识别规则，token 为 User-Agent 中浏览器版本号前的标识
+type browserRule struct {
+	token string
+	name  string
+}
+
+// browsers 浏览器识别规则，基于 Chromium 和 WebKit 的浏览器同时带有 Chrome、Safari 标识，需要排在前面
+var browsers = []browserRule{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"MicroMessenger/", "WeChat"},
+	{"DingTalk/", "DingTalk"},
+	{"SamsungBrowser/", "Samsung Browser"},
+	{"UCBrowser/", "UC Browser"},
+	{"QQBrowser/", "QQ Browser"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"MSIE ", "IE"},
+}
+
+// bots 程序客户端的识别规则
+var bots = []browserRule{
+	{"curl/", "curl"},
+	{"Wget/", "Wget"},
+	{"PostmanRuntime/", "Postman"},
+	{"python-requests/", "Python Requests"},
+	{"Go-http-client/", "Go HTTP Client"},
+	{"okhttp/", "OkHttp"},
+	{"Apache-HttpClient/", "Apache HttpClient"},
+}
+
+// Parse 解析 User-Agent，识别浏览器、操作系统和设备类型
+func Parse(ua string) Info {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return Info{}
+	}
+
+	if name, ok := parseBot(ua); ok {
+		return Info{Browser: name, Device: DeviceBot}
+	}
+
+	info := Info{Browser: parseBrowser(ua), OS: parseOS(ua)}
+	info.Device = parseDevice(ua, info.OS)
+	return info
+}
+
+func parseBot(ua string) (string, bool) {
+	for _, rule := range bots {
+		if v, ok := version(ua, rule.token); ok {
+			return join(rule.name, major(v)), true
+		}
+	}
+	// 爬虫的名称取包含 bot、spider、crawler 的产品标识，如 Googlebot/2.1
+	fields := strings.FieldsFunc(ua, func(r rune) bool {
+		return r == ' ' || r == ';' || r == '(' || r == ')' || r == '+'
+	})
+	for _, field := range fields {
+		lower := strings.ToLower(field)
+		if strings.Contains(lower, "bot") || strings.Contains(lower, "spider") || strings.Contains(lower, "crawler") {
+			name, _, _ := strings.Cut(field, "/")
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func parseBrowser(ua string) string {
+	for _, rule := range browsers {
+		if v, ok := version(ua, rule.token); ok {
+			return join(rule.name, major(v))
+		}
+	}
+	if strings.Contains(ua, "Trident/") {
+		// IE 11 不再带有 MSIE 标识，版本号位于 rv: 之后
+		v, _ := version(ua, "rv:")
+		return join("IE", major(v))
+	}
+	if strings.Contains(ua, "Safari/") {
+		v, _ := version(ua, "Version/")
+		return join("Safari", major(v))
+	}
+	return ""
+}
+
+func parseOS(ua string) string {
+	switch {
+	case strings.Contains(ua, "Windows Phone"):
+		return "Windows Phone"
+	case strings.Contains(ua, "Windows NT "):
+		v, _ := version(ua, "Windows NT ")
+		return join("Windows", windowsVersion(v))
+	case strings.Contains(ua, "iPad"):
+		v, _ := version(ua, "CPU OS ")
+		return join("iPadOS", v)
+	case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod"):
+		v, _ := version(ua, "iPhone OS ")
+		return join("iOS", v)
+	case strings.Contains(ua, "Android"):
+		v, _ := version(ua, "Android ")
+		return join("Android", v)
+	case strings.Contains(ua, "HarmonyOS"):
+		return "HarmonyOS"
+	case strings.Contains(ua, "Mac OS X"):
+		v, _ := version(ua, "Mac OS X ")
+		return join("macOS", v)
+	case strings.Contains(ua, "CrOS"):
+		return "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	}
+	return ""
+}
+
+func parseDevice(ua, os string) string {
+	switch {
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet"):
+		return DeviceTablet
+	case strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile"):
+		// Android 平板的 User-Agent 不带 Mobile 标识
+		return DeviceTablet
+	case strings.Contains(ua, "Mobile") || strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod") || strings.Contains(ua, "Windows Phone"):
+		return DeviceMobile
+	case os != "":
+		return DeviceDesktop
+	}
+	return ""
+}
+
+// windowsVersion 将 Windows NT 内核版本转换为发行版本，Windows 11 与 Windows 10 的标识相同
+func windowsVersion(nt string) string {
+	switch nt {
+	case "10.0":
+		return "10"
+	case "6.3":
+		return "8.1"
+	case "6.2":
+		return "8"
+	case "6.1":
+		return "7"
+	case "6.0":
+		return "Vista"
+	case "5.1", "5.2":
+		return "XP"
+	}
+	return nt
+}
+
+// version 返回 token 之后的版本号，iOS 和 macOS 版本号中的下划线转换为点
+func version(ua, token string) (string, bool) {
+	i := strings.Index(ua, token)
+	if i < 0 {
+		return "", false
+	}
+	rest := ua[i+len(token):]
+	end := 0
+	for end < len(rest) && (rest[end] >= '0' && rest[end] <= '9' || rest[end] == '.' || rest[end] == '_') {
+		end++
+	}
+	return strings.Trim(strings.ReplaceAll(rest[:end], "_", "."), "."), true
+}
+
+// major 返回版本号的主版本号
+func major(v string) string {
+	m, _, _ := strings.Cut(v, ".")
+	return m
+}
+
+func join(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + " " + version
+}